@@ -0,0 +1,454 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AcquireOption configures a single AcquireLease call.
+type AcquireOption func(*acquireConfig)
+
+type acquireConfig struct {
+	token    string
+	minToken uint64    // Minimum stored fencing token, see AcquireIfTokenAtLeast.
+	expiry   time.Time // Target expiry, see AcquireUntil. Zero for AcquireLease.
+}
+
+// WithIdempotencyToken tags the acquisition with token. Retrying an
+// acquisition with the token of the last successful one, while candidate still
+// holds the lease, is a no-op that returns the stored lease, which makes
+// AcquireLease safe to retry after an ambiguous failure.
+func WithIdempotencyToken(token string) AcquireOption {
+	return func(c *acquireConfig) {
+		c.token = token
+	}
+}
+
+// AcquireResult is the outcome of a successful AcquireLease.
+type AcquireResult struct {
+	// Lease is the lease as written.
+	*le.Lease
+	// FirstAcquisition is set when this acquisition created the lease, which
+	// makes the candidate its first leader ever. Callers can run one-time
+	// initialization on it.
+	FirstAcquisition bool
+	// FencingToken is the stored leader_transitions, see AcquireIfTokenAtLeast.
+	// Lease.LeaderTransitions only holds its low 32 bits, which wrap around
+	// after 2^32 transitions; the token does not.
+	FencingToken uint64
+}
+
+// AcquireLease acquires the lease for candidate, or renews it if candidate
+// already holds it, in a single atomic findAndModify. The lease can be acquired
+// if it does not exist, has no holder or has expired (see IsExpired);
+// otherwise ErrLeaseHeld is returned.
+//
+// Acquiring a lease with a different holder resets acquire_time and
+// increments leader_transitions, renewing keeps both.
+//
+// Unlike the GetLease, CreateLease and UpdateLease sequence of the elector,
+// there is no window between reading the lease and writing it, and the hot
+// path takes a single round trip: the expiry check is part of the filter and a
+// missing lease is upserted.
+func (s *Store) AcquireLease(ctx context.Context, candidate string, duration time.Duration, opts ...AcquireOption) (*AcquireResult, error) {
+	return s.acquire(ctx, candidate, func(time.Time) (time.Duration, error) { return duration, nil }, opts)
+}
+
+// AcquireUntil is like AcquireLease, but the lease expires at expiry rather
+// than after a duration: lease_duration is derived as expiry minus the stored
+// renew_time, which is taken from the store's clock (the server's with a
+// ClusterClock or WithServerTimestamps). expiry must be in the future; with
+// WithServerTimestamps, an expiry the server's clock has already passed
+// writes a zero duration.
+func (s *Store) AcquireUntil(ctx context.Context, candidate string, expiry time.Time, opts ...AcquireOption) (*AcquireResult, error) {
+	opts = append(opts, func(c *acquireConfig) { c.expiry = expiry })
+	return s.acquire(ctx, candidate, func(now time.Time) (time.Duration, error) {
+		if !expiry.After(now) {
+			return 0, fmt.Errorf("expiry %s is not after %s", expiry, now)
+		}
+		return expiry.Sub(now), nil
+	}, opts)
+}
+
+// AcquireIfTokenAtLeast is like AcquireLease, but only acquires the lease if
+// its stored fencing token is at least minToken. The fencing token is the
+// lease's leader_transitions, which AcquireLease increments on every
+// transition. Downstream systems that saw minToken can thus push a leadership
+// change back without a lease restored from an older state handing out tokens
+// they already saw. Returns ErrStaleFencingToken if the stored token is lower
+// or the lease does not exist.
+func (s *Store) AcquireIfTokenAtLeast(ctx context.Context, candidate string, minToken uint64, duration time.Duration, opts ...AcquireOption) (*AcquireResult, error) {
+	opts = append(opts, func(c *acquireConfig) { c.minToken = minToken })
+	return s.AcquireLease(ctx, candidate, duration, opts...)
+}
+
+// GetFencingToken returns the lease's current fencing token, its stored
+// leader_transitions, which every change of holder increments atomically with
+// the write that makes it. Downstream systems can reject writes carrying a
+// lower token than the last one they saw, as those come from a deposed leader.
+// The read cache is bypassed. Returns ErrLeaseNotFound if the lease does not
+// exist.
+func (s *Store) GetFencingToken(ctx context.Context) (_ uint64, err error) {
+	defer s.observeOperation("get", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "get", "")
+	defer endSpan(span, &err)
+
+	if err := s.checkContext(ctx, "get"); err != nil {
+		return 0, err
+	}
+	if err := s.requireDefaultDocument("get"); err != nil {
+		return 0, err
+	}
+
+	opts := s.findOneOptions().SetProjection(bson.M{"leader_transitions": 1})
+	var doc leaseDocument
+	err = s.collection.FindOne(ctx, s.leaseFilter(), opts).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, le.ErrLeaseNotFound
+		}
+		return 0, err
+	}
+
+	return doc.LeaderTransitions, nil
+}
+
+// acquire implements AcquireLease with the lease duration derived from the
+// renew time.
+func (s *Store) acquire(ctx context.Context, candidate string, durationAt func(now time.Time) (time.Duration, error), opts []AcquireOption) (_ *AcquireResult, err error) {
+	defer s.observeOperation("acquire", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "acquire", candidate)
+	defer endSpan(span, &err)
+	defer s.recordRejection("acquire", candidate, &err)
+	defer s.logRejection(ctx, "acquire", candidate, &err)
+
+	var cfg acquireConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := s.checkContext(ctx, "acquire"); err != nil {
+		return nil, err
+	}
+	if err := s.requireDefaultDocument("acquire"); err != nil {
+		return nil, err
+	}
+	if candidate == "" {
+		return nil, fmt.Errorf("acquire lease %q: %w", s.leaseKey, ErrEmptyHolder)
+	}
+	if err := s.checkDrain("acquire", &le.Lease{HolderIdentity: candidate}); err != nil {
+		return nil, err
+	}
+	if err := s.checkAcquireWindow(candidate); err != nil {
+		return nil, err
+	}
+	if err := s.waitAcquireBackoff(ctx, candidate); err != nil {
+		return nil, err
+	}
+
+	defer s.readCache.invalidate()
+
+	if err := s.yieldIfNotPreferred(ctx, "acquire", candidate); err != nil {
+		return nil, err
+	}
+
+	start := s.clock.Now()
+	now := start.Truncate(time.Millisecond) // Stored with millisecond precision.
+	duration, err := durationAt(now)
+	if err != nil {
+		return nil, fmt.Errorf("acquire lease %q for %q: %w", s.leaseKey, candidate, err)
+	}
+	expiredClause, err := s.expiredFilter(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("acquire lease %q for %q: %w", s.leaseKey, candidate, err)
+	}
+	renew := bson.M{"holder_identity": candidate}
+	if s.minRenewInterval > 0 {
+		renewAllowed := bson.A{s.minRenewIntervalElapsed()}
+		if cfg.token != "" {
+			// Replays are no-ops, not renews.
+			renewAllowed = append(renewAllowed, bson.M{"request_id": cfg.token})
+		}
+		renew["$or"] = renewAllowed
+	}
+	filter := bson.M{
+		"_id": s.leaseKey,
+		"$or": bson.A{
+			bson.M{"holder_identity": ""},
+			renew,
+			bson.M{"deleted_at": bson.M{"$exists": true}},
+			expiredClause,
+		},
+	}
+	var stamp, leaseDuration interface{} = now, duration
+	if s.serverTimestamps {
+		stamp = "$$NOW"
+		if !cfg.expiry.IsZero() {
+			leaseDuration = durationUntilByServer(cfg.expiry)
+		}
+	}
+	pipeline := s.acquirePipeline(candidate, leaseDuration, stamp, cfg.token)
+	coolingDown := s.coolingDown()
+	if coolingDown {
+		// Only renews are allowed during the cool-down.
+		filter["$or"] = bson.A{renew}
+	}
+	if cfg.minToken > 0 {
+		filter["leader_transitions"] = bson.M{"$gte": cfg.minToken}
+	}
+	if s.yieldToPreferred {
+		filter["$and"] = bson.A{s.preferredOrExpired(candidate, expiredClause)}
+	}
+	// A missing lease is created, unless only renews are allowed or it would
+	// start over from a fencing token below the minimum.
+	upsert := !coolingDown && cfg.minToken == 0
+
+	sent := time.Now()
+	before, err := s.findAndAcquire(ctx, filter, pipeline, upsert)
+	if mongo.IsDuplicateKeyError(err) {
+		// The lease existed but did not match, or a concurrent acquisition
+		// created it after we missed it. Retry as a plain conditional update
+		// to tell the two apart.
+		before, err = s.findAndAcquire(ctx, filter, pipeline, false)
+	}
+	s.stats.observe(sent)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, s.explainAcquireMiss(ctx, candidate, cfg, coolingDown)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.serverTimestamps {
+		if now, err = s.acquiredAt(ctx, before); err != nil {
+			return nil, fmt.Errorf("acquire lease %q for %q: %w", s.leaseKey, candidate, err)
+		}
+		if !cfg.expiry.IsZero() {
+			// As written by durationUntilByServer.
+			duration = max(cfg.expiry.Truncate(time.Millisecond).Sub(now), 0)
+		}
+	}
+
+	lease := acquiredLease(before, candidate, duration, now, cfg.token)
+	result := &AcquireResult{
+		Lease:            lease,
+		FirstAcquisition: before == nil,
+		FencingToken:     acquiredTransitions(before, candidate, cfg.token),
+	}
+	if isReplay(before, candidate, cfg.token) {
+		// Nothing was written.
+		return result, nil
+	}
+
+	if err := s.finishWrite(ctx, "acquire", lease, before, start); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// explainAcquireMiss explains why an acquisition by candidate matched nothing.
+func (s *Store) explainAcquireMiss(ctx context.Context, candidate string, cfg acquireConfig, coolingDown bool) error {
+	if coolingDown {
+		return s.errTransitionCooldown(candidate)
+	}
+	if cfg.minToken > 0 {
+		filter := s.leaseFilter()
+		filter["leader_transitions"] = bson.M{"$gte": cfg.minToken}
+		count, err := s.collection.CountDocuments(ctx, filter, s.countOptions())
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: lease %q is missing or has a fencing token below %d",
+				ErrStaleFencingToken, s.leaseKey, cfg.minToken)
+		}
+	}
+	if s.minRenewInterval > 0 {
+		if err := s.renewTooSoon(ctx, candidate); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("acquire lease %q for %q: %w", s.leaseKey, candidate, ErrLeaseHeld)
+}
+
+// findAndAcquire applies pipeline to the lease matching filter and returns the
+// pre-image. When upserting, a nil pre-image means the lease was created;
+// otherwise a missing match is reported as mongo.ErrNoDocuments.
+func (s *Store) findAndAcquire(ctx context.Context, filter bson.M, pipeline mongo.Pipeline, upsert bool) (*leaseDocument, error) {
+	opts := s.findOneAndUpdateOptions().
+		SetUpsert(upsert).
+		SetReturnDocument(options.Before)
+	if s.serverTimestamps {
+		// The server's time of the write, see acquiredAt.
+		opts.SetProjection(serverNowProjection)
+	}
+
+	var before leaseDocument
+	err := s.collection.FindOneAndUpdate(ctx, filter, pipeline, opts).Decode(&before)
+	if err != nil {
+		if upsert && errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &before, nil
+}
+
+// acquiredAt returns the server time an acquisition with WithServerTimestamps
+// stamped the lease with, given its pre-image before. A created lease has
+// none, so it is read back.
+func (s *Store) acquiredAt(ctx context.Context, before *leaseDocument) (time.Time, error) {
+	if before != nil && before.ServerNow != nil {
+		return before.ServerNow.Truncate(time.Millisecond), nil
+	}
+	lease, err := s.fetchLease(ctx, s.collection)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lease.RenewTime, nil
+}
+
+// durationUntilByServer is the lease_duration, in nanoseconds, from the
+// server's current time to expiry, or zero if it has passed.
+func durationUntilByServer(expiry time.Time) bson.M {
+	// Dates subtract to milliseconds.
+	untilExpiry := bson.M{"$subtract": bson.A{expiry, "$$NOW"}}
+	return bson.M{"$max": bson.A{bson.M{"$multiply": bson.A{untilExpiry, int64(time.Millisecond)}}, int64(0)}}
+}
+
+// acquirePipeline builds the update applied by AcquireLease to a lease it is
+// allowed to take, stamping it with now, a time or "$$NOW", and setting its
+// duration, a time.Duration or an expression. It must stay in sync with
+// acquiredLease.
+func (s *Store) acquirePipeline(candidate string, duration, now interface{}, token string) mongo.Pipeline {
+	// User-provided strings are wrapped in $literal so that a leading "$" is not
+	// read as a field path.
+	held := bson.M{"$eq": bson.A{"$holder_identity", bson.M{"$literal": candidate}}}
+	inserted := bson.M{"$eq": bson.A{bson.M{"$type": "$holder_identity"}, "missing"}}
+
+	// Fields are listed in the order of leaseDocument, which new leases are
+	// created with.
+	acquired := bson.D{
+		{Key: "holder_identity", Value: bson.M{"$literal": candidate}},
+		{Key: "acquire_time", Value: bson.M{"$cond": bson.A{
+			held,
+			bson.M{"$ifNull": bson.A{"$acquire_time", "$renew_time"}},
+			now,
+		}}},
+		{Key: "renew_time", Value: now},
+		{Key: "lease_duration", Value: duration},
+		// $add turns an int into a long when it overflows, so the count keeps
+		// growing past 2^31 and 2^32.
+		{Key: "leader_transitions", Value: bson.M{"$cond": bson.A{
+			held,
+			bson.M{"$ifNull": bson.A{"$leader_transitions", 0}},
+			bson.M{"$cond": bson.A{
+				inserted,
+				0,
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$leader_transitions", 0}}, 1}},
+			}},
+		}}},
+	}
+	if s.region != "" {
+		acquired = append(acquired, bson.E{Key: "region", Value: bson.M{"$literal": s.region}})
+	}
+	if token != "" {
+		acquired = append(acquired, bson.E{Key: "request_id", Value: bson.M{"$literal": token}})
+	}
+
+	var replaced interface{} = bson.M{"$mergeObjects": bson.A{"$$ROOT", acquired}}
+	if token != "" {
+		replay := bson.M{"$and": bson.A{held, bson.M{"$eq": bson.A{"$request_id", bson.M{"$literal": token}}}}}
+		replaced = bson.M{"$cond": bson.A{replay, "$$ROOT", replaced}}
+	}
+
+	return s.withExpiresAt(mongo.Pipeline{
+		{{Key: "$replaceWith", Value: replaced}},
+		{{Key: "$unset", Value: "deleted_at"}},
+	})
+}
+
+// acquiredLease computes the lease written by acquirePipeline over before, the
+// pre-image (nil if the lease was created).
+func acquiredLease(before *leaseDocument, candidate string, duration time.Duration, now time.Time, token string) *le.Lease {
+	if before == nil {
+		return &le.Lease{
+			HolderIdentity: candidate,
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  duration,
+		}
+	}
+
+	previous := before.toLease()
+	if isReplay(before, candidate, token) {
+		return previous
+	}
+
+	transitions := uint32(acquiredTransitions(before, candidate, token))
+	if before.HolderIdentity != candidate {
+		return &le.Lease{
+			HolderIdentity:    candidate,
+			AcquireTime:       now,
+			RenewTime:         now,
+			LeaseDuration:     duration,
+			LeaderTransitions: transitions,
+		}
+	}
+
+	return &le.Lease{
+		HolderIdentity:    candidate,
+		AcquireTime:       previous.AcquireTime,
+		RenewTime:         now,
+		LeaseDuration:     duration,
+		LeaderTransitions: transitions,
+	}
+}
+
+// acquiredTransitions computes the leader_transitions written by
+// acquirePipeline over before, the pre-image (nil if the lease was created).
+func acquiredTransitions(before *leaseDocument, candidate, token string) uint64 {
+	switch {
+	case before == nil:
+		return 0
+	case isReplay(before, candidate, token), before.HolderIdentity == candidate:
+		return before.LeaderTransitions
+	default:
+		return before.LeaderTransitions + 1
+	}
+}
+
+// isReplay reports whether an acquisition tagged with token repeats the last
+// successful one, given the pre-image before.
+func isReplay(before *leaseDocument, candidate, token string) bool {
+	return before != nil && token != "" && before.HolderIdentity == candidate && before.RequestID == token
+}
+
+// acquireWindow is the time range set with WithAcquireWindow.
+type acquireWindow struct {
+	start, end time.Time
+}
+
+// checkAcquireWindow returns ErrOutsideAcquireWindow if an acquire window is
+// set and the store's clock is outside of it.
+func (s *Store) checkAcquireWindow(candidate string) error {
+	if s.acquireWindow == nil {
+		return nil
+	}
+
+	now := s.clock.Now()
+	if !now.Before(s.acquireWindow.start) && now.Before(s.acquireWindow.end) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q cannot acquire lease %q at %s, outside [%s, %s)",
+		ErrOutsideAcquireWindow, candidate, s.leaseKey, now, s.acquireWindow.start, s.acquireWindow.end)
+}
@@ -0,0 +1,373 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestAcquireLease(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	first, err := store.AcquireLease(ctx, "candidate-1", 200*time.Millisecond)
+	require.NoError(t, err, "Missing lease should be acquired")
+	assert.Equal(t, "candidate-1", first.HolderIdentity)
+	assert.Zero(t, first.LeaderTransitions)
+	assert.True(t, first.FirstAcquisition, "Creating the lease should be the first acquisition")
+
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.ErrorIs(t, err, ErrLeaseHeld, "Live lease held by another candidate should not be acquired")
+
+	renewed, err := store.AcquireLease(ctx, "candidate-1", 200*time.Millisecond)
+	require.NoError(t, err, "Holder should renew its lease")
+	assert.True(t, renewed.AcquireTime.Equal(first.AcquireTime), "Renew should keep acquire_time")
+	assert.Zero(t, renewed.LeaderTransitions, "Renew should not be a transition")
+	assert.False(t, renewed.FirstAcquisition, "Renew should not be the first acquisition")
+
+	time.Sleep(300 * time.Millisecond)
+
+	t.Run("Idempotency Token", func(t *testing.T) {
+		acquired, err := store.AcquireLease(ctx, "candidate-2", time.Minute, WithIdempotencyToken("request-1"))
+		require.NoError(t, err, "Expired lease should be taken over")
+		assert.EqualValues(t, 1, acquired.LeaderTransitions)
+		assert.False(t, acquired.FirstAcquisition, "Handover should not be the first acquisition")
+
+		retried, err := store.AcquireLease(ctx, "candidate-2", time.Minute, WithIdempotencyToken("request-1"))
+		require.NoError(t, err, "Retried acquisition should succeed")
+		assert.Equal(t, acquired, retried, "Retried acquisition should be a no-op")
+
+		stored, err := store.GetLease(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, stored.LeaderTransitions, "Transitions should be incremented once")
+		assert.True(t, stored.RenewTime.Equal(acquired.RenewTime), "Retried acquisition should not renew")
+	})
+}
+
+func TestAcquireLeaseContention(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	const rounds = 20
+	const candidates = 8
+	for round := 0; round < rounds; round++ {
+		store, err := NewStore(Args{
+			LeaseCollection: collection,
+			LeaseKey:        fmt.Sprintf("test-lease-key-%d", round),
+		})
+		require.NoError(t, err, "Failed to create store")
+
+		// Every candidate races for the first acquisition of a fresh lease, and
+		// one candidate races against itself.
+		results := make([]*AcquireResult, candidates+1)
+		errs := make([]error, candidates+1)
+		var wg sync.WaitGroup
+		for i := range errs {
+			candidate := fmt.Sprintf("candidate-%d", i%candidates)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], errs[i] = store.AcquireLease(ctx, candidate, time.Minute)
+			}()
+		}
+		wg.Wait()
+
+		lease, err := store.GetLease(ctx)
+		require.NoError(t, err)
+
+		firsts := 0
+		for i, err := range errs {
+			if fmt.Sprintf("candidate-%d", i%candidates) == lease.HolderIdentity {
+				require.NoError(t, err, "Winner should succeed")
+				if results[i].FirstAcquisition {
+					firsts++
+				}
+				continue
+			}
+			require.Error(t, err)
+			assert.False(t, mongo.IsDuplicateKeyError(err), "Duplicate key errors should not escape: %v", err)
+			assert.ErrorIs(t, err, ErrLeaseHeld)
+		}
+		assert.Equal(t, 1, firsts, "Exactly one acquisition should create the lease")
+	}
+}
+
+func TestAcquiredLease(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	earlier := now.Add(-time.Minute)
+	before := &leaseDocument{
+		ID:                "test-lease-key",
+		HolderIdentity:    "candidate-1",
+		AcquireTime:       earlier,
+		RenewTime:         earlier,
+		LeaseDuration:     time.Second,
+		LeaderTransitions: 3,
+		RequestID:         "request-1",
+	}
+
+	tests := []struct {
+		name      string
+		before    *leaseDocument
+		candidate string
+		token     string
+		want      *le.Lease
+	}{
+		{
+			name:      "create",
+			candidate: "candidate-1",
+			want:      &le.Lease{HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now, LeaseDuration: time.Minute},
+		},
+		{
+			name:      "renew",
+			before:    before,
+			candidate: "candidate-1",
+			want:      &le.Lease{HolderIdentity: "candidate-1", AcquireTime: earlier, RenewTime: now, LeaseDuration: time.Minute, LeaderTransitions: 3},
+		},
+		{
+			name:      "takeover",
+			before:    before,
+			candidate: "candidate-2",
+			token:     "request-1",
+			want:      &le.Lease{HolderIdentity: "candidate-2", AcquireTime: now, RenewTime: now, LeaseDuration: time.Minute, LeaderTransitions: 4},
+		},
+		{
+			name:      "replay",
+			before:    before,
+			candidate: "candidate-1",
+			token:     "request-1",
+			want:      &le.Lease{HolderIdentity: "candidate-1", AcquireTime: earlier, RenewTime: earlier, LeaseDuration: time.Second, LeaderTransitions: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := acquiredLease(tt.before, tt.candidate, time.Minute, now, tt.token)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAcquireWindow(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		now  time.Time
+	}{
+		{name: "before", now: start.Add(-time.Nanosecond)},
+		{name: "at end", now: end},
+		{name: "after", now: end.Add(time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			collection := newFakeCollection()
+			store := newTestStore(t, collection,
+				WithClock(&steppingClock{now: tt.now}),
+				WithAcquireWindow(start, end),
+			)
+
+			_, err := store.AcquireLease(context.Background(), "candidate-1", time.Minute)
+			require.ErrorIs(t, err, ErrOutsideAcquireWindow)
+			assert.Zero(t, collection.callCount("FindOneAndUpdate"), "MongoDB should not be contacted")
+		})
+	}
+}
+
+func TestAcquireLeaseInsideWindow(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+	now := time.Now()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithAcquireWindow(now.Add(-time.Minute), now.Add(time.Minute)))
+	require.NoError(t, err, "Failed to create store")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err, "Acquisition inside the window should succeed")
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err, "Renew inside the window should succeed")
+}
+
+func TestAcquireUntil(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	expiry := time.Now().Add(500 * time.Millisecond)
+	acquired, err := store.AcquireUntil(ctx, "candidate-1", expiry)
+	require.NoError(t, err, "Missing lease should be acquired")
+	assert.True(t, acquired.RenewTime.Add(acquired.LeaseDuration).Equal(expiry), "Lease should expire at the target time")
+
+	next, err := store.NextAcquirableAt(ctx)
+	require.NoError(t, err)
+	assert.WithinDuration(t, expiry, next, time.Millisecond, "Stored lease should expire at the target time")
+
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.ErrorIs(t, err, ErrLeaseHeld, "Lease should be held until the target time")
+
+	time.Sleep(time.Until(expiry) + 100*time.Millisecond)
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err, "Lease should expire at the target time")
+}
+
+func TestAcquireUntilPast(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithClock(&steppingClock{now: now}))
+
+	_, err := store.AcquireUntil(context.Background(), "candidate-1", now.Add(-time.Second))
+	require.Error(t, err, "Expiry in the past should be rejected")
+	assert.Zero(t, collection.callCount("FindOneAndUpdate"), "MongoDB should not be contacted")
+}
+
+func TestAcquireUntilServerTimestamps(t *testing.T) {
+	t.Parallel()
+
+	// The store's clock runs an hour ahead of the server's.
+	now := time.Now()
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithServerTimestamps(), WithClock(&steppingClock{now: now.Add(time.Hour)}))
+
+	expiry := now.Add(90 * time.Minute).Truncate(time.Millisecond)
+	acquired, err := store.AcquireUntil(context.Background(), "candidate-1", expiry)
+	require.NoError(t, err)
+	assert.True(t, acquired.RenewTime.Add(acquired.LeaseDuration).Equal(expiry), "Lease should expire at the target time")
+	assert.WithinDuration(t, now, acquired.RenewTime, time.Second, "Lease should carry the server's time")
+	assert.InDelta(t, float64(90*time.Minute), float64(collection.doc.LeaseDuration), float64(time.Second),
+		"Stored duration should be measured from the server's time")
+	assert.Equal(t, acquired.LeaseDuration, collection.doc.LeaseDuration, "Returned duration should be the stored one")
+}
+
+func TestAcquireIfTokenAtLeast(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	_, err = store.AcquireIfTokenAtLeast(ctx, "candidate-1", 1, time.Minute)
+	require.ErrorIs(t, err, ErrStaleFencingToken, "Missing lease should not restart the tokens")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	acquired, err := store.AcquireLease(ctx, "candidate-2", time.Millisecond)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, acquired.LeaderTransitions)
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = store.AcquireIfTokenAtLeast(ctx, "candidate-3", 2, time.Minute)
+	require.ErrorIs(t, err, ErrStaleFencingToken, "Token below the minimum should refuse the acquisition")
+
+	acquired, err = store.AcquireIfTokenAtLeast(ctx, "candidate-3", 1, time.Minute)
+	require.NoError(t, err, "Token at the minimum should allow the acquisition")
+	assert.EqualValues(t, 2, acquired.LeaderTransitions)
+
+	_, err = store.AcquireIfTokenAtLeast(ctx, "candidate-1", 1, time.Minute)
+	require.ErrorIs(t, err, ErrLeaseHeld, "Live lease should still be held")
+}
+
+func TestAcquireLeaseTransitionsPast32Bits(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	// An expired lease one transition short of 2^32.
+	_, err = collection.InsertOne(ctx, bson.D{
+		{Key: "_id", Value: "test-lease-key"},
+		{Key: "holder_identity", Value: "candidate-1"},
+		{Key: "renew_time", Value: time.Now().Add(-time.Hour)},
+		{Key: "lease_duration", Value: time.Second},
+		{Key: "leader_transitions", Value: int64(1<<32 - 1)},
+	})
+	require.NoError(t, err)
+
+	result, err := store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1<<32), result.FencingToken, "The token should not wrap at 2^32")
+	assert.Equal(t, uint32(0), result.LeaderTransitions, "le.Lease should carry the low 32 bits")
+
+	var doc leaseDocument
+	require.NoError(t, collection.FindOne(ctx, bson.M{"_id": "test-lease-key"}).Decode(&doc))
+	assert.Equal(t, uint64(1<<32), doc.LeaderTransitions, "The stored count should not wrap at 2^32")
+
+	_, err = store.AcquireIfTokenAtLeast(ctx, "candidate-2", 1<<32, time.Minute)
+	require.NoError(t, err, "The full token should gate acquisitions")
+}
+
+func TestGetFencingToken(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	collection := newFakeCollection()
+	store := newTestStore(t, collection)
+
+	_, err := store.GetFencingToken(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+
+	collection.doc = &leaseDocument{ID: "test-lease-key", HolderIdentity: "candidate-1", LeaderTransitions: 1<<32 + 1}
+	token, err := store.GetFencingToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1<<32+1), token, "The full stored count should be returned")
+	assert.Equal(t, bson.M{"leader_transitions": 1}, collection.lastOptions("FindOne").(*options.FindOneOptions).Projection)
+}
@@ -0,0 +1,162 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AdminExtend pushes the lease's renew_time, and so its expiry, forward by
+// additional, whoever holds it. The holder is not changed. It is meant for
+// controlled failover testing, such as extending a crashed leader's grace, and
+// requires WithAdminOperations; otherwise ErrAdminDisabled is returned. Returns
+// ErrLeaseNotFound if the lease does not exist.
+func (s *Store) AdminExtend(ctx context.Context, additional time.Duration) error {
+	if err := s.checkContext(ctx, "extend"); err != nil {
+		return err
+	}
+	if err := s.requireDefaultDocument("extend"); err != nil {
+		return err
+	}
+	if !s.adminOperations {
+		return fmt.Errorf("extend lease %q: %w", s.leaseKey, ErrAdminDisabled)
+	}
+	if additional <= 0 {
+		return fmt.Errorf("extend lease %q: additional time must be positive, got %s", s.leaseKey, additional)
+	}
+
+	defer s.readCache.invalidate()
+
+	// Dates are added in milliseconds.
+	update := s.withExpiresAt(mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"renew_time": bson.M{"$add": bson.A{"$renew_time", additional.Milliseconds()}},
+	}}}})
+	if s.history == nil {
+		result, err := s.collection.UpdateOne(ctx, s.leaseFilter(), update, s.updateOptions())
+		if err != nil {
+			return fmt.Errorf("extend lease %q: %w", s.leaseKey, err)
+		}
+		if result.MatchedCount == 0 {
+			return le.ErrLeaseNotFound
+		}
+	} else {
+		// The history record needs the extended lease.
+		var after leaseDocument
+		opts := s.findOneAndUpdateOptions().SetReturnDocument(options.After)
+		err := s.collection.FindOneAndUpdate(ctx, s.leaseFilter(), update, opts).Decode(&after)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return le.ErrLeaseNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("extend lease %q: %w", s.leaseKey, err)
+		}
+		s.recordHistory(ctx, ReasonExtend, after.HolderIdentity, after.toLease(), after.LeaderTransitions)
+	}
+	s.audit(ctx, "extend", "", ReasonExtend)
+	s.logAdmin(ctx, "extend", "additional", additional)
+
+	return nil
+}
+
+// AdminForceRelease releases the lease whoever holds it, so that candidates
+// can acquire it right away instead of waiting for a stuck leader's lease to
+// expire. The holder loses the lease on its next renew. The lease keeps its
+// other fields, as a release by the elector does. It requires
+// WithAdminOperations; otherwise ErrAdminDisabled is returned. Returns
+// ErrLeaseNotFound if the lease does not exist. Use WithActor to attribute it.
+func (s *Store) AdminForceRelease(ctx context.Context) error {
+	return s.adminSetHolder(ctx, "force release", "")
+}
+
+// AdminTransfer hands the lease over to holder, whoever holds it, as if holder
+// had just acquired it: the acquire and renew times are set to now, and the
+// transition count is incremented if the holder changes. The previous holder
+// loses the lease on its next renew. It requires WithAdminOperations;
+// otherwise ErrAdminDisabled is returned. Returns ErrLeaseNotFound if the
+// lease does not exist. Use WithActor to attribute it.
+func (s *Store) AdminTransfer(ctx context.Context, holder string) error {
+	if holder == "" {
+		return fmt.Errorf("transfer lease %q: %w", s.leaseKey, ErrEmptyHolder)
+	}
+	return s.adminSetHolder(ctx, "transfer", holder)
+}
+
+// adminSetHolder sets the holder of the lease to holder, releasing it when
+// empty, and records the change as op.
+func (s *Store) adminSetHolder(ctx context.Context, op, holder string) error {
+	if err := s.checkContext(ctx, op); err != nil {
+		return err
+	}
+	if err := s.requireDefaultDocument(op); err != nil {
+		return err
+	}
+	if !s.adminOperations {
+		return fmt.Errorf("%s lease %q: %w", op, s.leaseKey, ErrAdminDisabled)
+	}
+
+	defer s.readCache.invalidate()
+
+	now := s.clock.Now()
+	set := bson.M{"holder_identity": holder}
+	if holder != "" {
+		set["acquire_time"] = now
+		set["renew_time"] = now
+		set["leader_transitions"] = bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$holder_identity", holder}},
+			"$leader_transitions",
+			// Omitted by minimal documents when zero.
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$leader_transitions", 0}}, 1}},
+		}}
+	}
+	update := s.withExpiresAt(mongo.Pipeline{{{Key: "$set", Value: set}}})
+	opts := s.findOneAndUpdateOptions().SetReturnDocument(options.Before)
+
+	var before leaseDocument
+	err := s.collection.FindOneAndUpdate(ctx, s.leaseFilter(), update, opts).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return le.ErrLeaseNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s lease %q: %w", op, s.leaseKey, err)
+	}
+
+	after := before.toLease()
+	after.HolderIdentity = holder
+	transitions := before.LeaderTransitions
+	if holder != "" {
+		after.AcquireTime, after.RenewTime = now, now
+		if before.HolderIdentity != holder {
+			after.LeaderTransitions++
+			transitions++
+		}
+	}
+	reason := updateReason(&before, after)
+	if s.history != nil {
+		s.recordHistory(ctx, reason, before.HolderIdentity, after, transitions)
+	}
+	s.audit(ctx, op, holder, reason)
+	s.logAdmin(ctx, op, "previous_holder", before.HolderIdentity, "holder", holder)
+
+	return nil
+}
+
+// logAdmin logs an admin operation op at info level with the actor set with
+// WithActor, followed by attrs.
+func (s *Store) logAdmin(ctx context.Context, op string, attrs ...any) {
+	if s.logger == nil {
+		return
+	}
+	a := actorFrom(ctx)
+	s.logger.InfoContext(ctx, "lease admin operation", append([]any{
+		"lease_key", s.leaseKey,
+		"op", op,
+		"actor", a.identity,
+		"actor_reason", a.reason,
+	}, attrs...)...)
+}
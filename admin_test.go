@@ -0,0 +1,127 @@
+package mongoleasestore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminExtend(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithAdminOperations())
+	require.NoError(t, err, "Failed to create store")
+
+	require.ErrorIs(t, store.AdminExtend(ctx, time.Minute), le.ErrLeaseNotFound)
+
+	now := time.Now().Truncate(time.Millisecond) // Stored with millisecond precision.
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now.Add(-time.Minute),
+		LeaseDuration:  time.Second,
+	}))
+	before, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	require.True(t, store.IsExpired(before))
+
+	require.NoError(t, store.AdminExtend(ctx, 2*time.Minute))
+
+	after, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.True(t, after.RenewTime.Equal(before.RenewTime.Add(2*time.Minute)), "Expiry should be pushed out")
+	assert.False(t, store.IsExpired(after))
+	assert.Equal(t, "candidate-1", after.HolderIdentity, "Holder should not change")
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		guarded, err := NewStore(Args{
+			LeaseCollection: collection,
+			LeaseKey:        "test-lease-key",
+		})
+		require.NoError(t, err, "Failed to create store")
+		require.ErrorIs(t, guarded.AdminExtend(ctx, time.Minute), ErrAdminDisabled)
+	})
+}
+
+func TestAdminTransfer(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := WithActor(context.Background(), "alice", "failover drill")
+
+	var buf bytes.Buffer
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithAdminOperations(), WithAuditWriter(&buf))
+	require.NoError(t, err, "Failed to create store")
+
+	require.ErrorIs(t, store.AdminForceRelease(ctx), le.ErrLeaseNotFound)
+	require.ErrorIs(t, store.AdminTransfer(ctx, ""), ErrEmptyHolder)
+
+	now := time.Now().Truncate(time.Millisecond) // Stored with millisecond precision.
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}))
+
+	require.NoError(t, store.AdminTransfer(ctx, "candidate-2"))
+	lease, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "candidate-2", lease.HolderIdentity)
+	assert.EqualValues(t, 1, lease.LeaderTransitions)
+	assert.False(t, lease.AcquireTime.Before(now), "Acquire time should be reset")
+
+	require.NoError(t, store.AdminForceRelease(ctx))
+	lease, err = store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.False(t, lease.HasHolder(), "The lease should be released")
+	assert.EqualValues(t, 1, lease.LeaderTransitions)
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event AuditEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		assert.Equal(t, "alice", event.Actor)
+		assert.Equal(t, "failover drill", event.ActorReason)
+		events = append(events, event)
+	}
+	require.Len(t, events, 3)
+	assert.Equal(t, "transfer", events[1].Operation)
+	assert.Equal(t, "candidate-2", events[1].Holder)
+	assert.Equal(t, ReasonTakeover, events[1].Reason)
+	assert.Equal(t, "force release", events[2].Operation)
+	assert.Equal(t, ReasonRelease, events[2].Reason)
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		guarded, err := NewStore(Args{
+			LeaseCollection: collection,
+			LeaseKey:        "test-lease-key",
+		})
+		require.NoError(t, err, "Failed to create store")
+		require.ErrorIs(t, guarded.AdminForceRelease(ctx), ErrAdminDisabled)
+		require.ErrorIs(t, guarded.AdminTransfer(ctx, "candidate-3"), ErrAdminDisabled)
+	})
+}
@@ -0,0 +1,99 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// applyErrorContext makes the lease collections wrap the errors of the driver
+// with the command that failed, the lease key and the namespace, so that an
+// error logged by one of many services sharing a deployment points to the
+// lease it is about. The errors are wrapped with %w, so errors.Is, errors.As
+// and the driver's helpers such as mongo.IsDuplicateKeyError still see them.
+// It must run after applyCollectionGuard and applyReadOnly.
+func (s *Store) applyErrorContext() {
+	collection := s.mongoCollection()
+	if collection == nil {
+		return
+	}
+
+	ns := namespace(collection)
+	s.collection = &annotatedCollection{leaseCollection: s.collection, leaseKey: s.leaseKey, namespace: ns}
+	if s.staleReads != nil {
+		s.staleReads = &annotatedCollection{leaseCollection: s.staleReads, leaseKey: s.leaseKey, namespace: ns}
+	}
+	if s.confirmReads != nil {
+		s.confirmReads = &annotatedCollection{leaseCollection: s.confirmReads, leaseKey: s.leaseKey, namespace: ns}
+	}
+}
+
+// annotatedCollection wraps the errors of the wrapped collection with the
+// command, lease key and namespace. mongo.ErrNoDocuments is not a failure and
+// is returned as is.
+type annotatedCollection struct {
+	leaseCollection
+	leaseKey  string
+	namespace string
+}
+
+func (c *annotatedCollection) annotate(command string, err error) error {
+	if err == nil || errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	return fmt.Errorf("%s lease %q in %s: %w", command, c.leaseKey, c.namespace, err)
+}
+
+func (c *annotatedCollection) annotateResult(command string, result *mongo.SingleResult) *mongo.SingleResult {
+	raw, err := result.Raw()
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, c.annotate(command, err), nil)
+	}
+	return mongo.NewSingleResultFromDocument(raw, nil, nil)
+}
+
+func (c *annotatedCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	return c.annotateResult("find", c.leaseCollection.FindOne(ctx, filter, opts...))
+}
+
+func (c *annotatedCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	cursor, err := c.leaseCollection.Find(ctx, filter, opts...)
+	return cursor, c.annotate("find", err)
+}
+
+func (c *annotatedCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	count, err := c.leaseCollection.CountDocuments(ctx, filter, opts...)
+	return count, c.annotate("count", err)
+}
+
+func (c *annotatedCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	result, err := c.leaseCollection.InsertOne(ctx, document, opts...)
+	return result, c.annotate("insert", err)
+}
+
+func (c *annotatedCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	result, err := c.leaseCollection.UpdateOne(ctx, filter, update, opts...)
+	return result, c.annotate("update", err)
+}
+
+func (c *annotatedCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	return c.annotateResult("findAndModify", c.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts...))
+}
+
+func (c *annotatedCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	result, err := c.leaseCollection.ReplaceOne(ctx, filter, replacement, opts...)
+	return result, c.annotate("replace", err)
+}
+
+func (c *annotatedCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	result, err := c.leaseCollection.DeleteOne(ctx, filter, opts...)
+	return result, c.annotate("delete", err)
+}
+
+func (c *annotatedCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	return c.annotateResult("findAndModify", c.leaseCollection.FindOneAndDelete(ctx, filter, opts...))
+}
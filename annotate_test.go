@@ -0,0 +1,53 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestErrorContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+	store, err := NewStore(Args{
+		LeaseCollection: mongoClient.Database("leases").Collection("elections"),
+		LeaseKey:        "billing",
+	})
+	require.NoError(t, err, "Failed to create store")
+	assert.Equal(t, "leases.elections", store.Config().Namespace)
+
+	stepDown := mongo.CommandError{Code: 189, Name: "PrimarySteppedDown", Message: "stepped down"}
+	collection := &flakyCollection{fakeCollection: newFakeCollection(), failures: 1, err: stepDown}
+	store.collection = &annotatedCollection{leaseCollection: collection, leaseKey: "billing", namespace: "leases.elections"}
+
+	_, err = store.GetLease(ctx)
+	assert.ErrorIs(t, err, le.ErrLeaseNotFound, "A missing lease should not be reported as a failure")
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	require.NoError(t, store.CreateLease(ctx, lease))
+	lease.RenewTime = now.Add(time.Millisecond)
+	err = store.UpdateLease(ctx, lease)
+	require.Error(t, err)
+	assert.Equal(t, `update lease "billing" in leases.elections: (PrimarySteppedDown) stepped down`, err.Error())
+	assert.True(t, hasErrorCode(err, 189), "The driver error should be wrapped")
+	assert.True(t, IsTransient(err))
+}
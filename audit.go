@@ -0,0 +1,85 @@
+package mongoleasestore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditEvent is a line written by WithAuditWriter for every mutation of the
+// lease.
+type AuditEvent struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	LeaseKey  string    `json:"lease_key"`
+	Holder    string    `json:"holder"`
+	Reason    string    `json:"reason"`
+	// Actor and ActorReason attribute the mutation, see WithActor.
+	Actor       string `json:"actor,omitempty"`
+	ActorReason string `json:"actor_reason,omitempty"`
+}
+
+// actorKey is the context key of the actor set with WithActor.
+type actorKey struct{}
+
+// actor is who performed a mutation, and why.
+type actor struct {
+	identity string
+	reason   string
+}
+
+// WithActor returns a copy of ctx attributing the mutations made with it to
+// identity, such as an operator or an automation, for reason. The actor is
+// written to the AuditEvent of every such mutation, and logged with admin
+// operations, so that a forced failover can be traced back to whoever
+// performed it and why.
+func WithActor(ctx context.Context, identity, reason string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor{identity: identity, reason: reason})
+}
+
+// actorFrom returns the actor set on ctx with WithActor, if any.
+func actorFrom(ctx context.Context) actor {
+	a, _ := ctx.Value(actorKey{}).(actor)
+	return a
+}
+
+// auditLog serializes audit events to a writer, one JSON document per line.
+type auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// audit writes an audit event, logging instead of failing.
+func (s *Store) audit(ctx context.Context, op, holder, reason string) {
+	if s.auditLog == nil {
+		return
+	}
+
+	a := actorFrom(ctx)
+	line, err := json.Marshal(AuditEvent{
+		Time:        s.clock.Now(),
+		Operation:   op,
+		LeaseKey:    s.leaseKey,
+		Holder:      holder,
+		Reason:      reason,
+		Actor:       a.identity,
+		ActorReason: a.reason,
+	})
+	if err == nil {
+		s.auditLog.mu.Lock()
+		// A single write per line keeps lines whole on writers shared with
+		// other goroutines.
+		_, err = s.auditLog.w.Write(append(line, '\n'))
+		s.auditLog.mu.Unlock()
+	}
+
+	if err != nil && s.logger != nil {
+		s.logger.WarnContext(ctx, "failed to write lease audit event",
+			"lease_key", s.leaseKey,
+			"operation", op,
+			"error", err,
+		)
+	}
+}
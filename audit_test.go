@@ -0,0 +1,96 @@
+package mongoleasestore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditWriter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var buf bytes.Buffer
+	store := newTestStore(t, newFakeCollection(), WithAuditWriter(&buf))
+
+	lease := func(holder string, transitions uint32) *le.Lease {
+		// Expired, so that candidate-2 may take it over.
+		now := time.Now().Add(-time.Minute)
+		return &le.Lease{
+			HolderIdentity:    holder,
+			AcquireTime:       now,
+			RenewTime:         now,
+			LeaseDuration:     time.Second,
+			LeaderTransitions: transitions,
+		}
+	}
+
+	require.NoError(t, store.CreateLease(ctx, lease("candidate-1", 0)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-1", 0)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)))
+
+	// Concurrent mutations should not interleave lines.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)))
+		}()
+	}
+	wg.Wait()
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event AuditEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event), "Every line should be a JSON document")
+		assert.Equal(t, "test-lease-key", event.LeaseKey)
+		assert.False(t, event.Time.IsZero())
+		events = append(events, event)
+	}
+	require.Len(t, events, 13, "Every mutation should be audited")
+
+	assert.Equal(t, "create", events[0].Operation)
+	assert.Equal(t, "candidate-1", events[0].Holder)
+	assert.Equal(t, ReasonAcquire, events[0].Reason)
+	assert.Equal(t, ReasonRenew, events[1].Reason)
+	assert.Equal(t, "update", events[2].Operation)
+	assert.Equal(t, "candidate-2", events[2].Holder)
+	assert.Equal(t, ReasonTakeover, events[2].Reason)
+}
+
+func TestAuditActor(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	store := newTestStore(t, newFakeCollection(), WithAuditWriter(&buf))
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	require.NoError(t, store.CreateLease(context.Background(), lease))
+	ctx := WithActor(context.Background(), "alice", "INC-42 stuck leader")
+	require.NoError(t, store.UpdateLease(ctx, lease))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.NotContains(t, string(lines[0]), "actor", "Unattributed mutations should have no actor")
+
+	var event AuditEvent
+	require.NoError(t, json.Unmarshal(lines[1], &event))
+	assert.Equal(t, "alice", event.Actor)
+	assert.Equal(t, "INC-42 stuck leader", event.ActorReason)
+}
@@ -0,0 +1,57 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// WithAcquireBackoffByIdentity delays the first AcquireLease of each candidate
+// by a deterministic duration below max derived from a hash of its identity, so
+// that candidates starting together spread their first attempts instead of
+// contending at once. Later calls, renews included, are not delayed. A max <= 0
+// disables the delay.
+func WithAcquireBackoffByIdentity(max time.Duration) Option {
+	return func(s *Store) {
+		if max <= 0 {
+			s.acquireBackoff = nil
+			return
+		}
+		s.acquireBackoff = &acquireBackoff{max: max}
+	}
+}
+
+// acquireBackoff delays the first acquisition of each candidate.
+type acquireBackoff struct {
+	max     time.Duration
+	started sync.Map // Candidates whose first acquisition was delayed.
+}
+
+// delay returns the delay of candidate's first acquisition, in [0, max).
+func (b *acquireBackoff) delay(candidate string) time.Duration {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(candidate))
+	return time.Duration(h.Sum64() % uint64(b.max))
+}
+
+// waitAcquireBackoff waits out candidate's delay if this is its first
+// acquisition, or returns early with an error once ctx is done.
+func (s *Store) waitAcquireBackoff(ctx context.Context, candidate string) error {
+	if s.acquireBackoff == nil {
+		return nil
+	}
+	if _, started := s.acquireBackoff.started.LoadOrStore(candidate, struct{}{}); started {
+		return nil
+	}
+
+	timer := time.NewTimer(s.acquireBackoff.delay(candidate))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("acquire lease %q for %q: %w", s.leaseKey, candidate, ctx.Err())
+	}
+}
@@ -0,0 +1,47 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireBackoffByIdentity(t *testing.T) {
+	t.Parallel()
+
+	const max = time.Second
+	store, err := NewStore(Args{LeaseKey: "test-lease-key"}, WithAcquireBackoffByIdentity(max))
+	require.NoError(t, err)
+	require.NotNil(t, store.acquireBackoff)
+	assert.Equal(t, max, store.Config().AcquireBackoff)
+
+	delays := map[time.Duration]string{}
+	for _, candidate := range []string{"node-a", "node-b", "node-c", "node-d"} {
+		delay := store.acquireBackoff.delay(candidate)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, max)
+		assert.Equal(t, delay, store.acquireBackoff.delay(candidate), "delay must be deterministic")
+		if other, ok := delays[delay]; ok {
+			t.Fatalf("candidates %q and %q share delay %v", other, candidate, delay)
+		}
+		delays[delay] = candidate
+	}
+
+	t.Run("only the first acquisition waits", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, store.waitAcquireBackoff(ctx, "node-e"), context.Canceled)
+		require.NoError(t, store.waitAcquireBackoff(ctx, "node-e"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		store, err := NewStore(Args{LeaseKey: "test-lease-key"}, WithAcquireBackoffByIdentity(max), WithAcquireBackoffByIdentity(0))
+		require.NoError(t, err)
+		assert.Nil(t, store.acquireBackoff)
+		assert.Zero(t, store.Config().AcquireBackoff)
+		require.NoError(t, store.waitAcquireBackoff(context.Background(), "node-a"))
+	})
+}
@@ -0,0 +1,104 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BootstrapOption configures a single Bootstrap call.
+type BootstrapOption func(*bootstrapConfig)
+
+type bootstrapConfig struct {
+	schemaValidation bool
+}
+
+// WithSchemaValidation makes Bootstrap install leaseSchema as the validator of
+// the lease collection, so that writes of malformed lease documents, by this
+// store or anything else, are rejected by the server.
+func WithSchemaValidation() BootstrapOption {
+	return func(c *bootstrapConfig) {
+		c.schemaValidation = true
+	}
+}
+
+// leaseSchema validates the fields of a lease document that the store reads.
+// Minimal documents (see WithMinimalDocument) omit acquire_time and
+// leader_transitions, and fields the store does not know are allowed.
+var leaseSchema = bson.M{"$jsonSchema": bson.M{
+	"bsonType": "object",
+	"required": bson.A{"holder_identity", "renew_time", "lease_duration"},
+	"properties": bson.M{
+		"_id":                bson.M{"bsonType": "string"},
+		"holder_identity":    bson.M{"bsonType": "string"},
+		"acquire_time":       bson.M{"bsonType": "date"},
+		"renew_time":         bson.M{"bsonType": "date"},
+		"lease_duration":     bson.M{"bsonType": bson.A{"int", "long"}},
+		"leader_transitions": bson.M{"bsonType": bson.A{"int", "long"}},
+		"deleted_at":         bson.M{"bsonType": "date"},
+		"region":             bson.M{"bsonType": "string"},
+		"request_id":         bson.M{"bsonType": "string"},
+		"expires_at":         bson.M{"bsonType": "date"},
+	},
+}}
+
+// Bootstrap sets up the lease collection: it creates it if missing, installs
+// the indexes of EnsureIndexes and, with WithSchemaValidation, the lease
+// document validator. It is idempotent, so every process can run it on start
+// instead of operators setting the collection up by hand.
+func (s *Store) Bootstrap(ctx context.Context, opts ...BootstrapOption) error {
+	var cfg bootstrapConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	collection := s.mongoCollection()
+	if collection == nil {
+		return errors.New("bootstrap: the store is not backed by a MongoDB collection")
+	}
+	if s.encoder != nil {
+		return fmt.Errorf("bootstrap: %w", ErrCustomDocument)
+	}
+	if s.readOnly {
+		return fmt.Errorf("bootstrap: %w", ErrReadOnly)
+	}
+
+	createOpts := options.CreateCollection()
+	if cfg.schemaValidation {
+		createOpts.SetValidator(leaseSchema)
+	}
+	err := collection.Database().CreateCollection(ctx, collection.Name(), createOpts)
+	switch {
+	case hasErrorCode(err, namespaceExistsCode):
+		if cfg.schemaValidation {
+			err = collection.Database().RunCommand(ctx, bson.D{
+				{Key: "collMod", Value: collection.Name()},
+				{Key: "validator", Value: leaseSchema},
+			}).Err()
+			if err != nil {
+				return fmt.Errorf("bootstrap %s: install validator: %w", namespace(collection), err)
+			}
+		}
+	case err != nil:
+		return fmt.Errorf("bootstrap %s: create collection: %w", namespace(collection), err)
+	}
+
+	return s.EnsureIndexes(ctx)
+}
+
+// EnsureIndexes creates, if missing, the indexes of EnsureHolderIndex and
+// EnsureExpiryIndex. It is idempotent.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	if err := s.EnsureHolderIndex(ctx); err != nil {
+		return err
+	}
+	err := s.EnsureExpiryIndex(ctx)
+	if hasErrorCode(err, indexOptionsConflictCode) {
+		// A TTL index on renew_time, see EnsureTTLIndex, serves as well.
+		return nil
+	}
+	return err
+}
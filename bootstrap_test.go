@@ -0,0 +1,72 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestBootstrap(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	require.NoError(t, store.Bootstrap(ctx), "A missing collection should be created")
+	require.NoError(t, store.Bootstrap(ctx, WithSchemaValidation()), "An existing collection should get the validator")
+	require.NoError(t, store.Bootstrap(ctx, WithSchemaValidation()), "Bootstrapping should be idempotent")
+
+	info, err := store.DescribeCollection(ctx)
+	require.NoError(t, err)
+	assert.True(t, info.SchemaValidation, "Validator should be installed")
+	keys := make([]bson.D, 0, len(info.Indexes))
+	for _, index := range info.Indexes {
+		keys = append(keys, index.Keys)
+	}
+	assert.ElementsMatch(t, []bson.D{
+		{{Key: "_id", Value: int32(1)}},
+		{{Key: "holder_identity", Value: int32(1)}, {Key: "renew_time", Value: int32(1)}},
+		{{Key: "renew_time", Value: int32(1)}},
+	}, keys)
+
+	_, err = collection.InsertOne(ctx, bson.M{"_id": "malformed", "holder_identity": "candidate-1", "renew_time": "yesterday"})
+	var writeErr mongo.WriteException
+	require.ErrorAs(t, err, &writeErr, "Malformed leases should be rejected")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err, "Acquired leases should pass validation")
+
+	minimal, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "minimal-lease-key",
+	}, WithMinimalDocument())
+	require.NoError(t, err, "Failed to create store")
+	now := time.Now()
+	require.NoError(t, minimal.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}), "Minimal documents should pass validation")
+}
+
+func TestBootstrapWithoutCollection(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, newFakeCollection())
+
+	require.Error(t, store.Bootstrap(context.Background()), "A store not backed by MongoDB cannot be bootstrapped")
+}
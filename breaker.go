@@ -0,0 +1,187 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithCircuitBreaker opens a circuit around the lease collection after
+// failures consecutive calls failed with a transient error or timed out, so
+// that a fleet of candidates does not keep hammering an unhealthy cluster.
+// While the circuit is open, calls fail fast with ErrCircuitOpen. After
+// cooldown, a single trial call is let through: the circuit closes if it
+// succeeds and stays open for another cooldown otherwise. onChange, if set, is
+// called with true when the circuit opens and false when it closes; it must
+// not block. By default there is no circuit breaker.
+func WithCircuitBreaker(failures int, cooldown time.Duration, onChange func(open bool)) Option {
+	return func(s *Store) {
+		if failures <= 0 {
+			s.breaker = nil
+			return
+		}
+		s.breaker = &circuitBreaker{failures: failures, cooldown: cooldown, onChange: onChange}
+	}
+}
+
+// circuitBreaker counts consecutive failures of the calls to the lease
+// collections.
+type circuitBreaker struct {
+	failures int
+	cooldown time.Duration
+	onChange func(open bool)
+
+	mu        sync.Mutex
+	failed    int       // Consecutive failures.
+	openUntil time.Time // Zero while closed.
+	trial     bool      // A trial call is in flight.
+}
+
+// allow reports whether a call may go through.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	if b.trial || time.Now().Before(b.openUntil) {
+		return ErrCircuitOpen
+	}
+	b.trial = true
+	return nil
+}
+
+// record counts the outcome of a call that was allowed.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	wasOpen := !b.openUntil.IsZero()
+	b.trial = false
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The caller gave up, which says nothing about the cluster.
+	case isTransient(err) || errors.Is(err, context.DeadlineExceeded):
+		b.failed++
+		if b.failed >= b.failures {
+			b.openUntil = time.Now().Add(b.cooldown)
+		}
+	default:
+		b.failed = 0
+		b.openUntil = time.Time{}
+	}
+	isOpen := !b.openUntil.IsZero()
+	b.mu.Unlock()
+
+	if b.onChange != nil && isOpen != wasOpen {
+		b.onChange(isOpen)
+	}
+}
+
+// applyCircuitBreaker makes the lease collections share the circuit breaker,
+// if any. It must run after applyOperationTimeout, so that timeouts count as
+// failures and a call counts once however often it was retried.
+func (s *Store) applyCircuitBreaker() {
+	if s.breaker == nil {
+		return
+	}
+
+	s.collection = &breakerCollection{leaseCollection: s.collection, breaker: s.breaker}
+	if s.staleReads != nil {
+		s.staleReads = &breakerCollection{leaseCollection: s.staleReads, breaker: s.breaker}
+	}
+	if s.confirmReads != nil {
+		s.confirmReads = &breakerCollection{leaseCollection: s.confirmReads, breaker: s.breaker}
+	}
+}
+
+// breakerCollection runs a call to the wrapped collection only while its
+// circuit breaker allows it, and reports the outcome to the breaker.
+type breakerCollection struct {
+	leaseCollection
+	breaker *circuitBreaker
+}
+
+func (c *breakerCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	if err := c.breaker.allow(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	result := settled(c.leaseCollection.FindOne(ctx, filter, opts...))
+	c.breaker.record(result.Err())
+	return result
+}
+
+func (c *breakerCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	cursor, err := c.leaseCollection.Find(ctx, filter, opts...)
+	c.breaker.record(err)
+	return cursor, err
+}
+
+func (c *breakerCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return 0, err
+	}
+	count, err := c.leaseCollection.CountDocuments(ctx, filter, opts...)
+	c.breaker.record(err)
+	return count, err
+}
+
+func (c *breakerCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	result, err := c.leaseCollection.InsertOne(ctx, document, opts...)
+	c.breaker.record(err)
+	return result, err
+}
+
+func (c *breakerCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	result, err := c.leaseCollection.UpdateOne(ctx, filter, update, opts...)
+	c.breaker.record(err)
+	return result, err
+}
+
+func (c *breakerCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	if err := c.breaker.allow(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	result := settled(c.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts...))
+	c.breaker.record(result.Err())
+	return result
+}
+
+func (c *breakerCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	result, err := c.leaseCollection.ReplaceOne(ctx, filter, replacement, opts...)
+	c.breaker.record(err)
+	return result, err
+}
+
+func (c *breakerCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	result, err := c.leaseCollection.DeleteOne(ctx, filter, opts...)
+	c.breaker.record(err)
+	return result, err
+}
+
+func (c *breakerCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	if err := c.breaker.allow(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	result := settled(c.leaseCollection.FindOneAndDelete(ctx, filter, opts...))
+	c.breaker.record(result.Err())
+	return result
+}
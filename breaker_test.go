@@ -0,0 +1,71 @@
+package mongoleasestore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var (
+		mu      sync.Mutex
+		changes []bool
+	)
+	collection := &flakyCollection{fakeCollection: newFakeCollection()}
+	store := newTestStore(t, collection, WithCircuitBreaker(2, 50*time.Millisecond, func(open bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, open)
+	}))
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	require.NoError(t, store.CreateLease(ctx, lease))
+
+	collection.mu.Lock()
+	collection.failures, collection.err = 3, mongo.CommandError{Code: 10107, Name: "NotWritablePrimary"}
+	collection.mu.Unlock()
+	renew := func() error {
+		lease.RenewTime = lease.RenewTime.Add(time.Millisecond)
+		return store.UpdateLease(ctx, lease)
+	}
+	attempts := func() int {
+		collection.mu.Lock()
+		defer collection.mu.Unlock()
+		return collection.attempts
+	}
+
+	require.Error(t, renew())
+	require.Error(t, renew())
+	assert.ErrorIs(t, renew(), ErrCircuitOpen, "The circuit should open after 2 failures")
+	assert.Equal(t, 2, attempts(), "An open circuit should not reach the collection")
+
+	time.Sleep(60 * time.Millisecond)
+	err := renew()
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCircuitOpen, "A trial call should go through after the cooldown")
+	assert.ErrorIs(t, renew(), ErrCircuitOpen, "A failed trial should reopen the circuit")
+
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, renew(), "A successful trial should close the circuit")
+	require.NoError(t, renew())
+	assert.Equal(t, 5, attempts())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []bool{true, false}, changes)
+}
@@ -0,0 +1,122 @@
+package mongoleasestore
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// StoreBuilder builds a Store with chainable methods, as an alternative to
+// NewStore for stores configured with many options. Each method maps to an
+// Option; Option adds the ones without a method.
+type StoreBuilder struct {
+	args       Args
+	opts       []Option
+	durability DurabilityProfile
+}
+
+// NewStoreBuilder returns an empty StoreBuilder.
+func NewStoreBuilder() *StoreBuilder {
+	return &StoreBuilder{}
+}
+
+// Collection sets the collection holding the lease.
+func (b *StoreBuilder) Collection(collection *mongo.Collection) *StoreBuilder {
+	b.args.LeaseCollection = collection
+	return b
+}
+
+// Key sets the lease key.
+func (b *StoreBuilder) Key(key string) *StoreBuilder {
+	b.args.LeaseKey = key
+	return b
+}
+
+// Timeout bounds every operation, see WithOperationTimeout.
+func (b *StoreBuilder) Timeout(timeout time.Duration) *StoreBuilder {
+	return b.Option(WithOperationTimeout(timeout))
+}
+
+// WriteConcern sets the write concern of the lease collection.
+func (b *StoreBuilder) WriteConcern(wc *writeconcern.WriteConcern) *StoreBuilder {
+	b.durability.WriteConcern = wc
+	return b
+}
+
+// ReadConcern sets the read concern of the lease collection.
+func (b *StoreBuilder) ReadConcern(rc *readconcern.ReadConcern) *StoreBuilder {
+	b.durability.ReadConcern = rc
+	return b
+}
+
+// ReadPreference sets the read preference of the lease collection. See
+// StaleReadPreference to offload observers from the primary instead.
+func (b *StoreBuilder) ReadPreference(rp *readpref.ReadPref) *StoreBuilder {
+	b.durability.ReadPreference = rp
+	return b
+}
+
+// StaleReadPreference routes the reads of observers to servers selected by
+// rp, see WithStaleReadPreference.
+func (b *StoreBuilder) StaleReadPreference(rp *readpref.ReadPref) *StoreBuilder {
+	return b.Option(WithStaleReadPreference(rp))
+}
+
+// Clock sets the clock, see WithClock.
+func (b *StoreBuilder) Clock(clock Clock) *StoreBuilder {
+	return b.Option(WithClock(clock))
+}
+
+// Logger sets the logger, see WithLogger.
+func (b *StoreBuilder) Logger(logger *slog.Logger) *StoreBuilder {
+	return b.Option(WithLogger(logger))
+}
+
+// SoftDelete enables soft deletes, see WithSoftDelete.
+func (b *StoreBuilder) SoftDelete() *StoreBuilder {
+	return b.Option(WithSoftDelete())
+}
+
+// ReadCache caches reads for ttl, see WithReadCache.
+func (b *StoreBuilder) ReadCache(ttl time.Duration) *StoreBuilder {
+	return b.Option(WithReadCache(ttl))
+}
+
+// Region sets the store's region, see WithRegion.
+func (b *StoreBuilder) Region(region string) *StoreBuilder {
+	return b.Option(WithRegion(region))
+}
+
+// Metrics sets the metrics hook, see WithMetrics.
+func (b *StoreBuilder) Metrics(metrics Metrics) *StoreBuilder {
+	return b.Option(WithMetrics(metrics))
+}
+
+// Option adds opts as they would be passed to NewStore.
+func (b *StoreBuilder) Option(opts ...Option) *StoreBuilder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Build validates the configuration and creates the Store.
+func (b *StoreBuilder) Build() (*Store, error) {
+	if b.args.LeaseCollection == nil {
+		return nil, errors.New("lease collection is required")
+	}
+	if b.args.LeaseKey == "" {
+		return nil, errors.New("lease key is required")
+	}
+
+	opts := b.opts
+	if b.durability != (DurabilityProfile{}) {
+		// Applied first so that a WithDurabilityProfile passed to Option wins.
+		opts = append([]Option{WithDurabilityProfile(b.durability)}, opts...)
+	}
+
+	return NewStore(b.args, opts...)
+}
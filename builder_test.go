@@ -0,0 +1,87 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestStoreBuilder(t *testing.T) {
+	t.Parallel()
+
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+	collection := mongoClient.Database("leases").Collection("elections")
+
+	store, err := NewStoreBuilder().
+		Collection(collection).
+		Key("test-lease-key").
+		Timeout(time.Second).
+		WriteConcern(writeconcern.Majority()).
+		ReadPreference(readpref.Primary()).
+		StaleReadPreference(readpref.SecondaryPreferred()).
+		Region("eu-west-1").
+		Option(WithGracePeriod(time.Second)).
+		Build()
+	require.NoError(t, err, "Failed to build store")
+
+	cfg := store.Config()
+	assert.Equal(t, "test-lease-key", cfg.LeaseKey)
+	assert.Equal(t, "leases.elections", cfg.Namespace)
+	assert.Equal(t, time.Second, cfg.OperationTimeout)
+	assert.Equal(t, writeconcern.Majority(), cfg.WriteConcern)
+	assert.Equal(t, readpref.Primary(), cfg.ReadPreference)
+	assert.Equal(t, readpref.SecondaryPreferred(), cfg.StaleReadPreference)
+	assert.Nil(t, cfg.ReadConcern, "Unset concerns should be inherited")
+	assert.Equal(t, "eu-west-1", cfg.Region)
+	assert.Equal(t, time.Second, cfg.GracePeriod)
+
+	_, err = NewStoreBuilder().Key("test-lease-key").Build()
+	require.Error(t, err, "Collection should be required")
+	_, err = NewStoreBuilder().Collection(collection).Build()
+	require.Error(t, err, "Key should be required")
+}
+
+func TestStoreBuilderStore(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStoreBuilder().
+		Collection(collection).
+		Key("test-lease-key").
+		Timeout(5 * time.Second).
+		WriteConcern(writeconcern.Majority()).
+		Build()
+	require.NoError(t, err, "Failed to build store")
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+
+	lease, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "candidate-1", lease.HolderIdentity)
+
+	leases, err := store.ListLeases(ctx, false)
+	require.NoError(t, err)
+	assert.Len(t, leases, 1)
+}
@@ -0,0 +1,82 @@
+package mongoleasestore
+
+import (
+	"sync"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// readCache holds the last lease read by GetLease for a short TTL. A nil
+// *readCache is a disabled cache.
+type readCache struct {
+	ttl time.Duration
+
+	mu         sync.Mutex
+	lease      *le.Lease
+	fetchedAt  time.Time
+	generation uint64 // Incremented by every invalidation.
+}
+
+func newReadCache(ttl time.Duration) *readCache {
+	return &readCache{ttl: ttl}
+}
+
+// get returns a copy of the cached lease if it is still fresh.
+func (c *readCache) get() (*le.Lease, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lease == nil || time.Since(c.fetchedAt) >= c.ttl {
+		return nil, false
+	}
+
+	lease := *c.lease
+	return &lease, true
+}
+
+// current returns the generation to pass to set for a lease about to be read.
+func (c *readCache) current() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// set caches lease, read since generation was current. It is dropped if the
+// cache was invalidated in between, as the read may predate the write.
+func (c *readCache) set(lease *le.Lease, generation uint64) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	cached := *lease
+	c.lease = &cached
+	c.fetchedAt = time.Now()
+}
+
+func (c *readCache) invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lease = nil
+	c.generation++
+}
@@ -0,0 +1,98 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestReadCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithReadCache(time.Minute))
+
+	now := time.Now().Add(-time.Minute) // Expired, so that candidate-2 may take it over.
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+
+	first, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	second, err := store.GetLease(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, collection.callCount("FindOne"), "Second read within the TTL should be served from cache")
+	assert.Equal(t, first, second)
+
+	require.NoError(t, store.UpdateLease(ctx, &le.Lease{
+		HolderIdentity:    "candidate-2",
+		AcquireTime:       now,
+		RenewTime:         now,
+		LeaseDuration:     time.Second,
+		LeaderTransitions: 1,
+	}))
+
+	third, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, collection.callCount("FindOne"), "Writes should invalidate the cache")
+	assert.Equal(t, "candidate-2", third.HolderIdentity)
+}
+
+// delayedReadCollection returns the lease as read when FindOne is called, but
+// only once release is closed, signaling read first.
+type delayedReadCollection struct {
+	*fakeCollection
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (c *delayedReadCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	result := c.fakeCollection.FindOne(ctx, filter, opts...)
+	c.read <- struct{}{}
+	<-c.release
+	return result
+}
+
+func TestReadCacheWriteDuringRead(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	collection := &delayedReadCollection{fakeCollection: newFakeCollection(), read: make(chan struct{}), release: make(chan struct{})}
+	store := newTestStore(t, collection, WithReadCache(time.Minute))
+
+	now := time.Now()
+	lease := &le.Lease{HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now, LeaseDuration: time.Second}
+	require.NoError(t, store.CreateLease(ctx, lease))
+
+	// A read returns the lease as it was before a write that completes while
+	// the read is in flight.
+	done := make(chan *le.Lease)
+	go func() {
+		got, err := store.GetLease(ctx)
+		assert.NoError(t, err)
+		done <- got
+	}()
+	<-collection.read
+	renewed := *lease
+	renewed.RenewTime = now.Add(time.Second)
+	require.NoError(t, store.UpdateLease(ctx, &renewed))
+	close(collection.release)
+	assert.Equal(t, lease.RenewTime.UnixMilli(), (<-done).RenewTime.UnixMilli(), "The read should return the lease it read")
+
+	go func() { <-collection.read }()
+	got, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, renewed.RenewTime.UnixMilli(), got.RenewTime.UnixMilli(), "A read predating the write should not be cached")
+	assert.Equal(t, 2, collection.callCount("FindOne"))
+}
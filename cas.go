@@ -0,0 +1,155 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UpdateLeaseCAS updates the lease to newLease only if its stored renew_time
+// still equals expectedRenewTime, as read from GetLease, in a single
+// conditional write. It is the building block for optimistic read-compute-write
+// cycles. The preconditions of UpdateLease, such as WithStrictRegion,
+// WithMinRenewInterval and WithMaxTransitionRate, apply and fail it with the
+// same errors. Returns ErrLeaseConflict if the lease changed and
+// ErrLeaseNotFound if it does not exist.
+func (s *Store) UpdateLeaseCAS(ctx context.Context, expectedRenewTime time.Time, newLease *le.Lease) error {
+	filter := s.leaseFilter()
+	// Stored with millisecond precision.
+	filter["renew_time"] = expectedRenewTime.Truncate(time.Millisecond)
+	return s.updateLeaseCAS(ctx, filter, newLease)
+}
+
+// UpdateLeaseFrom updates the lease to newLease only if it is still held by
+// expected's holder with expected's renew time, as read from GetLease, in a
+// single conditional write. UpdateLease only checks the holder, the transition
+// count and, for takeovers, the expiry, so a renew or a release still writes
+// over a lease renewed in between; UpdateLeaseFrom also pins the renew time,
+// so that any write in between makes it miss. The preconditions of UpdateLease
+// apply, as for UpdateLeaseCAS. Returns ErrLeaseConflict if the lease changed
+// and ErrLeaseNotFound if it does not exist.
+func (s *Store) UpdateLeaseFrom(ctx context.Context, expected, newLease *le.Lease) error {
+	filter := s.leaseFilter()
+	filter["holder_identity"] = expected.HolderIdentity
+	// Stored with millisecond precision.
+	filter["renew_time"] = expected.RenewTime.Truncate(time.Millisecond)
+	return s.updateLeaseCAS(ctx, filter, newLease)
+}
+
+// updateLeaseCAS updates the lease matching pin to newLease, subject to the
+// preconditions of UpdateLease.
+func (s *Store) updateLeaseCAS(ctx context.Context, pin bson.M, newLease *le.Lease) (err error) {
+	defer s.observeOperation("update", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "update", newLease.HolderIdentity)
+	defer endSpan(span, &err)
+	defer s.observeWrite("update", newLease, time.Now(), &err)
+	defer s.recordRejection("update", newLease.HolderIdentity, &err)
+	defer s.logRejection(ctx, "update", newLease.HolderIdentity, &err)
+
+	if err := s.checkContext(ctx, "update"); err != nil {
+		return err
+	}
+	if err := s.requireDefaultDocument("update"); err != nil {
+		return err
+	}
+	if err := s.checkDrain("update", newLease); err != nil {
+		return err
+	}
+
+	defer s.readCache.invalidate()
+
+	if err := s.yieldIfNotPreferred(ctx, "update", newLease.HolderIdentity); err != nil {
+		return err
+	}
+
+	start := s.clock.Now()
+	// Kept apart from pin, whose holder the cool-down would overwrite.
+	preconditions := bson.M{}
+	conditioned := s.addUpdatePreconditions(preconditions, newLease)
+	filter := pin
+	if conditioned {
+		filter = bson.M{"$and": bson.A{pin, preconditions}}
+	}
+	update, err := s.leaseUpdate(newLease)
+	if err != nil {
+		return err
+	}
+
+	sent := time.Now()
+	outcome, err := s.updateOne(ctx, filter, update, newLease)
+	s.stats.observe(sent)
+	if err != nil {
+		return err
+	}
+
+	if !outcome.matched {
+		return s.explainCASMiss(ctx, pin, newLease, conditioned)
+	}
+
+	return s.finishWrite(ctx, "update", newLease, outcome.before, start)
+}
+
+// explainCASMiss explains why an update of the lease matching pin to newLease
+// matched nothing: as explainUpdateMiss does if the lease matches pin, so that
+// only the preconditions (if conditioned) failed, and as conflictOrNotFound
+// does otherwise.
+func (s *Store) explainCASMiss(ctx context.Context, pin bson.M, newLease *le.Lease, conditioned bool) error {
+	if conditioned {
+		count, err := s.collection.CountDocuments(ctx, pin, s.countOptions())
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return s.explainUpdateMiss(ctx, newLease)
+		}
+	}
+	return s.conflictOrNotFound(ctx)
+}
+
+// RenewWithCASRetry renews the lease held by holder for duration with
+// UpdateLeaseCAS, conditioned on a fresh read. When the lease changes between
+// the read and the write, it reads it again and retries, up to maxAttempts
+// attempts in total. Returns the renewed lease, ErrLeaseLost if holder no longer
+// holds the lease, or ErrLeaseConflict if every attempt conflicted.
+func (s *Store) RenewWithCASRetry(ctx context.Context, holder string, duration time.Duration, maxAttempts int) (*le.Lease, error) {
+	if err := s.checkContext(ctx, "renew"); err != nil {
+		return nil, err
+	}
+	if holder == "" {
+		return nil, fmt.Errorf("renew lease %q: %w", s.leaseKey, ErrEmptyHolder)
+	}
+
+	attempts := max(maxAttempts, 1)
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		var current *le.Lease
+		current, err = s.fetchLease(ctx, s.collection)
+		if err != nil {
+			return nil, err
+		}
+		if current.HolderIdentity != holder {
+			return nil, fmt.Errorf("renew lease %q for %q: %w", s.leaseKey, holder, ErrLeaseLost)
+		}
+
+		renewed := &le.Lease{
+			HolderIdentity:    holder,
+			AcquireTime:       current.AcquireTime,
+			RenewTime:         s.clock.Now().Truncate(time.Millisecond), // Stored with millisecond precision.
+			LeaseDuration:     duration,
+			LeaderTransitions: current.LeaderTransitions,
+		}
+		err = s.UpdateLeaseCAS(ctx, current.RenewTime, renewed)
+		if err == nil {
+			return renewed, nil
+		}
+		if !errors.Is(err, ErrLeaseConflict) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("renew lease %q for %q after %d attempts: %w", s.leaseKey, holder, attempts, err)
+}
@@ -0,0 +1,215 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestUpdateLeaseCAS(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	lease := func(holder string, renewTime time.Time) *le.Lease {
+		return &le.Lease{
+			HolderIdentity: holder,
+			AcquireTime:    renewTime,
+			RenewTime:      renewTime,
+			LeaseDuration:  time.Minute,
+		}
+	}
+	now := time.Now()
+
+	t.Run("Missing", func(t *testing.T) {
+		err := store.UpdateLeaseCAS(ctx, now, lease("candidate-1", now))
+		require.ErrorIs(t, err, le.ErrLeaseNotFound)
+	})
+
+	require.NoError(t, store.CreateLease(ctx, lease("candidate-1", now)))
+	read, err := store.GetLease(ctx)
+	require.NoError(t, err)
+
+	later := now.Add(time.Second)
+	t.Run("Match", func(t *testing.T) {
+		require.NoError(t, store.UpdateLeaseCAS(ctx, read.RenewTime, lease("candidate-1", later)))
+
+		stored, err := store.GetLease(ctx)
+		require.NoError(t, err)
+		assert.WithinDuration(t, later, stored.RenewTime, time.Millisecond)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		// read is stale after the previous update.
+		err := store.UpdateLeaseCAS(ctx, read.RenewTime, lease("candidate-2", later.Add(time.Second)))
+		require.ErrorIs(t, err, ErrLeaseConflict)
+
+		stored, err := store.GetLease(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "candidate-1", stored.HolderIdentity, "Conflicting update should not be applied")
+	})
+}
+
+func TestUpdateLeaseCASPreconditions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	observer := &recordingObserver{}
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithMinRenewInterval(time.Minute), WithObserver(observer))
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}
+	require.NoError(t, store.CreateLease(ctx, lease))
+	read, err := store.GetLease(ctx)
+	require.NoError(t, err)
+
+	renewed := *lease
+	renewed.RenewTime = now.Add(time.Second)
+	err = store.UpdateLeaseCAS(ctx, read.RenewTime, &renewed)
+	require.ErrorIs(t, err, ErrRenewTooSoon, "Renews should be held to the minimum interval")
+	err = store.UpdateLeaseFrom(ctx, read, &renewed)
+	require.ErrorIs(t, err, ErrRenewTooSoon, "Renews should be held to the minimum interval")
+	err = store.UpdateLeaseCAS(ctx, now.Add(-time.Hour), &renewed)
+	require.ErrorIs(t, err, ErrLeaseConflict, "A stale read should still be reported as a conflict")
+	assert.True(t, collection.doc.RenewTime.Equal(read.RenewTime), "Rejected renews should not be applied")
+
+	assert.Equal(t, observedCall{op: "update", holder: "candidate-1", err: err}, observer.calls[len(observer.calls)-1],
+		"Updates should be reported to the observer")
+}
+
+func TestUpdateLeaseFrom(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	lease := func(holder string, renewTime time.Time) *le.Lease {
+		return &le.Lease{
+			HolderIdentity: holder,
+			AcquireTime:    renewTime,
+			RenewTime:      renewTime,
+			LeaseDuration:  time.Minute,
+		}
+	}
+	now := time.Now()
+
+	t.Run("Missing", func(t *testing.T) {
+		err := store.UpdateLeaseFrom(ctx, lease("candidate-1", now), lease("candidate-1", now))
+		require.ErrorIs(t, err, le.ErrLeaseNotFound)
+	})
+
+	require.NoError(t, store.CreateLease(ctx, lease("candidate-1", now)))
+	read, err := store.GetLease(ctx)
+	require.NoError(t, err)
+
+	t.Run("Match", func(t *testing.T) {
+		later := now.Add(time.Second)
+		require.NoError(t, store.UpdateLeaseFrom(ctx, read, lease("candidate-1", later)))
+
+		read, err = store.GetLease(ctx)
+		require.NoError(t, err)
+		assert.WithinDuration(t, later, read.RenewTime, time.Millisecond)
+	})
+
+	t.Run("HolderChanged", func(t *testing.T) {
+		// Another holder takes the lease over at the very same renew time.
+		require.NoError(t, store.UpdateLeaseCAS(ctx, read.RenewTime, lease("candidate-2", read.RenewTime)))
+
+		err := store.UpdateLeaseFrom(ctx, read, lease("candidate-1", read.RenewTime.Add(time.Second)))
+		require.ErrorIs(t, err, ErrLeaseConflict)
+
+		stored, err := store.GetLease(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "candidate-2", stored.HolderIdentity, "Conflicting update should not be applied")
+	})
+}
+
+// interceptedCollection runs afterFind after every FindOne on the wrapped
+// collection.
+type interceptedCollection struct {
+	leaseCollection
+	afterFind func()
+}
+
+func (c *interceptedCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	result := c.leaseCollection.FindOne(ctx, filter, opts...)
+	c.afterFind()
+	return result
+}
+
+func TestRenewWithCASRetry(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	now := time.Now().Add(-time.Second)
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}))
+
+	// A concurrent renew by the same holder lands between the first read and
+	// the first write.
+	reads := 0
+	store.collection = &interceptedCollection{
+		leaseCollection: collection,
+		afterFind: func() {
+			reads++
+			if reads == 1 {
+				_, err := collection.UpdateOne(ctx, bson.M{"_id": "test-lease-key"}, bson.M{"$set": bson.M{"renew_time": now.Add(time.Millisecond)}})
+				require.NoError(t, err)
+			}
+		},
+	}
+
+	renewed, err := store.RenewWithCASRetry(ctx, "candidate-1", 2*time.Minute, 3)
+	require.NoError(t, err, "Renew should succeed after refreshing")
+	assert.Equal(t, 2, reads, "Conflict should trigger one refresh")
+	assert.Equal(t, 2*time.Minute, renewed.LeaseDuration)
+
+	stored, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.True(t, stored.RenewTime.Equal(renewed.RenewTime), "Returned lease should be the stored one")
+	assert.Equal(t, renewed.LeaseDuration, stored.LeaseDuration)
+
+	_, err = store.RenewWithCASRetry(ctx, "candidate-2", time.Minute, 3)
+	require.ErrorIs(t, err, ErrLeaseLost)
+}
@@ -0,0 +1,234 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LeaseEventType is the kind of change reported by a LeaseEvent.
+type LeaseEventType string
+
+const (
+	// LeaseCreated reports the insertion of the lease document.
+	LeaseCreated LeaseEventType = "create"
+	// LeaseUpdated reports a write to an existing lease document.
+	LeaseUpdated LeaseEventType = "update"
+	// LeaseDeleted reports the removal of the lease document, a soft delete
+	// or the drop of the collection included.
+	LeaseDeleted LeaseEventType = "delete"
+)
+
+// LeaseEvent is a change of the lease document emitted by Watch.
+type LeaseEvent struct {
+	Type LeaseEventType
+	// Lease is the lease after the change, nil for LeaseDeleted.
+	Lease *le.Lease
+	// Resync is set on the event reporting the current state of the lease
+	// after the stream could not be resumed where it stopped: changes that
+	// happened in between were missed.
+	Resync bool
+}
+
+// Delays between two attempts to reopen the change stream of Watch, doubling
+// from the first to the last.
+const (
+	watchReconnectMin = 100 * time.Millisecond
+	watchReconnectMax = 5 * time.Second
+)
+
+// changeStreamHistoryLostCode is the server error code of a change stream
+// whose resume point is no longer in the oplog.
+const changeStreamHistoryLostCode = 286
+
+// Watch emits a LeaseEvent for every write to the lease document, read from
+// a MongoDB change stream, so that consumers can react to leadership changes
+// without polling. Change streams need a replica set or a sharded cluster,
+// see WatchLeaderPolling otherwise. The channel is closed once ctx is done or
+// the store is closed.
+//
+// Update events carry the lease as read when the event is delivered, which
+// may already include later writes. A stream that fails is reopened where it
+// stopped; if the oplog no longer holds that point, it is reopened from the
+// present and an event with Resync set reports the current state of the
+// lease.
+func (s *Store) Watch(ctx context.Context) (<-chan LeaseEvent, error) {
+	collection := s.mongoCollection()
+	if collection == nil {
+		return nil, errors.New("watch: the store is not backed by a MongoDB collection")
+	}
+	if s.closeGate.isClosed() {
+		return nil, fmt.Errorf("watch lease %q: %w", s.leaseKey, ErrStoreClosed)
+	}
+
+	ctx, cancel := s.untilClosed(ctx)
+	stream, err := s.openChangeStream(ctx, collection, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("watch lease %q: %w", s.leaseKey, err)
+	}
+
+	events := make(chan LeaseEvent, 1)
+	go func() {
+		defer close(events)
+		defer cancel()
+		delay := watchReconnectMin
+		for {
+			resumeToken, err := s.forwardChangeStream(ctx, stream, events)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil && s.logger != nil {
+				s.logger.WarnContext(ctx, "lease change stream failed", "lease_key", s.leaseKey, "error", err)
+			}
+
+			for stream = nil; stream == nil; {
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				}
+				delay = min(2*delay, watchReconnectMax)
+
+				if stream, err = s.openChangeStream(ctx, collection, resumeToken); err == nil {
+					break
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				if s.logger != nil {
+					s.logger.WarnContext(ctx, "failed to reopen lease change stream", "lease_key", s.leaseKey, "resuming", resumeToken != nil, "error", err)
+				}
+				if resumeToken == nil || !hasErrorCode(err, changeStreamHistoryLostCode) {
+					continue
+				}
+
+				// The resume point left the oplog: start over from the present
+				// and report the state of the lease instead.
+				resumeToken = nil
+				if stream, err = s.openChangeStream(ctx, collection, nil); err != nil {
+					continue
+				}
+				if !s.sendResync(ctx, events) {
+					_ = stream.Close(context.Background())
+					return
+				}
+			}
+			delay = watchReconnectMin
+		}
+	}()
+
+	return events, nil
+}
+
+// openChangeStream opens a change stream of the writes to the lease document,
+// resuming after resumeToken if not nil.
+func (s *Store) openChangeStream(ctx context.Context, collection *mongo.Collection, resumeToken bson.Raw) (*mongo.ChangeStream, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"$or": bson.A{
+		bson.M{"documentKey._id": s.leaseKey},
+		bson.M{"operationType": bson.M{"$in": bson.A{"drop", "rename", "dropDatabase"}}},
+	}}}}}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeToken != nil {
+		opts.SetResumeAfter(resumeToken)
+	}
+	return collection.Watch(ctx, pipeline, opts)
+}
+
+// forwardChangeStream sends the events of stream until it fails or ctx is
+// done, and closes it. It returns the token to resume the stream after the
+// last event sent, nil once the stream was invalidated.
+func (s *Store) forwardChangeStream(ctx context.Context, stream *mongo.ChangeStream, events chan<- LeaseEvent) (bson.Raw, error) {
+	defer func() { _ = stream.Close(context.Background()) }()
+
+	for stream.Next(ctx) {
+		if operationType, _ := stream.Current.Lookup("operationType").StringValueOK(); operationType == "invalidate" {
+			// Follows the drop or rename of the collection, already reported.
+			return nil, nil
+		}
+		event, ok, err := s.leaseEvent(stream.Current)
+		if err != nil {
+			return stream.ResumeToken(), err
+		}
+		if ok {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return stream.ResumeToken(), stream.Err()
+}
+
+// sendResync sends the current state of the lease as a Resync event. It
+// reports false once ctx is done.
+func (s *Store) sendResync(ctx context.Context, events chan<- LeaseEvent) bool {
+	event := LeaseEvent{Type: LeaseDeleted, Resync: true}
+	lease, err := s.fetchLease(ctx, s.collection)
+	switch {
+	case err == nil:
+		event.Type, event.Lease = LeaseUpdated, lease
+	case !errors.Is(err, le.ErrLeaseNotFound):
+		if s.logger != nil && ctx.Err() == nil {
+			s.logger.WarnContext(ctx, "failed to read lease after change stream gap", "lease_key", s.leaseKey, "error", err)
+		}
+		return ctx.Err() == nil
+	}
+
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// changeEvent is the part of a change stream event the store reads.
+type changeEvent struct {
+	OperationType string   `bson:"operationType"`
+	FullDocument  bson.Raw `bson:"fullDocument,omitempty"`
+}
+
+// leaseEvent converts a change stream event to a LeaseEvent. It reports
+// false for events that are not emitted, such as updates of a document
+// deleted before it could be looked up, whose deletion is reported next.
+func (s *Store) leaseEvent(raw bson.Raw) (LeaseEvent, bool, error) {
+	var change changeEvent
+	if err := bson.Unmarshal(raw, &change); err != nil {
+		return LeaseEvent{}, false, fmt.Errorf("decode change of lease %q: %w", s.leaseKey, err)
+	}
+
+	var event LeaseEvent
+	switch change.OperationType {
+	case "insert":
+		event.Type = LeaseCreated
+	case "update", "replace":
+		event.Type = LeaseUpdated
+	case "delete", "drop", "rename", "dropDatabase":
+		return LeaseEvent{Type: LeaseDeleted}, true, nil
+	default:
+		return LeaseEvent{}, false, nil
+	}
+	if len(change.FullDocument) == 0 {
+		return LeaseEvent{}, false, nil
+	}
+
+	doc, err := s.decodeDocument(mongo.NewSingleResultFromDocument(change.FullDocument, nil, nil))
+	if err != nil {
+		return LeaseEvent{}, false, err
+	}
+	if doc.DeletedAt != nil {
+		return LeaseEvent{Type: LeaseDeleted}, true, nil
+	}
+	event.Lease = doc.toLease()
+	return event, true, nil
+}
@@ -0,0 +1,288 @@
+package mongoleasestore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI(setupReplicaSetURI(t)))
+	require.NoError(t, err, "Failed to connect to mongo")
+	t.Cleanup(func() { _ = mongoClient.Disconnect(context.Background()) })
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := store.Watch(ctx)
+	require.NoError(t, err)
+
+	next := func() LeaseEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "Timed out waiting for a lease event")
+			return LeaseEvent{}
+		}
+	}
+
+	now := time.Now().Add(-time.Minute) // Expired, so that candidate-2 may take it over.
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}
+	require.NoError(t, store.CreateLease(context.Background(), lease))
+	// Other leases of the collection are not watched.
+	_, err = collection.InsertOne(context.Background(), BuildLeaseDocument("other-lease-key", lease))
+	require.NoError(t, err)
+	lease.HolderIdentity, lease.LeaderTransitions = "candidate-2", 1
+	require.NoError(t, store.UpdateLease(context.Background(), lease))
+	_, err = collection.DeleteOne(context.Background(), bson.M{"_id": "test-lease-key"})
+	require.NoError(t, err)
+
+	event := next()
+	assert.Equal(t, LeaseCreated, event.Type)
+	require.NotNil(t, event.Lease)
+	assert.Equal(t, "candidate-1", event.Lease.HolderIdentity)
+
+	event = next()
+	assert.Equal(t, LeaseUpdated, event.Type)
+	if event.Lease != nil {
+		assert.Equal(t, "candidate-2", event.Lease.HolderIdentity)
+	}
+
+	event = next()
+	assert.Equal(t, LeaseDeleted, event.Type)
+	assert.Nil(t, event.Lease)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, open := <-events
+		return !open
+	}, time.Second, 10*time.Millisecond, "Channel should be closed on cancel")
+}
+
+func TestWatchLeader(t *testing.T) {
+	t.Parallel()
+
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI(setupReplicaSetURI(t)))
+	require.NoError(t, err, "Failed to connect to mongo")
+	t.Cleanup(func() { _ = mongoClient.Disconnect(context.Background()) })
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	lease := func(holder string, duration time.Duration) *le.Lease {
+		now := time.Now()
+		return &le.Lease{
+			HolderIdentity: holder,
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  duration,
+		}
+	}
+	require.NoError(t, store.CreateLease(context.Background(), lease("candidate-1", time.Minute)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaders := store.WatchLeader(ctx)
+	next := func() string {
+		select {
+		case leader := <-leaders:
+			return leader
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "Timed out waiting for a leader")
+			return ""
+		}
+	}
+	assert.Equal(t, "candidate-1", next(), "Initial leader should be emitted")
+
+	// Renews are not leader changes.
+	require.NoError(t, store.UpdateLease(context.Background(), lease("candidate-1", time.Minute)))
+	require.NoError(t, store.UpdateLease(context.Background(), lease("", time.Minute)))
+	assert.Equal(t, "", next(), "Release should be emitted")
+	takeover := lease("candidate-2", time.Second)
+	takeover.LeaderTransitions = 1
+	require.NoError(t, store.UpdateLease(context.Background(), takeover))
+	assert.Equal(t, "candidate-2", next())
+	assert.Equal(t, "", next(), "Expiry should be emitted without a write")
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, open := <-leaders
+		return !open
+	}, time.Second, 10*time.Millisecond, "Channel should be closed on cancel")
+}
+
+func TestWatchStopsOnClose(t *testing.T) {
+	t.Parallel()
+
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI(setupReplicaSetURI(t)))
+	require.NoError(t, err, "Failed to connect to mongo")
+	t.Cleanup(func() { _ = mongoClient.Disconnect(context.Background()) })
+
+	store, err := NewStore(Args{
+		LeaseCollection: mongoClient.Database(t.Name()).Collection(t.Name()),
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	events, err := store.Watch(context.Background())
+	require.NoError(t, err)
+	leaders := store.WatchLeader(context.Background())
+	assert.Equal(t, "", <-leaders, "Initial leader should be emitted")
+
+	require.NoError(t, store.Close(context.Background()))
+	assert.Eventually(t, func() bool {
+		_, open := <-events
+		return !open
+	}, time.Second, 10*time.Millisecond, "Watch should stop on close")
+	assert.Eventually(t, func() bool {
+		_, open := <-leaders
+		return !open
+	}, time.Second, 10*time.Millisecond, "WatchLeader should stop on close")
+
+	_, err = store.Watch(context.Background())
+	require.ErrorIs(t, err, ErrStoreClosed)
+}
+
+func TestWatchWithoutCollection(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, newFakeCollection())
+
+	_, err := store.Watch(context.Background())
+	assert.Error(t, err, "Change streams need a MongoDB collection")
+
+	_, open := <-store.WatchLeader(context.Background())
+	assert.False(t, open, "WatchLeader should close its channel")
+}
+
+func TestLeaseEvent(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewStore(Args{LeaseKey: "test-lease-key"}, WithSoftDelete())
+	require.NoError(t, err, "Failed to create store")
+
+	now := time.Now().Truncate(time.Millisecond)
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}
+	live := BuildLeaseDocument("test-lease-key", lease)
+	deleted := BuildLeaseDocument("test-lease-key", lease)
+	deleted["deleted_at"] = now
+
+	tests := []struct {
+		name   string
+		change bson.M
+		want   LeaseEventType
+		lease  bool
+		ok     bool
+	}{
+		{"insert", bson.M{"operationType": "insert", "fullDocument": live}, LeaseCreated, true, true},
+		{"update", bson.M{"operationType": "update", "fullDocument": live}, LeaseUpdated, true, true},
+		{"replace", bson.M{"operationType": "replace", "fullDocument": live}, LeaseUpdated, true, true},
+		{"soft delete", bson.M{"operationType": "update", "fullDocument": deleted}, LeaseDeleted, false, true},
+		{"delete", bson.M{"operationType": "delete"}, LeaseDeleted, false, true},
+		{"drop", bson.M{"operationType": "drop"}, LeaseDeleted, false, true},
+		{"update of a deleted lease", bson.M{"operationType": "update", "fullDocument": nil}, "", false, false},
+		{"unknown", bson.M{"operationType": "createIndexes"}, "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := bson.Marshal(tt.change)
+			require.NoError(t, err)
+
+			event, ok, err := store.leaseEvent(raw)
+			require.NoError(t, err)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, event.Type)
+			assert.False(t, event.Resync)
+			if !tt.lease {
+				assert.Nil(t, event.Lease)
+				return
+			}
+			require.NotNil(t, event.Lease)
+			assert.Equal(t, "candidate-1", event.Lease.HolderIdentity)
+			assert.True(t, now.Equal(event.Lease.RenewTime))
+		})
+	}
+}
+
+// setupReplicaSetURI starts a MongoDB container running a single member
+// replica set, which change streams need, stopped when the test ends, and
+// returns its URI.
+func setupReplicaSetURI(t *testing.T) string {
+	t.Helper()
+
+	ctx := context.Background()
+	mongoContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "mongo:latest",
+			ExposedPorts: []string{"27017/tcp"},
+			Cmd:          []string{"--replSet", "rs0", "--bind_ip_all"},
+			WaitingFor:   wait.ForListeningPort("27017/tcp"),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to start mongo container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mongoContainer.Terminate(ctx); err != nil {
+			t.Logf("failed to terminate mongo container: %v", err)
+		}
+	})
+
+	eval := func(script string) string {
+		_, output, err := mongoContainer.Exec(ctx, []string{"mongosh", "--quiet", "--eval", script})
+		if err != nil {
+			t.Fatalf("failed to run mongosh: %v", err)
+		}
+		out, _ := io.ReadAll(output)
+		return string(out)
+	}
+	eval(`rs.initiate({_id: "rs0", members: [{_id: 0, host: "localhost:27017"}]})`)
+	require.Eventually(t, func() bool {
+		return strings.Contains(eval("db.hello().isWritablePrimary"), "true")
+	}, 30*time.Second, 200*time.Millisecond, "Replica set should elect a primary")
+
+	mappedPort, err := mongoContainer.MappedPort(ctx, "27017/tcp")
+	if err != nil {
+		t.Fatalf("failed to get mapped port: %v", err)
+	}
+	hostIP, err := mongoContainer.Host(ctx)
+	if err != nil {
+		t.Fatalf("failed to get container host: %v", err)
+	}
+
+	// The member is known as localhost inside the container only.
+	return "mongodb://" + hostIP + ":" + mappedPort.Port() + "/?directConnection=true"
+}
@@ -0,0 +1,37 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewStoreFromURI connects to the deployment at uri and creates a store for
+// the lease key in the given database and collection. The store owns the
+// client: Close disconnects it. The connection is checked with a ping, so that
+// a wrong URI fails here rather than at the first lease operation.
+func NewStoreFromURI(ctx context.Context, uri, database, collection, key string, opts ...Option) (*Store, error) {
+	// The URI is left out of errors, it may hold credentials.
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("connect to mongo: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, fmt.Errorf("ping mongo: %w", err)
+	}
+
+	store, err := NewStore(Args{
+		LeaseCollection: client.Database(database).Collection(collection),
+		LeaseKey:        key,
+	}, opts...)
+	if err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, err
+	}
+	store.ownedClient = client
+
+	return store, nil
+}
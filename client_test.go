@@ -0,0 +1,63 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewStoreFromURI(t *testing.T) {
+	t.Parallel()
+
+	uri := setupMongoURI(t)
+	ctx := context.Background()
+
+	store, err := NewStoreFromURI(ctx, uri, t.Name(), t.Name(), "test-lease-key")
+	require.NoError(t, err, "Failed to create store")
+	assert.Equal(t, t.Name()+"."+t.Name(), store.Config().Namespace)
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}))
+
+	client := store.mongoCollection().Database().Client()
+	require.NoError(t, store.Close(ctx))
+	require.ErrorIs(t, client.Ping(ctx, nil), mongo.ErrClientDisconnected, "Close should disconnect the owned client")
+}
+
+func TestCloseBorrowedClient(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	require.NoError(t, store.Close(ctx))
+	require.NoError(t, mongoClient.Ping(ctx, nil), "Close should leave the caller's client connected")
+}
+
+func TestNewStoreFromURIUnreachable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	_, err := NewStoreFromURI(ctx, "not-a-uri", "leases", "elections", "test-lease-key")
+	require.Error(t, err, "An invalid URI should be rejected")
+
+	_, err = NewStoreFromURI(ctx, "mongodb://localhost:1/?serverSelectionTimeoutMS=100", "leases", "elections", "test-lease-key")
+	require.Error(t, err, "An unreachable deployment should fail the ping")
+}
@@ -0,0 +1,116 @@
+package mongoleasestore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// clusterClockSyncTimeout bounds the round trip made when a ClusterClock
+// refreshes in the background.
+const clusterClockSyncTimeout = 2 * time.Second
+
+// Clock is a source of the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ClusterClock is a Clock that follows the MongoDB server's wall clock instead
+// of the local one. The server time is sampled at most once per refresh
+// interval and extrapolated with the local monotonic clock in between, so Now
+// does not cost a round trip per call.
+type ClusterClock struct {
+	fetch   func(ctx context.Context) (time.Time, error)
+	refresh time.Duration
+
+	mu          sync.Mutex
+	serverTime  time.Time // Server time at the last successful sync.
+	syncedAt    time.Time // Local time of the last successful sync.
+	attemptedAt time.Time // Local time of the last sync attempt.
+	refreshing  bool      // Whether a background refresh is running.
+}
+
+// NewClusterClock creates a ClusterClock that samples the server time of the
+// deployment behind client every refresh interval. Call Sync before first use
+// to start from the server time rather than the local one.
+func NewClusterClock(client *mongo.Client, refresh time.Duration) *ClusterClock {
+	return newClusterClock(func(ctx context.Context) (time.Time, error) {
+		var res struct {
+			LocalTime time.Time `bson:"localTime"`
+		}
+		err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&res)
+		return res.LocalTime, err
+	}, refresh)
+}
+
+func newClusterClock(fetch func(ctx context.Context) (time.Time, error), refresh time.Duration) *ClusterClock {
+	return &ClusterClock{
+		fetch:   fetch,
+		refresh: refresh,
+	}
+}
+
+// Sync samples the server time immediately.
+func (c *ClusterClock) Sync(ctx context.Context) error {
+	c.mu.Lock()
+	c.attemptedAt = time.Now()
+	c.mu.Unlock()
+
+	return c.sync(ctx)
+}
+
+// Now returns the current server time, extrapolated from the last sample. A
+// sample older than the refresh interval is refreshed in the background, so
+// Now never waits for the server. If the server has never been reached, the
+// local wall clock is returned.
+func (c *ClusterClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.refreshing && (c.attemptedAt.IsZero() || time.Since(c.attemptedAt) >= c.refresh) {
+		c.attemptedAt = time.Now()
+		c.refreshing = true
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), clusterClockSyncTimeout)
+			defer cancel()
+			_ = c.sync(ctx)
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.refreshing = false
+		}()
+	}
+
+	if c.syncedAt.IsZero() {
+		return time.Now()
+	}
+
+	return c.serverTime.Add(time.Since(c.syncedAt))
+}
+
+// sync samples the server time, without holding c.mu during the round trip.
+func (c *ClusterClock) sync(ctx context.Context) error {
+	start := time.Now()
+	serverTime, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Assume the server sampled its clock halfway through the round trip.
+	end := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.serverTime = serverTime.Add(end.Sub(start) / 2)
+	c.syncedAt = end
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package mongoleasestore
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterClock(t *testing.T) {
+	t.Parallel()
+
+	// The cluster runs one hour behind the local clock.
+	var fetches atomic.Int32
+	clock := newClusterClock(func(context.Context) (time.Time, error) {
+		fetches.Add(1)
+		return time.Now().Add(-time.Hour), nil
+	}, time.Minute)
+	require.NoError(t, clock.Sync(context.Background()))
+
+	t.Run("Expiry Uses Cluster Clock", func(t *testing.T) {
+		clusterStore, err := NewStore(Args{}, WithClock(clock))
+		require.NoError(t, err, "Failed to create store")
+		localStore, err := NewStore(Args{})
+		require.NoError(t, err, "Failed to create store")
+
+		lease := &le.Lease{
+			HolderIdentity: "candidate-1",
+			RenewTime:      time.Now().Add(-30 * time.Minute),
+			LeaseDuration:  time.Minute,
+		}
+
+		assert.True(t, localStore.IsExpired(lease), "Lease should be expired by the local clock")
+		assert.False(t, clusterStore.IsExpired(lease), "Lease should not be expired by the cluster clock")
+	})
+
+	t.Run("Cached Between Refreshes", func(t *testing.T) {
+		before := clock.Now()
+		after := clock.Now()
+
+		assert.EqualValues(t, 1, fetches.Load(), "Cluster time should be fetched once per refresh interval")
+		assert.False(t, after.Before(before), "Cluster clock should not go backwards")
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), after, time.Second)
+	})
+}
+
+func TestClusterClockRefreshesInBackground(t *testing.T) {
+	t.Parallel()
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	clock := newClusterClock(func(context.Context) (time.Time, error) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		return time.Now().Add(-time.Hour), nil
+	}, time.Millisecond)
+	require.NoError(t, clock.Sync(context.Background()))
+	time.Sleep(2 * time.Millisecond)
+
+	// The stale sample is extrapolated while a single refresh is stuck.
+	for range 10 {
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), clock.Now(), time.Second)
+	}
+	assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, time.Millisecond)
+	assert.EqualValues(t, 2, fetches.Load(), "Refreshes should not run concurrently")
+
+	close(release)
+	assert.Eventually(t, func() bool { clock.Now(); return fetches.Load() > 2 }, time.Second, time.Millisecond,
+		"Refreshes should resume once the previous one completed")
+}
@@ -0,0 +1,259 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithReleaseOnClose makes Close release the lease if holder still holds it,
+// so that the next election does not wait for it to expire. The lease keeps
+// its other fields, as a release by the elector does.
+func WithReleaseOnClose(holder string) Option {
+	return func(s *Store) {
+		s.releaseOnClose = holder
+	}
+}
+
+// Close shuts the store down: calls to the lease collection fail with
+// ErrStoreClosed from then on, and those in flight are waited for, while the
+// watchers, heartbeats and renew loops started on the store stop. It then
+// releases the lease if WithReleaseOnClose is set, and disconnects the client
+// of a store created with NewStoreFromURI; the client of a store created with
+// NewStore belongs to the caller and is left connected. If ctx is done before
+// the calls in flight finish, the lease is not released but the client is
+// still disconnected, interrupting them. Closing a closed store is a no-op.
+func (s *Store) Close(ctx context.Context) error {
+	if !s.closeGate.close() {
+		return nil
+	}
+
+	var errs []error
+	if err := s.closeGate.wait(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("close store: %w", err))
+	} else if s.releaseOnClose != "" {
+		if err := s.releaseLease(bypassCloseGate(ctx), s.releaseOnClose); err != nil {
+			errs = append(errs, fmt.Errorf("close store: release lease %q: %w", s.leaseKey, err))
+		}
+	}
+	if s.ownedClient != nil {
+		if err := s.ownedClient.Disconnect(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close store: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// closeGate tracks the calls in flight to the lease collections, and rejects
+// new ones once closed.
+type closeGate struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	idle     chan struct{} // Closed when the last call in flight finishes after close.
+	done     chan struct{} // Closed on close, created on demand.
+}
+
+// enter registers a call, unless the gate is closed and ctx does not bypass it.
+func (g *closeGate) enter(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed && ctx.Value(closeBypassKey{}) == nil {
+		return ErrStoreClosed
+	}
+	g.inflight++
+	return nil
+}
+
+func (g *closeGate) exit() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inflight--
+	if g.inflight == 0 && g.idle != nil {
+		close(g.idle)
+		g.idle = nil
+	}
+}
+
+// close closes the gate and reports whether it was open.
+func (g *closeGate) close() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.closed = true
+	if g.inflight > 0 {
+		g.idle = make(chan struct{})
+	}
+	if g.done != nil {
+		close(g.done)
+	}
+	return true
+}
+
+// isClosed reports whether the gate was closed.
+func (g *closeGate) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
+
+// closing returns a channel closed once the gate is.
+func (g *closeGate) closing() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done == nil {
+		g.done = make(chan struct{})
+		if g.closed {
+			close(g.done)
+		}
+	}
+	return g.done
+}
+
+// wait waits for the calls in flight when the gate was closed to finish.
+func (g *closeGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	idle := g.idle
+	g.mu.Unlock()
+	if idle == nil {
+		return nil
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for operations in flight: %w", ctx.Err())
+	}
+}
+
+// untilClosed returns a context canceled once ctx is done or the store is
+// closed, for the goroutines the store runs on behalf of its caller.
+func (s *Store) untilClosed(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	closing := s.closeGate.closing()
+	go func() {
+		select {
+		case <-closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// closeBypassKey marks the context of calls Close itself makes.
+type closeBypassKey struct{}
+
+func bypassCloseGate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, closeBypassKey{}, true)
+}
+
+// applyCloseGate makes the calls to the lease collections go through the
+// store's close gate. It must run last, so that the calls in flight include
+// those waiting for a slot or retrying.
+func (s *Store) applyCloseGate() {
+	s.collection = &gatedCollection{leaseCollection: s.collection, gate: &s.closeGate}
+	if s.staleReads != nil {
+		s.staleReads = &gatedCollection{leaseCollection: s.staleReads, gate: &s.closeGate}
+	}
+	if s.confirmReads != nil {
+		s.confirmReads = &gatedCollection{leaseCollection: s.confirmReads, gate: &s.closeGate}
+	}
+}
+
+// gatedCollection runs a call to the wrapped collection only while its gate is
+// open. Results are read before the call counts as finished.
+type gatedCollection struct {
+	leaseCollection
+	gate *closeGate
+}
+
+func (c *gatedCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	if err := c.gate.enter(ctx); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	defer c.gate.exit()
+	return settled(c.leaseCollection.FindOne(ctx, filter, opts...))
+}
+
+func (c *gatedCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	if err := c.gate.enter(ctx); err != nil {
+		return nil, err
+	}
+	defer c.gate.exit()
+
+	cursor, err := c.leaseCollection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var docs []interface{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return mongo.NewCursorFromDocuments(docs, nil, nil)
+}
+
+func (c *gatedCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	if err := c.gate.enter(ctx); err != nil {
+		return 0, err
+	}
+	defer c.gate.exit()
+	return c.leaseCollection.CountDocuments(ctx, filter, opts...)
+}
+
+func (c *gatedCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	if err := c.gate.enter(ctx); err != nil {
+		return nil, err
+	}
+	defer c.gate.exit()
+	return c.leaseCollection.InsertOne(ctx, document, opts...)
+}
+
+func (c *gatedCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	if err := c.gate.enter(ctx); err != nil {
+		return nil, err
+	}
+	defer c.gate.exit()
+	return c.leaseCollection.UpdateOne(ctx, filter, update, opts...)
+}
+
+func (c *gatedCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	if err := c.gate.enter(ctx); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	defer c.gate.exit()
+	return settled(c.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts...))
+}
+
+func (c *gatedCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	if err := c.gate.enter(ctx); err != nil {
+		return nil, err
+	}
+	defer c.gate.exit()
+	return c.leaseCollection.ReplaceOne(ctx, filter, replacement, opts...)
+}
+
+func (c *gatedCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	if err := c.gate.enter(ctx); err != nil {
+		return nil, err
+	}
+	defer c.gate.exit()
+	return c.leaseCollection.DeleteOne(ctx, filter, opts...)
+}
+
+func (c *gatedCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	if err := c.gate.enter(ctx); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	defer c.gate.exit()
+	return settled(c.leaseCollection.FindOneAndDelete(ctx, filter, opts...))
+}
@@ -0,0 +1,135 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// blockingCollection blocks every FindOne until release is closed, signaling
+// entered first.
+type blockingCollection struct {
+	*fakeCollection
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	c.entered <- struct{}{}
+	<-c.release
+	return c.fakeCollection.FindOne(ctx, filter, opts...)
+}
+
+func TestCloseWaitsForOperations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	collection := &blockingCollection{
+		fakeCollection: newFakeCollection(),
+		entered:        make(chan struct{}),
+		release:        make(chan struct{}),
+	}
+	store := newTestStore(t, collection)
+
+	inflight := make(chan error)
+	go func() {
+		_, err := store.GetLease(ctx)
+		inflight <- err
+	}()
+	<-collection.entered
+
+	closed := make(chan error)
+	go func() {
+		closed <- store.Close(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		_, err := store.GetLease(ctx)
+		return assert.ErrorIs(t, err, ErrStoreClosed)
+	}, time.Second, 10*time.Millisecond, "New operations should be rejected once closing")
+	select {
+	case <-closed:
+		t.Fatal("Close should wait for the operation in flight")
+	default:
+	}
+
+	close(collection.release)
+	require.ErrorIs(t, <-inflight, le.ErrLeaseNotFound, "The operation in flight should complete")
+	require.NoError(t, <-closed)
+	require.NoError(t, store.Close(ctx), "Closing twice should be a no-op")
+}
+
+func TestCloseDeadline(t *testing.T) {
+	t.Parallel()
+
+	collection := &blockingCollection{
+		fakeCollection: newFakeCollection(),
+		entered:        make(chan struct{}),
+		release:        make(chan struct{}),
+	}
+	store := newTestStore(t, collection, WithReleaseOnClose("candidate-1"))
+	defer close(collection.release)
+
+	go func() {
+		_, _ = store.GetLease(context.Background())
+	}()
+	<-collection.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, store.Close(ctx), context.DeadlineExceeded, "Close should give up at the deadline")
+}
+
+func TestCloseCancelsBackgroundWork(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, newFakeCollection())
+	ctx, cancel := store.untilClosed(context.Background())
+	defer cancel()
+
+	require.NoError(t, store.Close(context.Background()))
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		require.FailNow(t, "Close should cancel the background work")
+	}
+
+	late, cancel := store.untilClosed(context.Background())
+	defer cancel()
+	select {
+	case <-late.Done():
+	case <-time.After(time.Second):
+		require.FailNow(t, "Work started after close should be canceled")
+	}
+}
+
+func TestReleaseOnClose(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithReleaseOnClose("candidate-1"))
+	assert.Equal(t, "candidate-1", store.Config().ReleaseOnClose)
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity:    "candidate-1",
+		AcquireTime:       now,
+		RenewTime:         now,
+		LeaseDuration:     time.Minute,
+		LeaderTransitions: 3,
+	}))
+
+	require.NoError(t, store.Close(ctx))
+	require.NotNil(t, collection.doc)
+	assert.Empty(t, collection.doc.HolderIdentity, "Close should release the lease")
+	assert.Equal(t, uint64(3), collection.doc.LeaderTransitions, "The release should keep the other fields")
+
+	_, err := store.GetLease(ctx)
+	require.ErrorIs(t, err, ErrStoreClosed)
+}
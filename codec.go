@@ -0,0 +1,143 @@
+package mongoleasestore
+
+import (
+	"errors"
+	"fmt"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DocumentEncoder builds the document stored for lease under key. The document
+// must have key as its _id.
+type DocumentEncoder func(key string, lease *le.Lease) (interface{}, error)
+
+// DocumentDecoder reads a lease from a document built by a DocumentEncoder.
+type DocumentDecoder func(raw bson.Raw) (*le.Lease, error)
+
+// WithDocumentEncoder replaces the default document layout on writes by
+// GetLease, CreateLease, UpdateLease and DeleteLease. It must be used together
+// with WithDocumentDecoder.
+//
+// Operations that query lease fields on the server, such as AcquireLease, Touch
+// or ListLeases, need the default layout and return ErrCustomDocument, and
+// options that depend on it cannot be combined with a custom layout.
+func WithDocumentEncoder(encode DocumentEncoder) Option {
+	return func(s *Store) {
+		s.encoder = encode
+	}
+}
+
+// WithDocumentDecoder replaces the default document layout on reads, see
+// WithDocumentEncoder.
+func WithDocumentDecoder(decode DocumentDecoder) Option {
+	return func(s *Store) {
+		s.decoder = decode
+	}
+}
+
+// checkCodec rejects a custom document layout that is incomplete or combined
+// with options that need the default one.
+func (s *Store) checkCodec() error {
+	if (s.encoder == nil) != (s.decoder == nil) {
+		return errors.New("document encoder and decoder must be set together")
+	}
+	if s.encoder == nil {
+		return nil
+	}
+
+	switch {
+	case s.softDelete:
+		return fmt.Errorf("%w: soft delete", ErrCustomDocument)
+	case s.minimalDocument:
+		return fmt.Errorf("%w: minimal document", ErrCustomDocument)
+	case s.enforceConsistentDuration:
+		return fmt.Errorf("%w: consistent duration enforcement", ErrCustomDocument)
+	case s.durationReconciliation == DurationError:
+		return fmt.Errorf("%w: duration reconciliation by error", ErrCustomDocument)
+	case s.strictRegion:
+		return fmt.Errorf("%w: strict region", ErrCustomDocument)
+	case s.minRenewInterval > 0:
+		return fmt.Errorf("%w: minimum renew interval", ErrCustomDocument)
+	case s.serverClockCheck != nil:
+		return fmt.Errorf("%w: server clock monotonicity check", ErrCustomDocument)
+	case s.expiresAt:
+		return fmt.Errorf("%w: expires_at field", ErrCustomDocument)
+	}
+
+	return nil
+}
+
+// requireDefaultDocument fails op if the store uses a custom document layout.
+func (s *Store) requireDefaultDocument(op string) error {
+	if s.encoder == nil {
+		return nil
+	}
+	return fmt.Errorf("%s lease %q: %w", op, s.leaseKey, ErrCustomDocument)
+}
+
+// decodeDocument decodes a lease document from result with the configured
+// decoder, if any.
+func (s *Store) decodeDocument(result *mongo.SingleResult) (*leaseDocument, error) {
+	if s.decoder == nil {
+		var doc leaseDocument
+		if err := result.Decode(&doc); err != nil {
+			return nil, err
+		}
+		return &doc, nil
+	}
+
+	raw, err := result.Raw()
+	if err != nil {
+		return nil, err
+	}
+	lease, err := s.decoder(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode lease %q: %w", s.leaseKey, err)
+	}
+
+	doc := fromLease(s.leaseKey, lease)
+	return &doc, nil
+}
+
+// BuildLeaseDocument returns the document the store writes for lease under key
+// in the default layout, for tooling that seeds or inspects leases outside the
+// package. A bson.M is not marshaled in a stable field order; see
+// BuildLeaseDocumentOrdered for the canonical one.
+func BuildLeaseDocument(key string, lease *le.Lease) bson.M {
+	return bson.M{
+		"_id":                key,
+		"holder_identity":    lease.HolderIdentity,
+		"acquire_time":       lease.AcquireTime,
+		"renew_time":         lease.RenewTime,
+		"lease_duration":     lease.LeaseDuration,
+		"leader_transitions": lease.LeaderTransitions,
+	}
+}
+
+// BuildLeaseDocumentOrdered is like BuildLeaseDocument, with the fields in the
+// canonical order the store writes them in: _id, holder_identity,
+// acquire_time, renew_time, lease_duration and leader_transitions. Marshaling
+// it yields the same bytes for the same lease, which suits snapshots and
+// content hashes.
+func BuildLeaseDocumentOrdered(key string, lease *le.Lease) bson.D {
+	return bson.D{
+		{Key: "_id", Value: key},
+		{Key: "holder_identity", Value: lease.HolderIdentity},
+		{Key: "acquire_time", Value: lease.AcquireTime},
+		{Key: "renew_time", Value: lease.RenewTime},
+		{Key: "lease_duration", Value: lease.LeaseDuration},
+		{Key: "leader_transitions", Value: lease.LeaderTransitions},
+	}
+}
+
+// ParseLeaseDocument reads a lease from a document in the default layout, as
+// the store does.
+func ParseLeaseDocument(raw bson.Raw) (*le.Lease, error) {
+	var doc leaseDocument
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse lease document: %w", err)
+	}
+	return doc.toLease(), nil
+}
@@ -0,0 +1,170 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// nestedLease stores the lease under a "lease" subdocument.
+type nestedLease struct {
+	ID    string   `bson:"_id"`
+	Lease le.Lease `bson:"lease"`
+}
+
+func encodeNested(key string, lease *le.Lease) (interface{}, error) {
+	return nestedLease{ID: key, Lease: *lease}, nil
+}
+
+func decodeNested(raw bson.Raw) (*le.Lease, error) {
+	var doc nestedLease
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return &doc.Lease, nil
+}
+
+func TestDocumentCodec(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithDocumentEncoder(encodeNested), WithDocumentDecoder(decodeNested))
+	require.NoError(t, err, "Failed to create store")
+
+	now := time.Now().Truncate(time.Millisecond)
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	require.NoError(t, store.CreateLease(ctx, lease))
+
+	var raw bson.M
+	require.NoError(t, collection.FindOne(ctx, bson.M{"_id": "test-lease-key"}).Decode(&raw))
+	assert.Contains(t, raw, "lease", "Lease should be nested")
+	assert.NotContains(t, raw, "holder_identity", "Default layout should not be used")
+
+	got, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "candidate-1", got.HolderIdentity)
+	assert.True(t, got.RenewTime.Equal(now))
+	assert.Equal(t, time.Second, got.LeaseDuration)
+
+	lease.HolderIdentity = "candidate-2"
+	lease.LeaderTransitions = 1
+	require.NoError(t, store.UpdateLease(ctx, lease))
+
+	got, err = store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "candidate-2", got.HolderIdentity)
+	assert.EqualValues(t, 1, got.LeaderTransitions)
+}
+
+func TestDocumentCodecOptions(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewStore(Args{LeaseKey: "test-lease-key"}, WithDocumentEncoder(encodeNested))
+	require.Error(t, err, "Encoder without decoder should be rejected")
+
+	_, err = NewStore(Args{LeaseKey: "test-lease-key"},
+		WithDocumentEncoder(encodeNested), WithDocumentDecoder(decodeNested), WithSoftDelete())
+	require.ErrorIs(t, err, ErrCustomDocument, "Soft delete needs the default layout")
+
+	collection := newFakeCollection()
+	store := newTestStore(t, collection,
+		WithDocumentEncoder(encodeNested), WithDocumentDecoder(decodeNested))
+
+	_, err = store.AcquireLease(context.Background(), "candidate-1", time.Minute)
+	require.ErrorIs(t, err, ErrCustomDocument)
+	assert.Zero(t, collection.callCount("FindOneAndUpdate"), "MongoDB should not be contacted")
+}
+
+func TestLeaseDocument(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Millisecond)
+	lease := &le.Lease{
+		HolderIdentity:    "candidate-1",
+		AcquireTime:       now.Add(-time.Minute),
+		RenewTime:         now,
+		LeaseDuration:     time.Second,
+		LeaderTransitions: 3,
+	}
+
+	built, err := bson.Marshal(BuildLeaseDocument("test-lease-key", lease))
+	require.NoError(t, err)
+	internal, err := bson.Marshal(fromLease("test-lease-key", lease))
+	require.NoError(t, err)
+
+	var fromBuilt, fromInternal leaseDocument
+	require.NoError(t, bson.Unmarshal(built, &fromBuilt))
+	require.NoError(t, bson.Unmarshal(internal, &fromInternal))
+	assert.Equal(t, fromInternal, fromBuilt, "Built document should match the store's")
+
+	parsed, err := ParseLeaseDocument(internal)
+	require.NoError(t, err)
+	assert.Equal(t, fromInternal.toLease(), parsed, "Parsed lease should match the store's")
+	assert.True(t, parsed.RenewTime.Equal(lease.RenewTime))
+	assert.Equal(t, lease.LeaderTransitions, parsed.LeaderTransitions)
+
+	_, err = ParseLeaseDocument(bson.Raw{0x01})
+	require.Error(t, err, "Malformed documents should be rejected")
+}
+
+func TestLeaseDocumentOrder(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Millisecond)
+	lease := &le.Lease{
+		HolderIdentity:    "candidate-1",
+		AcquireTime:       now.Add(-time.Minute),
+		RenewTime:         now,
+		LeaseDuration:     time.Second,
+		LeaderTransitions: 3,
+	}
+	wantKeys := []string{"_id", "holder_identity", "acquire_time", "renew_time", "lease_duration", "leader_transitions"}
+
+	stored, err := bson.Marshal(fromLease("test-lease-key", lease))
+	require.NoError(t, err)
+	for range 10 {
+		again, err := bson.Marshal(fromLease("test-lease-key", lease))
+		require.NoError(t, err)
+		require.Equal(t, stored, again, "Stored documents should be byte-identical")
+
+		ordered, err := bson.Marshal(BuildLeaseDocumentOrdered("test-lease-key", lease))
+		require.NoError(t, err)
+		require.Equal(t, stored, ordered, "Ordered document should match the stored bytes")
+	}
+	assert.Equal(t, wantKeys, documentKeys(t, stored))
+
+	store, err := NewStore(Args{LeaseKey: "test-lease-key"}, WithMinimalDocument(), WithRegion("eu-west-1"))
+	require.NoError(t, err, "Failed to create store")
+	minimal, _ := store.minimalLease(lease)
+	raw, err := bson.Marshal(minimal)
+	require.NoError(t, err)
+	assert.Equal(t, append(wantKeys, "region"), documentKeys(t, raw), "Minimal documents should keep the order")
+}
+
+func documentKeys(t *testing.T, raw bson.Raw) []string {
+	t.Helper()
+
+	elements, err := raw.Elements()
+	require.NoError(t, err)
+	keys := make([]string, 0, len(elements))
+	for _, element := range elements {
+		keys = append(keys, element.Key())
+	}
+	return keys
+}
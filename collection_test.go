@@ -0,0 +1,733 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newTestStore creates a store through NewStore, with its wrappers, on top of
+// collection instead of a MongoDB collection. Wrappers that only apply to
+// MongoDB collections, such as the error context, are not installed.
+func newTestStore(t *testing.T, collection leaseCollection, opts ...Option) *Store {
+	t.Helper()
+	store, err := NewStore(Args{LeaseKey: "test-lease-key"}, append([]Option{withBaseCollection(collection)}, opts...)...)
+	require.NoError(t, err, "Failed to create store")
+	return store
+}
+
+// withBaseCollection sets the collection NewStore wraps.
+func withBaseCollection(collection leaseCollection) Option {
+	return func(s *Store) {
+		s.collection = collection
+	}
+}
+
+// fakeCollection is an in-memory leaseCollection holding at most one lease
+// document. It counts calls per operation and evaluates filters and updates,
+// pipelines included, like MongoDB would for the operators the store uses;
+// other operators panic. $$NOW is the local time. The document is held as a
+// leaseDocument, so fields omitted by minimal documents read as zero values.
+type fakeCollection struct {
+	leaseCollection // Unimplemented operations panic.
+
+	mu    sync.Mutex
+	doc   *leaseDocument
+	calls map[string]int
+	opts  map[string]interface{} // Last options passed per operation.
+}
+
+func newFakeCollection() *fakeCollection {
+	return &fakeCollection{
+		calls: make(map[string]int),
+		opts:  make(map[string]interface{}),
+	}
+}
+
+func (c *fakeCollection) lastOptions(op string) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts[op]
+}
+
+func (c *fakeCollection) callCount(op string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[op]
+}
+
+// record counts a call to op and keeps its options. It must be called with
+// c.mu held.
+func (c *fakeCollection) record(op string, opts interface{}) {
+	c.calls[op]++
+	if v := reflect.ValueOf(opts); v.Len() > 0 {
+		c.opts[op] = v.Index(0).Interface()
+	}
+}
+
+// matching returns the document as a map if it matches filter, nil otherwise.
+// It must be called with c.mu held.
+func (c *fakeCollection) matching(filter interface{}) bson.M {
+	if c.doc == nil {
+		return nil
+	}
+	doc := toM(c.doc)
+	if !matches(doc, filter) {
+		return nil
+	}
+	return doc
+}
+
+// upserted returns the document an upsert with filter starts from, or a
+// duplicate key error if the lease exists but did not match. It must be
+// called with c.mu held.
+func (c *fakeCollection) upserted(filter interface{}) (bson.M, error) {
+	if c.doc != nil {
+		return nil, errDuplicateKey
+	}
+	return bson.M{"_id": asM(filter)["_id"]}, nil
+}
+
+var errDuplicateKey = mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+
+func (c *fakeCollection) FindOne(_ context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("FindOne", opts)
+
+	doc := c.matching(filter)
+	if doc == nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+	}
+	return mongo.NewSingleResultFromDocument(c.doc, nil, nil)
+}
+
+func (c *fakeCollection) CountDocuments(_ context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("CountDocuments", opts)
+
+	if c.matching(filter) == nil {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (c *fakeCollection) InsertOne(_ context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("InsertOne", opts)
+
+	if c.doc != nil {
+		return nil, errDuplicateKey
+	}
+	doc := fromM(asM(document))
+	c.doc = &doc
+	return &mongo.InsertOneResult{InsertedID: doc.ID}, nil
+}
+
+func (c *fakeCollection) UpdateOne(_ context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("UpdateOne", opts)
+
+	doc := c.matching(filter)
+	if doc == nil {
+		if len(opts) == 0 || opts[0].Upsert == nil || !*opts[0].Upsert {
+			return &mongo.UpdateResult{}, nil
+		}
+		start, err := c.upserted(filter)
+		if err != nil {
+			return nil, err
+		}
+		after := fromM(applyUpdate(start, update))
+		c.doc = &after
+		return &mongo.UpdateResult{UpsertedCount: 1, UpsertedID: after.ID}, nil
+	}
+
+	after := fromM(applyUpdate(doc, update))
+	if reflect.DeepEqual(*c.doc, after) {
+		return &mongo.UpdateResult{MatchedCount: 1}, nil
+	}
+	c.doc = &after
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (c *fakeCollection) FindOneAndUpdate(_ context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("FindOneAndUpdate", opts)
+
+	returnAfter := len(opts) > 0 && opts[0].ReturnDocument != nil && *opts[0].ReturnDocument == options.After
+	doc := c.matching(filter)
+	if doc == nil {
+		if len(opts) == 0 || opts[0].Upsert == nil || !*opts[0].Upsert {
+			return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+		}
+		start, err := c.upserted(filter)
+		if err != nil {
+			return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+		}
+		after := fromM(applyUpdate(start, update))
+		c.doc = &after
+		if returnAfter {
+			return mongo.NewSingleResultFromDocument(after, nil, nil)
+		}
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+	}
+
+	before := *c.doc
+	after := fromM(applyUpdate(doc, update))
+	c.doc = &after
+	if returnAfter {
+		return mongo.NewSingleResultFromDocument(after, nil, nil)
+	}
+	return mongo.NewSingleResultFromDocument(before, nil, nil)
+}
+
+func (c *fakeCollection) ReplaceOne(_ context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("ReplaceOne", opts)
+
+	if c.matching(filter) == nil {
+		if len(opts) == 0 || opts[0].Upsert == nil || !*opts[0].Upsert {
+			return &mongo.UpdateResult{}, nil
+		}
+		if _, err := c.upserted(filter); err != nil {
+			return nil, err
+		}
+		doc := fromM(asM(replacement))
+		c.doc = &doc
+		return &mongo.UpdateResult{UpsertedCount: 1, UpsertedID: doc.ID}, nil
+	}
+
+	doc := fromM(asM(replacement))
+	c.doc = &doc
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (c *fakeCollection) DeleteOne(_ context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("DeleteOne", opts)
+
+	if c.matching(filter) == nil {
+		return &mongo.DeleteResult{}, nil
+	}
+	c.doc = nil
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+func (c *fakeCollection) FindOneAndDelete(_ context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("FindOneAndDelete", opts)
+
+	if c.matching(filter) == nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+	}
+	before := *c.doc
+	c.doc = nil
+	return mongo.NewSingleResultFromDocument(before, nil, nil)
+}
+
+// asM converts a document, of any type the driver accepts, to a map.
+func asM(document interface{}) bson.M {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		panic(fmt.Sprintf("fake collection: marshal %T: %v", document, err))
+	}
+	var m bson.M
+	if err := bson.Unmarshal(raw, &m); err != nil {
+		panic(fmt.Sprintf("fake collection: unmarshal: %v", err))
+	}
+	return m
+}
+
+func toM(doc *leaseDocument) bson.M {
+	return asM(doc)
+}
+
+func fromM(m bson.M) leaseDocument {
+	raw, err := bson.Marshal(m)
+	if err != nil {
+		panic(fmt.Sprintf("fake collection: marshal: %v", err))
+	}
+	var doc leaseDocument
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		panic(fmt.Sprintf("fake collection: unmarshal lease document: %v", err))
+	}
+	return doc
+}
+
+// fields returns the keys and values of a bson.M or bson.D, in order for the
+// latter.
+func fields(v interface{}) (bson.D, bool) {
+	switch v := v.(type) {
+	case bson.D:
+		return v, true
+	case bson.M:
+		d := make(bson.D, 0, len(v))
+		for k, value := range v {
+			d = append(d, bson.E{Key: k, Value: value})
+		}
+		return d, true
+	}
+	return nil, false
+}
+
+// matches reports whether doc matches the query filter.
+func matches(doc bson.M, filter interface{}) bool {
+	clauses, ok := fields(filter)
+	if !ok {
+		panic(fmt.Sprintf("fake collection: filter of type %T", filter))
+	}
+	for _, clause := range clauses {
+		switch clause.Key {
+		case "$and":
+			for _, sub := range clause.Value.(bson.A) {
+				if !matches(doc, sub) {
+					return false
+				}
+			}
+		case "$or":
+			any := false
+			for _, sub := range clause.Value.(bson.A) {
+				any = any || matches(doc, sub)
+			}
+			if !any {
+				return false
+			}
+		case "$expr":
+			if !truthy(eval(doc, clause.Value)) {
+				return false
+			}
+		default:
+			value, present := doc[clause.Key]
+			if !matchesField(value, present, clause.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchesField reports whether a field, with value if present, matches cond.
+func matchesField(value interface{}, present bool, cond interface{}) bool {
+	ops, ok := fields(cond)
+	if !ok || len(ops) == 0 || !strings.HasPrefix(ops[0].Key, "$") {
+		return equal(value, cond)
+	}
+	for _, op := range ops {
+		var ok bool
+		switch op.Key {
+		case "$eq":
+			ok = equal(value, op.Value)
+		case "$ne":
+			ok = !equal(value, op.Value)
+		case "$in":
+			for _, candidate := range op.Value.(bson.A) {
+				ok = ok || equal(value, candidate)
+			}
+		case "$exists":
+			ok = present == op.Value.(bool)
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = present && value != nil && compareOp(op.Key, value, op.Value)
+		case "$mod":
+			args := op.Value.(bson.A)
+			n, isNumber := integer(value)
+			ok = present && isNumber && n%mustInteger(args[0]) == mustInteger(args[1])
+		default:
+			panic("fake collection: unsupported query operator " + op.Key)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// removed is the value of $$REMOVE.
+type removed struct{}
+
+// eval evaluates an aggregation expression against doc.
+func eval(doc bson.M, expr interface{}) interface{} {
+	switch e := expr.(type) {
+	case string:
+		switch {
+		case e == "$$NOW":
+			return time.Now()
+		case e == "$$ROOT":
+			return copyM(doc)
+		case e == "$$REMOVE":
+			return removed{}
+		case strings.HasPrefix(e, "$$"):
+			panic("fake collection: unsupported variable " + e)
+		case strings.HasPrefix(e, "$"):
+			return doc[e[1:]]
+		}
+		return e
+	case bson.A:
+		values := make(bson.A, len(e))
+		for i, v := range e {
+			values[i] = eval(doc, v)
+		}
+		return values
+	}
+
+	ops, ok := fields(expr)
+	if !ok {
+		return expr
+	}
+	if len(ops) != 1 || !strings.HasPrefix(ops[0].Key, "$") {
+		// An object whose fields are expressions.
+		object := bson.M{}
+		for _, field := range ops {
+			if v := eval(doc, field.Value); v != (removed{}) {
+				object[field.Key] = v
+			}
+		}
+		return object
+	}
+
+	op, arg := ops[0].Key, ops[0].Value
+	if op == "$literal" {
+		return arg
+	}
+	if op == "$type" {
+		if path, ok := arg.(string); ok && strings.HasPrefix(path, "$") && !strings.HasPrefix(path, "$$") {
+			if _, present := doc[path[1:]]; !present {
+				return "missing"
+			}
+		}
+		return fmt.Sprintf("%T", eval(doc, arg))
+	}
+
+	args, _ := eval(doc, arg).(bson.A)
+	switch op {
+	case "$add":
+		return add(args...)
+	case "$subtract":
+		return subtract(args[0], args[1])
+	case "$divide":
+		return number(args[0]) / number(args[1])
+	case "$multiply":
+		return mustInteger(args[0]) * mustInteger(args[1])
+	case "$max":
+		if compare(args[0], args[1]) < 0 {
+			return args[1]
+		}
+		return args[0]
+	case "$mod":
+		return mustInteger(args[0]) % mustInteger(args[1])
+	case "$ifNull":
+		if args[0] != nil {
+			return args[0]
+		}
+		return args[1]
+	case "$cond":
+		if truthy(args[0]) {
+			return args[1]
+		}
+		return args[2]
+	case "$eq":
+		return equal(args[0], args[1])
+	case "$ne":
+		return !equal(args[0], args[1])
+	case "$gt", "$gte", "$lt", "$lte":
+		return compareOp(op, args[0], args[1])
+	case "$and":
+		for _, a := range args {
+			if !truthy(a) {
+				return false
+			}
+		}
+		return true
+	case "$or":
+		for _, a := range args {
+			if truthy(a) {
+				return true
+			}
+		}
+		return false
+	case "$not":
+		return !truthy(args[0])
+	case "$mergeObjects":
+		merged := bson.M{}
+		for _, a := range args {
+			if object, ok := a.(bson.M); ok {
+				for k, v := range object {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	panic("fake collection: unsupported expression operator " + op)
+}
+
+// applyUpdate applies an update document or pipeline to doc.
+func applyUpdate(doc bson.M, update interface{}) bson.M {
+	if pipeline, ok := update.(mongo.Pipeline); ok {
+		for _, stage := range pipeline {
+			doc = applyStage(doc, stage[0].Key, stage[0].Value)
+		}
+		return doc
+	}
+
+	ops, _ := fields(update)
+	doc = copyM(doc)
+	for _, op := range ops {
+		switch op.Key {
+		case "$set":
+			for k, v := range asM(op.Value) {
+				doc[k] = v
+			}
+		case "$unset":
+			for k := range asM(op.Value) {
+				delete(doc, k)
+			}
+		case "$inc":
+			for k, v := range asM(op.Value) {
+				doc[k] = add(doc[k], v)
+			}
+		default:
+			panic("fake collection: unsupported update operator " + op.Key)
+		}
+	}
+	return doc
+}
+
+// applyStage applies an update pipeline stage to doc.
+func applyStage(doc bson.M, stage string, spec interface{}) bson.M {
+	switch stage {
+	case "$set", "$addFields":
+		values := eval(doc, spec).(bson.M)
+		doc = copyM(doc)
+		for k, v := range values {
+			doc[k] = v
+		}
+		for k, v := range doc {
+			if v == (removed{}) {
+				delete(doc, k)
+			}
+		}
+		return doc
+	case "$unset":
+		doc = copyM(doc)
+		switch spec := spec.(type) {
+		case string:
+			delete(doc, spec)
+		case []string:
+			for _, field := range spec {
+				delete(doc, field)
+			}
+		default:
+			for _, field := range spec.(bson.A) {
+				delete(doc, field.(string))
+			}
+		}
+		return doc
+	case "$replaceWith":
+		return eval(doc, spec).(bson.M)
+	}
+	panic("fake collection: unsupported update stage " + stage)
+}
+
+func copyM(doc bson.M) bson.M {
+	c := make(bson.M, len(doc))
+	for k, v := range doc {
+		c[k] = v
+	}
+	return c
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	}
+	if n, ok := numeric(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+// date returns v as a time, if it is a date.
+func date(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Truncate(time.Millisecond), true
+	case primitive.DateTime:
+		return v.Time(), true
+	}
+	return time.Time{}, false
+}
+
+// integer returns v as an integer, if it is one.
+func integer(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case time.Duration:
+		return int64(v), true
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+func mustInteger(v interface{}) int64 {
+	n, ok := integer(v)
+	if !ok {
+		panic(fmt.Sprintf("fake collection: %v is not an integer", v))
+	}
+	return n
+}
+
+// numeric returns v as a float, if it is a number.
+func numeric(v interface{}) (float64, bool) {
+	if f, ok := v.(float64); ok {
+		return f, true
+	}
+	if n, ok := integer(v); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func number(v interface{}) float64 {
+	f, ok := numeric(v)
+	if !ok {
+		panic(fmt.Sprintf("fake collection: %v is not a number", v))
+	}
+	return f
+}
+
+// add adds numbers, integers staying integers, or milliseconds to a date.
+func add(values ...interface{}) interface{} {
+	var at *time.Time
+	var sum int64
+	var fsum float64
+	floats := false
+	for _, v := range values {
+		if t, ok := date(v); ok {
+			at = &t
+			continue
+		}
+		if v == nil {
+			return nil
+		}
+		if n, ok := integer(v); ok && !floats {
+			sum += n
+			continue
+		}
+		if !floats {
+			floats, fsum = true, float64(sum)
+		}
+		fsum += number(v)
+	}
+	switch {
+	case at != nil && floats:
+		return at.Add(time.Duration(fsum * float64(time.Millisecond)))
+	case at != nil:
+		return at.Add(time.Duration(sum) * time.Millisecond)
+	case floats:
+		return fsum
+	}
+	return sum
+}
+
+// subtract subtracts numbers, milliseconds from a date, or dates, giving
+// milliseconds.
+func subtract(a, b interface{}) interface{} {
+	ta, aIsDate := date(a)
+	tb, bIsDate := date(b)
+	switch {
+	case aIsDate && bIsDate:
+		return ta.Sub(tb).Milliseconds()
+	case aIsDate:
+		return ta.Add(-time.Duration(number(b) * float64(time.Millisecond)))
+	}
+	na, aInt := integer(a)
+	nb, bInt := integer(b)
+	if aInt && bInt {
+		return na - nb
+	}
+	return number(a) - number(b)
+}
+
+// compare orders a and b, both dates, numbers or strings.
+func compare(a, b interface{}) int {
+	if ta, ok := date(a); ok {
+		tb, ok := date(b)
+		if !ok {
+			panic(fmt.Sprintf("fake collection: cannot compare %v to %v", a, b))
+		}
+		return ta.Compare(tb)
+	}
+	if sa, ok := a.(string); ok {
+		return strings.Compare(sa, b.(string))
+	}
+	na, nb := number(a), number(b)
+	switch {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+	return 0
+}
+
+func compareOp(op string, a, b interface{}) bool {
+	c := compare(a, b)
+	switch op {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	}
+	return c <= 0
+}
+
+// equal reports whether two values are equal as MongoDB compares them:
+// numbers by value whatever their type, dates at millisecond precision.
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if _, ok := date(a); ok {
+		_, ok := date(b)
+		return ok && compare(a, b) == 0
+	}
+	if na, ok := numeric(a); ok {
+		nb, ok := numeric(b)
+		return ok && na == nb
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,138 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithMaxConcurrency bounds the calls the store makes to the lease collection
+// at once to n, to protect a shared deployment from a burst of candidates.
+// Excess calls queue until a slot frees or their context is done. By default
+// calls are not bounded.
+func WithMaxConcurrency(n int) Option {
+	return func(s *Store) {
+		s.maxConcurrency = n
+	}
+}
+
+// applyMaxConcurrency makes the lease collections share a semaphore of the
+// maximum concurrency, if any. It must run after the collections are cloned.
+func (s *Store) applyMaxConcurrency() {
+	if s.maxConcurrency <= 0 {
+		return
+	}
+
+	slots := make(chan struct{}, s.maxConcurrency)
+	s.collection = &limitedCollection{leaseCollection: s.collection, slots: slots}
+	if s.staleReads != nil {
+		s.staleReads = &limitedCollection{leaseCollection: s.staleReads, slots: slots}
+	}
+	if s.confirmReads != nil {
+		s.confirmReads = &limitedCollection{leaseCollection: s.confirmReads, slots: slots}
+	}
+}
+
+// limitedCollection runs a call to the wrapped collection only while holding
+// one of slots. Results are read before the slot is released.
+type limitedCollection struct {
+	leaseCollection
+	slots chan struct{}
+}
+
+func (c *limitedCollection) acquire(ctx context.Context) error {
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for a free operation slot: %w", ctx.Err())
+	}
+}
+
+func (c *limitedCollection) release() {
+	<-c.slots
+}
+
+func (c *limitedCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	if err := c.acquire(ctx); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	defer c.release()
+	return settled(c.leaseCollection.FindOne(ctx, filter, opts...))
+}
+
+func (c *limitedCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	cursor, err := c.leaseCollection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var docs []interface{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return mongo.NewCursorFromDocuments(docs, nil, nil)
+}
+
+func (c *limitedCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	if err := c.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer c.release()
+	return c.leaseCollection.CountDocuments(ctx, filter, opts...)
+}
+
+func (c *limitedCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.leaseCollection.InsertOne(ctx, document, opts...)
+}
+
+func (c *limitedCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.leaseCollection.UpdateOne(ctx, filter, update, opts...)
+}
+
+func (c *limitedCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	if err := c.acquire(ctx); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	defer c.release()
+	return settled(c.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts...))
+}
+
+func (c *limitedCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.leaseCollection.ReplaceOne(ctx, filter, replacement, opts...)
+}
+
+func (c *limitedCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.leaseCollection.DeleteOne(ctx, filter, opts...)
+}
+
+func (c *limitedCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	if err := c.acquire(ctx); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	defer c.release()
+	return settled(c.leaseCollection.FindOneAndDelete(ctx, filter, opts...))
+}
@@ -0,0 +1,81 @@
+package mongoleasestore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// slowCollection makes FindOne take delay and tracks how many run at once.
+type slowCollection struct {
+	*fakeCollection
+	delay time.Duration
+
+	mu                  sync.Mutex
+	running, maxRunning int
+}
+
+func (c *slowCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	c.mu.Lock()
+	c.running++
+	c.maxRunning = max(c.maxRunning, c.running)
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+	return c.fakeCollection.FindOne(ctx, filter, opts...)
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	t.Parallel()
+
+	collection := &slowCollection{fakeCollection: newFakeCollection(), delay: 20 * time.Millisecond}
+	store := newTestStore(t, collection, WithMaxConcurrency(3))
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(context.Background(), &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.GetLease(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 3, collection.maxRunning, "At most 3 operations should run at once")
+
+	t.Run("Queued Context Done", func(t *testing.T) {
+		collection.delay = time.Second
+		for range 3 {
+			go func() { _, _ = store.GetLease(context.Background()) }()
+		}
+		require.Eventually(t, func() bool {
+			collection.mu.Lock()
+			defer collection.mu.Unlock()
+			return collection.running == 3
+		}, time.Second, time.Millisecond, "Slots should fill up")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := store.GetLease(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded, "Queued operation should give up with its context")
+	})
+}
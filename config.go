@@ -0,0 +1,192 @@
+package mongoleasestore
+
+import (
+	"io"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config is a snapshot of the settings a Store resolved from its arguments,
+// options and defaults.
+type Config struct {
+	LeaseKey string
+	// Namespace is the "database.collection" holding the lease.
+	Namespace string
+	// HistoryNamespace is the namespace of the history collection, if any.
+	HistoryNamespace string
+	// HistoryRetention and HistoryTransitionsOnly are the settings of
+	// WithHistoryRetention and WithHistoryTransitionsOnly.
+	HistoryRetention       time.Duration
+	HistoryTransitionsOnly bool
+
+	WriteConcern   *writeconcern.WriteConcern
+	ReadConcern    *readconcern.ReadConcern
+	ReadPreference *readpref.ReadPref
+	// StaleReadPreference is the read preference of GetLease and ListLeases
+	// set with WithStaleReadPreference, if any.
+	StaleReadPreference *readpref.ReadPref
+
+	OperationComment          string
+	ReadCacheTTL              time.Duration
+	SoftDelete                bool
+	MinimalDocument           bool
+	EnforceConsistentDuration bool
+	Region                    string
+	StrictRegion              bool
+	RenewDeadlineGuard        bool
+	AdminOperations           bool
+	MinRenewInterval          time.Duration
+	GracePeriod               time.Duration
+	ServerClockCheck          bool
+	DurationReconciliation    DurationReconciliation
+	OperationTimeout          time.Duration
+	LateRenewThreshold        time.Duration
+	MaxPayloadBytes           int
+	MaxConcurrency            int
+	UpdateUpserts             bool
+	UnchangedUpdateError      bool
+	ServerTimestamps          bool
+	EnsureCollection          bool
+	ExpiresAt                 bool
+	ExpireAfter               time.Duration
+	YieldToPreferred          bool
+	// AcquireWindowStart and AcquireWindowEnd bound the window set with
+	// WithAcquireWindow, zero when there is none.
+	AcquireWindowStart time.Time
+	AcquireWindowEnd   time.Time
+	// MaxTransitions, TransitionWindow and TransitionCooldown are the settings of
+	// WithMaxTransitionRate, zero when transitions are not tracked.
+	MaxTransitions     int
+	TransitionWindow   time.Duration
+	TransitionCooldown time.Duration
+	// AcquireBackoff is the maximum delay set with
+	// WithAcquireBackoffByIdentity, zero when disabled.
+	AcquireBackoff time.Duration
+	// LatencyStatsWindow is the number of latencies kept by WithLatencyStats,
+	// zero when tracking is disabled.
+	LatencyStatsWindow int
+	// RetryPolicy is the policy set with WithRetryPolicy, zero when calls are
+	// not retried.
+	RetryPolicy RetryPolicy
+	// CircuitBreakerFailures and CircuitBreakerCooldown are the settings of
+	// WithCircuitBreaker, zero when there is no circuit breaker.
+	CircuitBreakerFailures int
+	CircuitBreakerCooldown time.Duration
+	// ExpiryPredicate is the predicate set with WithExpiryPredicate, nil when
+	// the default rule applies.
+	ExpiryPredicate ExpiryPredicate
+	// Metrics is the hook set with WithMetrics, if any.
+	Metrics Metrics
+	// Observer is the hook set with WithObserver, if any.
+	Observer Observer
+	// TracerProvider is the provider set with WithTracerProvider, if any.
+	TracerProvider trace.TracerProvider
+	// AuditWriter is the writer set with WithAuditWriter, if any.
+	AuditWriter io.Writer
+	// OnWrite is the callback set with WithOnWrite, if any.
+	OnWrite func(before, after *le.Lease, reason string)
+	// ReleaseOnClose is the holder set with WithReleaseOnClose, if any.
+	ReleaseOnClose string
+	// ReadOnly is set by WithReadOnly.
+	ReadOnly bool
+	// DecisionRecorder is the channel set with WithDecisionRecorder, if any.
+	DecisionRecorder chan<- Decision
+	// DocumentEncoder and DocumentDecoder are the custom document layout set
+	// with WithDocumentEncoder and WithDocumentDecoder, nil for the default one.
+	DocumentEncoder DocumentEncoder
+	DocumentDecoder DocumentDecoder
+}
+
+// Config returns the settings the store is using.
+func (s *Store) Config() Config {
+	cfg := Config{
+		LeaseKey:                  s.leaseKey,
+		HistoryNamespace:          namespace(s.history),
+		HistoryRetention:          s.historyRetention,
+		HistoryTransitionsOnly:    s.historyTransitionsOnly,
+		WriteConcern:              s.collectionOptions.WriteConcern,
+		ReadConcern:               s.collectionOptions.ReadConcern,
+		ReadPreference:            s.collectionOptions.ReadPreference,
+		StaleReadPreference:       s.staleReadPreference,
+		OperationComment:          s.comment,
+		SoftDelete:                s.softDelete,
+		MinimalDocument:           s.minimalDocument,
+		EnforceConsistentDuration: s.enforceConsistentDuration,
+		Region:                    s.region,
+		StrictRegion:              s.strictRegion,
+		RenewDeadlineGuard:        s.renewDeadlineGuard,
+		AdminOperations:           s.adminOperations,
+		MinRenewInterval:          s.minRenewInterval,
+		GracePeriod:               s.grace,
+		ServerClockCheck:          s.serverClockCheck != nil,
+		DurationReconciliation:    s.durationReconciliation,
+		OperationTimeout:          s.timeout,
+		LateRenewThreshold:        s.lateRenewThreshold,
+		MaxPayloadBytes:           s.maxPayloadBytes,
+		MaxConcurrency:            s.maxConcurrency,
+		UpdateUpserts:             s.updateUpserts,
+		UnchangedUpdateError:      s.unchangedUpdateError,
+		ServerTimestamps:          s.serverTimestamps,
+		EnsureCollection:          s.ensureCollection,
+		ExpiresAt:                 s.expiresAt,
+		ExpireAfter:               s.expireAfter,
+		YieldToPreferred:          s.yieldToPreferred,
+		ExpiryPredicate:           s.expiryPredicate,
+		Metrics:                   s.metrics,
+		Observer:                  s.observer,
+		TracerProvider:            s.tracerProvider,
+		OnWrite:                   s.onWrite,
+		ReleaseOnClose:            s.releaseOnClose,
+		ReadOnly:                  s.readOnly,
+		DecisionRecorder:          s.decisions,
+		DocumentEncoder:           s.encoder,
+		DocumentDecoder:           s.decoder,
+	}
+
+	if collection := s.mongoCollection(); collection != nil {
+		cfg.Namespace = namespace(collection)
+	}
+	if s.readCache != nil {
+		cfg.ReadCacheTTL = s.readCache.ttl
+	}
+	if s.acquireWindow != nil {
+		cfg.AcquireWindowStart = s.acquireWindow.start
+		cfg.AcquireWindowEnd = s.acquireWindow.end
+	}
+	if s.auditLog != nil {
+		cfg.AuditWriter = s.auditLog.w
+	}
+	if s.transitions != nil {
+		cfg.MaxTransitions = s.transitions.max
+		cfg.TransitionWindow = s.transitions.window
+		cfg.TransitionCooldown = s.transitions.cooldown
+	}
+	if s.acquireBackoff != nil {
+		cfg.AcquireBackoff = s.acquireBackoff.max
+	}
+	if s.retryPolicy != nil {
+		cfg.RetryPolicy = *s.retryPolicy
+	}
+	if s.breaker != nil {
+		cfg.CircuitBreakerFailures = s.breaker.failures
+		cfg.CircuitBreakerCooldown = s.breaker.cooldown
+	}
+	if s.stats != nil {
+		cfg.LatencyStatsWindow = s.stats.window
+	}
+
+	return cfg
+}
+
+func namespace(collection *mongo.Collection) string {
+	if collection == nil {
+		return ""
+	}
+	return collection.Database().Name() + "." + collection.Name()
+}
@@ -0,0 +1,160 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+
+	store, err := NewStore(Args{
+		LeaseCollection: mongoClient.Database("leases").Collection("elections"),
+		LeaseKey:        "test-lease-key",
+	},
+		WithDurabilityProfile(DurabilityStrong),
+		WithReadCache(time.Second),
+		WithRegion("eu-west-1"),
+	)
+	require.NoError(t, err, "Failed to create store")
+
+	cfg := store.Config()
+	assert.Equal(t, "test-lease-key", cfg.LeaseKey)
+	assert.Equal(t, "leases.elections", cfg.Namespace)
+	assert.Equal(t, writeconcern.Majority(), cfg.WriteConcern)
+	assert.Equal(t, time.Second, cfg.ReadCacheTTL)
+	assert.Equal(t, "eu-west-1", cfg.Region)
+	assert.Equal(t, "mongoleasestore", cfg.OperationComment, "Defaults should be reported")
+	assert.False(t, cfg.SoftDelete)
+	assert.Empty(t, cfg.HistoryNamespace)
+}
+
+func TestArgsConcerns(t *testing.T) {
+	t.Parallel()
+
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+	collection := mongoClient.Database("leases").Collection("elections")
+
+	t.Run("set", func(t *testing.T) {
+		store, err := NewStore(Args{
+			LeaseCollection:  collection,
+			LeaseKey:         "test-lease-key",
+			WriteConcern:     writeconcern.Majority(),
+			ReadConcern:      readconcern.Majority(),
+			OperationTimeout: 2 * time.Second,
+		})
+		require.NoError(t, err, "Failed to create store")
+
+		cfg := store.Config()
+		assert.Equal(t, writeconcern.Majority(), cfg.WriteConcern)
+		assert.Equal(t, readconcern.Majority(), cfg.ReadConcern)
+		assert.Equal(t, 2*time.Second, cfg.OperationTimeout)
+		assert.NotSame(t, collection, store.mongoCollection(), "The collection should be cloned with the concerns")
+		require.IsType(t, &gatedCollection{}, store.collection)
+		assert.IsType(t, &timeoutCollection{}, store.collection.(*gatedCollection).leaseCollection, "Operations should be bounded")
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		store, err := NewStore(Args{LeaseCollection: collection, LeaseKey: "test-lease-key"})
+		require.NoError(t, err, "Failed to create store")
+
+		cfg := store.Config()
+		assert.Equal(t, defaultWriteConcern(), cfg.WriteConcern, "Writes should default to majority with journaling")
+		assert.Nil(t, cfg.ReadConcern)
+		assert.Zero(t, cfg.OperationTimeout)
+	})
+
+	t.Run("collection's own", func(t *testing.T) {
+		store, err := NewStore(Args{LeaseCollection: collection, LeaseKey: "test-lease-key"}, WithWriteConcern(nil))
+		require.NoError(t, err, "Failed to create store")
+
+		assert.Nil(t, store.Config().WriteConcern)
+		assert.Same(t, collection, store.mongoCollection(), "The collection should be used as is")
+	})
+
+	t.Run("option", func(t *testing.T) {
+		store, err := NewStore(Args{
+			LeaseCollection: collection,
+			LeaseKey:        "test-lease-key",
+			WriteConcern:    writeconcern.Majority(),
+		}, WithWriteConcern(writeconcern.W1()))
+		require.NoError(t, err, "Failed to create store")
+
+		assert.Equal(t, writeconcern.W1(), store.Config().WriteConcern, "Options should override Args")
+	})
+}
+
+func TestArgsOptions(t *testing.T) {
+	t.Parallel()
+
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+
+	store, err := NewStore(Args{},
+		WithLeaseCollection(mongoClient.Database("leases").Collection("elections")),
+		WithLeaseKey("test-lease-key"),
+		WithOperationTimeout(time.Second),
+	)
+	require.NoError(t, err, "Failed to create store")
+	cfg := store.Config()
+	assert.Equal(t, "test-lease-key", cfg.LeaseKey)
+	assert.Equal(t, "leases.elections", cfg.Namespace)
+	assert.Equal(t, time.Second, cfg.OperationTimeout)
+
+	store, err = NewStore(Args{
+		LeaseCollection: mongoClient.Database("leases").Collection("elections"),
+		LeaseKey:        "args-lease-key",
+	}, WithLeaseKey("test-lease-key"))
+	require.NoError(t, err, "Failed to create store")
+	assert.Equal(t, "test-lease-key", store.Config().LeaseKey, "Options should override Args")
+}
+
+func TestReadConcern(t *testing.T) {
+	t.Parallel()
+
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+	args := Args{
+		LeaseCollection: mongoClient.Database("leases").Collection("elections"),
+		LeaseKey:        "test-lease-key",
+	}
+
+	store, err := NewStore(args, WithReadConcern(readconcern.Linearizable()), WithStaleReadPreference(readpref.Nearest()))
+	require.NoError(t, err, "Stale reads should fall back from linearizable")
+	assert.Equal(t, readconcern.Linearizable(), store.Config().ReadConcern)
+	assert.NotNil(t, store.staleReads)
+
+	_, err = NewStore(args, WithReadConcern(readconcern.Linearizable()), WithDurabilityProfile(DurabilityProfile{
+		ReadPreference: readpref.SecondaryPreferred(),
+	}))
+	require.Error(t, err, "Linearizable reads should require the primary")
+}
@@ -0,0 +1,89 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// ConfirmLeadership reports whether holder still holds an unexpired lease,
+// for a holder about to perform an action that must never run under two
+// leaders. The lease is read from the primary with the majority read concern,
+// or the linearizable one if the store is configured with it, so that a state
+// which could be rolled back is never confirmed. Expiry is evaluated against
+// the server's clock at renew_time + lease_duration, without the grace
+// period: a holder past its duration is no longer entitled to act even though
+// others cannot take over yet. A missing lease is not held by anyone.
+func (s *Store) ConfirmLeadership(ctx context.Context, holder string) (_ bool, err error) {
+	defer s.observeOperation("confirm", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "confirm", holder)
+	defer endSpan(span, &err)
+
+	if err := s.checkContext(ctx, "confirm"); err != nil {
+		return false, err
+	}
+	if err := s.requireDefaultDocument("confirm"); err != nil {
+		return false, err
+	}
+	if holder == "" {
+		return false, nil
+	}
+
+	filter := s.leaseFilter()
+	filter["holder_identity"] = holder
+	filter["$expr"] = bson.M{"$gt": bson.A{
+		// lease_duration is stored in nanoseconds, dates are added in milliseconds.
+		bson.M{"$add": bson.A{"$renew_time", bson.M{"$divide": bson.A{"$lease_duration", int64(time.Millisecond)}}}},
+		"$$NOW",
+	}}
+
+	opts := s.findOneOptions().SetProjection(bson.M{"_id": 1})
+	err = s.confirmCollection().FindOne(ctx, filter, opts).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// applyConfirmReads clones the lease collection for ConfirmLeadership with
+// primary reads and the majority read concern, unless the store already reads
+// linearizably.
+func (s *Store) applyConfirmReads() error {
+	collection, ok := s.collection.(*mongo.Collection)
+	if !ok || collection == nil {
+		return nil
+	}
+
+	concern := readconcern.Majority()
+	if isLinearizable(s.collectionOptions.ReadConcern) {
+		concern = s.collectionOptions.ReadConcern
+	}
+
+	cloned, err := collection.Clone(options.Collection().
+		SetReadPreference(readpref.Primary()).
+		SetReadConcern(concern))
+	if err != nil {
+		return err
+	}
+	s.confirmReads = cloned
+
+	return nil
+}
+
+// confirmCollection is the collection serving ConfirmLeadership.
+func (s *Store) confirmCollection() leaseCollection {
+	if s.confirmReads != nil {
+		return s.confirmReads
+	}
+	return s.collection
+}
@@ -0,0 +1,55 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmLeadership(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	confirmed, err := store.ConfirmLeadership(ctx, "candidate-1")
+	require.NoError(t, err)
+	assert.False(t, confirmed, "A missing lease should not be confirmed")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", 300*time.Millisecond)
+	require.NoError(t, err)
+
+	confirmed, err = store.ConfirmLeadership(ctx, "candidate-1")
+	require.NoError(t, err)
+	assert.True(t, confirmed, "The holder should be confirmed")
+
+	confirmed, err = store.ConfirmLeadership(ctx, "candidate-2")
+	require.NoError(t, err)
+	assert.False(t, confirmed, "Another candidate should not be confirmed")
+
+	time.Sleep(400 * time.Millisecond)
+	confirmed, err = store.ConfirmLeadership(ctx, "candidate-1")
+	require.NoError(t, err)
+	assert.False(t, confirmed, "An expired lease should not be confirmed")
+
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err, "Expired lease should be taken over")
+
+	confirmed, err = store.ConfirmLeadership(ctx, "candidate-1")
+	require.NoError(t, err)
+	assert.False(t, confirmed, "The previous holder should lose leadership")
+
+	confirmed, err = store.ConfirmLeadership(ctx, "candidate-2")
+	require.NoError(t, err)
+	assert.True(t, confirmed, "The new holder should be confirmed")
+}
@@ -0,0 +1,91 @@
+package mongoleasestore
+
+import (
+	"errors"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// DecisionOutcome is the outcome of a lease write, see Decision.
+type DecisionOutcome string
+
+const (
+	// DecisionAcquired is a lease acquired or taken over by the holder.
+	DecisionAcquired DecisionOutcome = "acquired"
+	// DecisionRenewed is a lease renewed by its holder.
+	DecisionRenewed DecisionOutcome = "renewed"
+	// DecisionReleased is a lease released by its holder.
+	DecisionReleased DecisionOutcome = "released"
+	// DecisionConflict is a write rejected because another candidate holds the
+	// lease or changed it concurrently.
+	DecisionConflict DecisionOutcome = "conflict"
+	// DecisionNotFound is a write rejected because the lease does not exist.
+	DecisionNotFound DecisionOutcome = "not_found"
+)
+
+// Decision records the outcome of a lease write, see WithDecisionRecorder.
+type Decision struct {
+	LeaseKey string
+	// Op is the operation: acquire, create, update or touch.
+	Op string
+	// Holder is the candidate the write was made for, empty for a release by
+	// UpdateLease.
+	Holder  string
+	Outcome DecisionOutcome
+}
+
+// WithDecisionRecorder sends a Decision to decisions for every lease write
+// that acquired, renewed or released the lease, or was rejected for a
+// conflict or a missing lease, in the order the store observed them. Writes
+// failing for other reasons, such as network errors, are not recorded.
+//
+// Decisions are sent without blocking: they are dropped while decisions is
+// full, so its buffer must fit the decisions made between reads. It is meant
+// for tests asserting the sequence of decisions of an election.
+func WithDecisionRecorder(decisions chan<- Decision) Option {
+	return func(s *Store) {
+		s.decisions = decisions
+	}
+}
+
+// recordDecision sends the decision of op for holder, if any.
+func (s *Store) recordDecision(op, holder string, outcome DecisionOutcome) {
+	if s.decisions == nil {
+		return
+	}
+
+	select {
+	case s.decisions <- Decision{LeaseKey: s.leaseKey, Op: op, Holder: holder, Outcome: outcome}:
+	default:
+	}
+}
+
+// recordRejection records the rejection of a write by op for holder, given
+// its error. It is meant to be deferred with a pointer to the operation's
+// named error; applied writes are recorded by finishWrite.
+func (s *Store) recordRejection(op, holder string, err *error) {
+	if s.decisions == nil || *err == nil {
+		return
+	}
+
+	switch {
+	case errors.Is(*err, ErrLeaseYielded):
+		// Recorded as released by yieldIfNotPreferred.
+	case errors.Is(*err, le.ErrLeaseNotFound):
+		s.recordDecision(op, holder, DecisionNotFound)
+	case IsConflict(*err):
+		s.recordDecision(op, holder, DecisionConflict)
+	}
+}
+
+// decisionOutcome maps the reason of an applied write to its outcome.
+func decisionOutcome(reason string) DecisionOutcome {
+	switch reason {
+	case ReasonRenew:
+		return DecisionRenewed
+	case ReasonRelease:
+		return DecisionReleased
+	default:
+		return DecisionAcquired
+	}
+}
@@ -0,0 +1,77 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionRecorder(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	decisions := make(chan Decision, 16)
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithDecisionRecorder(decisions))
+	require.NoError(t, err, "Failed to create store")
+
+	now := time.Now()
+	err = store.UpdateLease(ctx, &le.Lease{HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now, LeaseDuration: time.Second})
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+
+	_, err = store.AcquireLease(ctx, "candidate-1", 300*time.Millisecond)
+	require.NoError(t, err)
+	_, err = store.AcquireLease(ctx, "candidate-1", 300*time.Millisecond)
+	require.NoError(t, err)
+	_, err = store.AcquireLease(ctx, "candidate-2", 300*time.Millisecond)
+	require.ErrorIs(t, err, ErrLeaseHeld)
+
+	// Handover once the lease of candidate-1 expired.
+	time.Sleep(400 * time.Millisecond)
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err)
+	require.ErrorIs(t, store.Touch(ctx, "candidate-1"), ErrLeaseLost)
+	require.NoError(t, store.Touch(ctx, "candidate-2"))
+
+	want := []Decision{
+		{LeaseKey: "test-lease-key", Op: "update", Holder: "candidate-1", Outcome: DecisionNotFound},
+		{LeaseKey: "test-lease-key", Op: "acquire", Holder: "candidate-1", Outcome: DecisionAcquired},
+		{LeaseKey: "test-lease-key", Op: "acquire", Holder: "candidate-1", Outcome: DecisionRenewed},
+		{LeaseKey: "test-lease-key", Op: "acquire", Holder: "candidate-2", Outcome: DecisionConflict},
+		{LeaseKey: "test-lease-key", Op: "acquire", Holder: "candidate-2", Outcome: DecisionAcquired},
+		{LeaseKey: "test-lease-key", Op: "touch", Holder: "candidate-1", Outcome: DecisionConflict},
+		{LeaseKey: "test-lease-key", Op: "touch", Holder: "candidate-2", Outcome: DecisionRenewed},
+	}
+	got := make([]Decision, 0, len(want))
+	for len(decisions) > 0 {
+		got = append(got, <-decisions)
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestDecisionRecorderFull(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	decisions := make(chan Decision, 1)
+	store := newTestStore(t, newFakeCollection(), WithDecisionRecorder(decisions))
+
+	now := time.Now()
+	lease := &le.Lease{HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now, LeaseDuration: time.Second}
+	require.NoError(t, store.CreateLease(ctx, lease))
+	renewed := *lease
+	renewed.RenewTime = now.Add(100 * time.Millisecond)
+	require.NoError(t, store.UpdateLease(ctx, &renewed), "A full recorder should not block writes")
+
+	require.Len(t, decisions, 1, "Decisions should be dropped while the recorder is full")
+	assert.Equal(t, Decision{LeaseKey: "test-lease-key", Op: "create", Holder: "candidate-1", Outcome: DecisionAcquired}, <-decisions)
+}
@@ -0,0 +1,187 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeleteCondition restricts DeleteLeaseIf to leases in a given state.
+type DeleteCondition interface {
+	filter(ctx context.Context, s *Store, now time.Time) (bson.M, error)
+}
+
+type byHolder string
+
+func (h byHolder) filter(context.Context, *Store, time.Time) (bson.M, error) {
+	return bson.M{"holder_identity": string(h)}, nil
+}
+
+// ByHolder matches a lease held by holder.
+func ByHolder(holder string) DeleteCondition {
+	return byHolder(holder)
+}
+
+type expired struct{}
+
+func (expired) filter(ctx context.Context, s *Store, now time.Time) (bson.M, error) {
+	return s.expiredFilter(ctx, now)
+}
+
+// Expired matches a lease whose renew_time + lease_duration is in the past
+// according to the store's clock, or that the store's expiry predicate (see
+// WithExpiryPredicate) reports as expired.
+var Expired DeleteCondition = expired{}
+
+// DeleteLease removes the lease, or tombstones it when WithSoftDelete is set.
+// Returns ErrLeaseNotFound if the lease does not exist.
+func (s *Store) DeleteLease(ctx context.Context) (err error) {
+	defer s.observeOperation("delete", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "delete", "")
+	defer endSpan(span, &err)
+
+	if err := s.checkContext(ctx, "delete"); err != nil {
+		return err
+	}
+
+	before, deleted, err := s.deleteLease(ctx, s.leaseFilter())
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return le.ErrLeaseNotFound
+	}
+
+	if s.history != nil {
+		s.recordHistory(ctx, ReasonDelete, before.HolderIdentity, &le.Lease{}, before.LeaderTransitions)
+	}
+	s.audit(ctx, "delete", "", ReasonDelete)
+
+	return nil
+}
+
+// DeleteLeaseIf deletes the lease only if it matches cond, in a single
+// conditional write. Returns ErrLeaseConflict if the lease exists but does not
+// match cond and ErrLeaseNotFound if it does not exist.
+func (s *Store) DeleteLeaseIf(ctx context.Context, cond DeleteCondition) (err error) {
+	defer s.observeOperation("delete", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "delete", "")
+	defer endSpan(span, &err)
+
+	if err := s.checkContext(ctx, "delete"); err != nil {
+		return err
+	}
+	if err := s.requireDefaultDocument("delete"); err != nil {
+		return err
+	}
+
+	condition, err := cond.filter(ctx, s, s.clock.Now())
+	if err != nil {
+		return fmt.Errorf("delete lease %q: %w", s.leaseKey, err)
+	}
+
+	filter := s.leaseFilter()
+	for k, v := range condition {
+		filter[k] = v
+	}
+
+	before, deleted, err := s.deleteLease(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return s.conflictOrNotFound(ctx)
+	}
+
+	if s.history != nil {
+		s.recordHistory(ctx, ReasonDelete, before.HolderIdentity, &le.Lease{}, before.LeaderTransitions)
+	}
+	s.audit(ctx, "delete", "", ReasonDelete)
+
+	return nil
+}
+
+// ReleaseLease deletes the lease only if holder holds it, so that the next
+// election can start right away instead of waiting for the lease to expire, as
+// in ReleaseOnCancel workflows. Returns ErrLeaseConflict if another candidate
+// holds the lease and ErrLeaseNotFound if it does not exist.
+func (s *Store) ReleaseLease(ctx context.Context, holder string) error {
+	if holder == "" {
+		return fmt.Errorf("release lease %q: %w", s.leaseKey, ErrEmptyHolder)
+	}
+	return s.DeleteLeaseIf(ctx, ByHolder(holder))
+}
+
+// deleteLease removes or tombstones the document matching filter and reports
+// whether there was one. The deleted document is captured (at the cost of a
+// findAndModify) only when history is recorded, and is nil otherwise.
+func (s *Store) deleteLease(ctx context.Context, filter bson.M) (*leaseDocument, bool, error) {
+	defer s.readCache.invalidate()
+
+	if s.history != nil {
+		before, err := s.decodeDocument(s.deleteLeaseReturning(ctx, filter))
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return before, true, nil
+	}
+
+	if s.softDelete {
+		result, err := s.collection.UpdateOne(ctx, filter, s.tombstoneUpdate(), s.updateOptions())
+		if err != nil {
+			return nil, false, err
+		}
+		return nil, result.MatchedCount > 0, nil
+	}
+
+	result, err := s.collection.DeleteOne(ctx, filter, s.deleteOptions())
+	if err != nil {
+		return nil, false, err
+	}
+
+	return nil, result.DeletedCount > 0, nil
+}
+
+// deleteLeaseReturning removes or tombstones the document matching filter and
+// returns it as it was before.
+func (s *Store) deleteLeaseReturning(ctx context.Context, filter bson.M) *mongo.SingleResult {
+	if s.softDelete {
+		opts := s.findOneAndUpdateOptions().SetReturnDocument(options.Before)
+		return s.collection.FindOneAndUpdate(ctx, filter, s.tombstoneUpdate(), opts)
+	}
+	return s.collection.FindOneAndDelete(ctx, filter, s.findOneAndDeleteOptions())
+}
+
+// tombstoneUpdate marks a lease as deleted, see WithSoftDelete.
+func (s *Store) tombstoneUpdate() bson.M {
+	return bson.M{"$set": bson.M{
+		"deleted_at":      s.clock.Now(),
+		"holder_identity": "",
+	}}
+}
+
+// conflictOrNotFound explains why a conditional write matched nothing:
+// ErrLeaseConflict if the lease exists, ErrLeaseNotFound otherwise.
+func (s *Store) conflictOrNotFound(ctx context.Context) error {
+	count, err := s.collection.CountDocuments(ctx, s.leaseFilter(), s.countOptions())
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return le.ErrLeaseNotFound
+	}
+
+	return ErrLeaseConflict
+}
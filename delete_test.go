@@ -0,0 +1,148 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSoftDelete(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithSoftDelete())
+	require.NoError(t, err, "Failed to create store")
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	require.NoError(t, store.CreateLease(ctx, lease))
+	require.NoError(t, store.DeleteLease(ctx))
+
+	_, err = store.GetLease(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound, "Soft-deleted lease should read as not found")
+	require.ErrorIs(t, store.DeleteLease(ctx), le.ErrLeaseNotFound, "Soft-deleted lease should not be deleted twice")
+
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": "test-lease-key", "deleted_at": bson.M{"$exists": true}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count, "Soft-deleted lease should remain in the collection")
+
+	live, err := store.ListLeases(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, live, "Tombstones should be excluded by default")
+
+	all, err := store.ListLeases(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, all, 1, "Tombstones should be listed on request")
+	assert.NotNil(t, all[0].DeletedAt)
+	assert.Empty(t, all[0].Lease.HolderIdentity, "Tombstone should not have a holder")
+
+	// A tombstone does not block a new acquisition.
+	require.NoError(t, store.CreateLease(ctx, lease))
+	got, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "candidate-1", got.HolderIdentity)
+}
+
+func TestDeleteLeaseIf(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	t.Run("By Holder", func(t *testing.T) {
+		now := time.Now()
+		require.NoError(t, store.CreateLease(ctx, &le.Lease{
+			HolderIdentity: "candidate-1",
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  time.Minute,
+		}))
+
+		err := store.DeleteLeaseIf(ctx, ByHolder("candidate-2"))
+		require.ErrorIs(t, err, ErrLeaseConflict, "Lease held by another candidate should not be deleted")
+
+		require.NoError(t, store.DeleteLeaseIf(ctx, ByHolder("candidate-1")))
+
+		err = store.DeleteLeaseIf(ctx, ByHolder("candidate-1"))
+		require.ErrorIs(t, err, le.ErrLeaseNotFound)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		now := time.Now()
+		require.NoError(t, store.CreateLease(ctx, &le.Lease{
+			HolderIdentity: "candidate-1",
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  time.Minute,
+		}))
+
+		err := store.DeleteLeaseIf(ctx, Expired)
+		require.ErrorIs(t, err, ErrLeaseConflict, "Live lease should not be deleted")
+
+		past := now.Add(-2 * time.Minute)
+		require.NoError(t, store.UpdateLease(ctx, &le.Lease{
+			HolderIdentity: "candidate-1",
+			AcquireTime:    past,
+			RenewTime:      past,
+			LeaseDuration:  time.Minute,
+		}))
+
+		require.NoError(t, store.DeleteLeaseIf(ctx, Expired))
+
+		_, err = store.GetLease(ctx)
+		require.ErrorIs(t, err, le.ErrLeaseNotFound)
+	})
+}
+
+func TestReleaseLease(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	require.ErrorIs(t, store.ReleaseLease(ctx, ""), ErrEmptyHolder)
+	require.ErrorIs(t, store.ReleaseLease(ctx, "candidate-1"), le.ErrLeaseNotFound)
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err)
+
+	err = store.ReleaseLease(ctx, "candidate-2")
+	require.ErrorIs(t, err, ErrLeaseConflict, "Lease held by another candidate should not be released")
+
+	require.NoError(t, store.ReleaseLease(ctx, "candidate-1"))
+	_, err = store.GetLease(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound, "Released lease should be deleted")
+
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err, "Released lease should be acquirable right away")
+}
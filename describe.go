@@ -0,0 +1,120 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CollectionInfo describes the setup of the lease collection, see
+// DescribeCollection.
+type CollectionInfo struct {
+	// Namespace is the "database.collection" holding the lease.
+	Namespace string
+	// Indexes are the indexes of the collection.
+	Indexes []IndexInfo
+	// TTLIndex is the first index of Indexes with an expiry, if any.
+	TTLIndex *IndexInfo
+	// TTLMatchesGrace reports whether the TTL index keeps documents at least the
+	// configured grace period (see WithGracePeriod) past their indexed time.
+	TTLMatchesGrace bool
+	// SchemaValidation reports whether the collection has a validator.
+	SchemaValidation bool
+}
+
+// IndexInfo describes an index of the lease collection.
+type IndexInfo struct {
+	Name   string
+	Keys   bson.D
+	Unique bool
+	// ExpireAfter is the expiry of a TTL index, nil for other indexes.
+	ExpireAfter *time.Duration
+}
+
+// DescribeCollection reports the indexes and schema validation of the lease
+// collection, for troubleshooting deployments.
+func (s *Store) DescribeCollection(ctx context.Context) (CollectionInfo, error) {
+	collection := s.mongoCollection()
+	if collection == nil {
+		return CollectionInfo{}, errors.New("describe collection: the store is not backed by a MongoDB collection")
+	}
+
+	info := CollectionInfo{Namespace: namespace(collection)}
+
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return CollectionInfo{}, fmt.Errorf("describe collection %s: %w", info.Namespace, err)
+	}
+	var indexes []struct {
+		Name               string `bson:"name"`
+		Key                bson.D `bson:"key"`
+		Unique             bool   `bson:"unique"`
+		ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return CollectionInfo{}, fmt.Errorf("describe collection %s: %w", info.Namespace, err)
+	}
+	for _, index := range indexes {
+		indexInfo := IndexInfo{Name: index.Name, Keys: index.Key, Unique: index.Unique}
+		if index.ExpireAfterSeconds != nil {
+			expireAfter := time.Duration(*index.ExpireAfterSeconds) * time.Second
+			indexInfo.ExpireAfter = &expireAfter
+		}
+		info.Indexes = append(info.Indexes, indexInfo)
+	}
+	for i := range info.Indexes {
+		if info.Indexes[i].ExpireAfter != nil {
+			info.TTLIndex = &info.Indexes[i]
+			info.TTLMatchesGrace = *info.TTLIndex.ExpireAfter >= s.grace
+			break
+		}
+	}
+
+	specs, err := collection.Database().ListCollectionSpecifications(ctx, bson.M{"name": collection.Name()})
+	if err != nil {
+		return CollectionInfo{}, fmt.Errorf("describe collection %s: %w", info.Namespace, err)
+	}
+	for _, spec := range specs {
+		if spec.Options == nil {
+			continue
+		}
+		if _, err := spec.Options.LookupErr("validator"); err == nil {
+			info.SchemaValidation = true
+		}
+	}
+
+	return info, nil
+}
+
+// mongoCollection returns the MongoDB collection behind the store, nil when
+// the store is backed by something else.
+func (s *Store) mongoCollection() *mongo.Collection {
+	collection := s.collection
+	for {
+		switch wrapped := collection.(type) {
+		case *timeoutCollection:
+			collection = wrapped.leaseCollection
+		case *limitedCollection:
+			collection = wrapped.leaseCollection
+		case *retryCollection:
+			collection = wrapped.leaseCollection
+		case *breakerCollection:
+			collection = wrapped.leaseCollection
+		case *guardedCollection:
+			collection = wrapped.leaseCollection
+		case *readOnlyCollection:
+			collection = wrapped.leaseCollection
+		case *annotatedCollection:
+			collection = wrapped.leaseCollection
+		case *gatedCollection:
+			collection = wrapped.leaseCollection
+		default:
+			mongoCollection, _ := collection.(*mongo.Collection)
+			return mongoCollection
+		}
+	}
+}
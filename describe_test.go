@@ -0,0 +1,45 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestDescribeCollection(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "renew_time", Value: 1}},
+		Options: options.Index().SetName("renew_time_ttl").SetExpireAfterSeconds(60),
+	})
+	require.NoError(t, err)
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithGracePeriod(30*time.Second))
+	require.NoError(t, err, "Failed to create store")
+
+	info, err := store.DescribeCollection(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, t.Name()+"."+t.Name(), info.Namespace)
+	assert.Len(t, info.Indexes, 2, "The _id and TTL indexes should be reported")
+	require.NotNil(t, info.TTLIndex, "TTL index should be reported")
+	assert.Equal(t, "renew_time_ttl", info.TTLIndex.Name)
+	assert.Equal(t, bson.D{{Key: "renew_time", Value: int32(1)}}, info.TTLIndex.Keys)
+	require.NotNil(t, info.TTLIndex.ExpireAfter)
+	assert.Equal(t, time.Minute, *info.TTLIndex.ExpireAfter)
+	assert.True(t, info.TTLMatchesGrace)
+	assert.False(t, info.SchemaValidation)
+}
@@ -0,0 +1,23 @@
+package mongoleasestore
+
+import (
+	"fmt"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// SetDrain puts the store in (or takes it out of) draining mode. While
+// draining, writes that would acquire or renew the lease fail with
+// ErrDraining so that the elector voluntarily stops leading; releases and
+// deletions still go through.
+func (s *Store) SetDrain(draining bool) {
+	s.draining.Store(draining)
+}
+
+// checkDrain rejects writes that would give newLease a holder while draining.
+func (s *Store) checkDrain(op string, newLease *le.Lease) error {
+	if s.draining.Load() && newLease.HolderIdentity != "" {
+		return fmt.Errorf("%s lease %q: %w", op, s.leaseKey, ErrDraining)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	collection := newFakeCollection()
+	store := newTestStore(t, collection)
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+
+	store.SetDrain(true)
+
+	err := store.CreateLease(ctx, lease)
+	require.ErrorIs(t, err, ErrDraining, "Acquire should be refused while draining")
+	assert.Zero(t, collection.callCount("InsertOne"), "Mongo should not be contacted while draining")
+
+	store.SetDrain(false)
+	require.NoError(t, store.CreateLease(ctx, lease))
+
+	store.SetDrain(true)
+	err = store.UpdateLease(ctx, lease)
+	require.ErrorIs(t, err, ErrDraining, "Renew should be refused while draining")
+
+	released := *lease
+	released.HolderIdentity = ""
+	require.NoError(t, store.UpdateLease(ctx, &released), "Release should be allowed while draining")
+}
@@ -0,0 +1,144 @@
+package mongoleasestore
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// DurabilityProfile configures the write concern, read concern and read
+// preference of every store operation together.
+type DurabilityProfile struct {
+	WriteConcern   *writeconcern.WriteConcern
+	ReadConcern    *readconcern.ReadConcern
+	ReadPreference *readpref.ReadPref
+}
+
+var (
+	// DurabilityStrong acknowledges writes once they are replicated to a
+	// majority and reads linearizably from the primary, so that a lease
+	// acquisition survives failover and reads never observe a state that could
+	// be rolled back. It is the safest and slowest profile.
+	DurabilityStrong = DurabilityProfile{
+		WriteConcern:   writeconcern.Majority(),
+		ReadConcern:    readconcern.Linearizable(),
+		ReadPreference: readpref.Primary(),
+	}
+	// DurabilityFast acknowledges writes as soon as the primary applies them
+	// and reads the primary's local data. A failover may roll back a recent
+	// acquisition, briefly allowing two leaders.
+	DurabilityFast = DurabilityProfile{
+		WriteConcern:   writeconcern.W1(),
+		ReadConcern:    readconcern.Local(),
+		ReadPreference: readpref.Primary(),
+	}
+)
+
+// defaultWriteConcern acknowledges lease writes once they are journaled on a
+// majority, so that leadership decisions survive failover.
+func defaultWriteConcern() *writeconcern.WriteConcern {
+	journal := true
+	return &writeconcern.WriteConcern{W: "majority", Journal: &journal}
+}
+
+// WithWriteConcern sets the write concern of every lease write. Defaults to
+// majority with journaling: a write acknowledged by the primary alone can be
+// rolled back by a failover, silently handing leadership to two candidates. A
+// nil write concern keeps the lease collection's own.
+func WithWriteConcern(wc *writeconcern.WriteConcern) Option {
+	return func(s *Store) {
+		s.collectionOptions.SetWriteConcern(wc)
+	}
+}
+
+// WithReadConcern sets the read concern of every lease read. The collection's
+// own read concern, often local, can return a lease that a failover is about
+// to roll back; majority only returns leases that survive it, and
+// linearizable also reflects every write acknowledged before the read, at the
+// cost of a round trip to a majority. Linearizable reads require the primary
+// read preference; reads of WithStaleReadPreference fall back to majority.
+func WithReadConcern(rc *readconcern.ReadConcern) Option {
+	return func(s *Store) {
+		s.collectionOptions.SetReadConcern(rc)
+	}
+}
+
+// WithDurabilityProfile applies the concerns and read preference of profile to
+// the lease collection. Nil fields of profile are left as they are.
+func WithDurabilityProfile(profile DurabilityProfile) Option {
+	return func(s *Store) {
+		if profile.WriteConcern != nil {
+			s.collectionOptions.SetWriteConcern(profile.WriteConcern)
+		}
+		if profile.ReadConcern != nil {
+			s.collectionOptions.SetReadConcern(profile.ReadConcern)
+		}
+		if profile.ReadPreference != nil {
+			s.collectionOptions.SetReadPreference(profile.ReadPreference)
+		}
+	}
+}
+
+// applyCollectionOptions clones the lease collection with the configured
+// concerns and read preference, if any.
+func (s *Store) applyCollectionOptions() error {
+	collection, ok := s.collection.(*mongo.Collection)
+	if !ok || collection == nil {
+		return nil
+	}
+
+	opts := s.collectionOptions
+	if isLinearizable(opts.ReadConcern) && opts.ReadPreference != nil && opts.ReadPreference.Mode() != readpref.PrimaryMode {
+		return fmt.Errorf("linearizable read concern requires the primary read preference, not %s", opts.ReadPreference)
+	}
+	if opts.WriteConcern == nil && opts.ReadConcern == nil && opts.ReadPreference == nil {
+		return nil
+	}
+
+	cloned, err := collection.Clone(opts)
+	if err != nil {
+		return err
+	}
+	s.collection = cloned
+
+	return nil
+}
+
+// applyStaleReadPreference clones the lease collection with the stale read
+// preference, if any, for the reads that tolerate it.
+func (s *Store) applyStaleReadPreference() error {
+	collection, ok := s.collection.(*mongo.Collection)
+	if !ok || collection == nil || s.staleReadPreference == nil {
+		return nil
+	}
+
+	opts := options.Collection().SetReadPreference(s.staleReadPreference)
+	if isLinearizable(s.collectionOptions.ReadConcern) {
+		// Only the primary serves linearizable reads.
+		opts.SetReadConcern(readconcern.Majority())
+	}
+	cloned, err := collection.Clone(opts)
+	if err != nil {
+		return err
+	}
+	s.staleReads = cloned
+
+	return nil
+}
+
+// isLinearizable reports whether rc is the linearizable read concern.
+func isLinearizable(rc *readconcern.ReadConcern) bool {
+	return rc != nil && rc.Level == readconcern.Linearizable().Level
+}
+
+// readCollection is the collection serving reads that tolerate staleness.
+func (s *Store) readCollection() leaseCollection {
+	if s.staleReads != nil {
+		return s.staleReads
+	}
+	return s.collection
+}
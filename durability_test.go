@@ -0,0 +1,116 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestDurabilityProfile(t *testing.T) {
+	t.Parallel()
+
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithDurabilityProfile(DurabilityStrong))
+	require.NoError(t, err, "Failed to create store")
+
+	assert.Equal(t, writeconcern.Majority(), store.collectionOptions.WriteConcern, "Strong profile should write with majority")
+	assert.Equal(t, readconcern.Linearizable(), store.collectionOptions.ReadConcern)
+	assert.Equal(t, readpref.Primary(), store.collectionOptions.ReadPreference)
+	assert.NotSame(t, collection, store.mongoCollection(), "Collection should be cloned with the profile applied")
+}
+
+func TestStaleReadPreference(t *testing.T) {
+	t.Parallel()
+
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithStaleReadPreference(readpref.Nearest()), WithWriteConcern(nil))
+	require.NoError(t, err, "Failed to create store")
+	assert.Same(t, collection, store.mongoCollection(), "Writes should stay on the configured collection")
+	assert.NotNil(t, store.staleReads, "Stale reads should use a cloned collection")
+	assert.Equal(t, readpref.Nearest(), store.Config().StaleReadPreference)
+
+	t.Run("Routing", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		primary, stale := newFakeCollection(), newFakeCollection()
+		store.collection, store.staleReads = primary, stale
+
+		now := time.Now()
+		require.NoError(t, store.CreateLease(ctx, &le.Lease{
+			HolderIdentity: "candidate-1",
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  time.Minute,
+		}))
+		_, err := store.GetLease(ctx)
+		require.ErrorIs(t, err, le.ErrLeaseNotFound, "GetLease should read from the stale collection")
+		assert.Equal(t, 1, stale.callCount("FindOne"))
+
+		waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, store.WaitUntilFree(waitCtx, 10*time.Millisecond), context.DeadlineExceeded)
+		assert.Equal(t, 1, stale.callCount("FindOne"), "Expiry decisions should read from the primary")
+		assert.Positive(t, primary.callCount("FindOne"))
+		assert.Equal(t, 1, primary.callCount("InsertOne"), "Writes should go to the primary")
+	})
+}
+
+func TestStaleReadPreferenceObservers(t *testing.T) {
+	t.Parallel()
+
+	// The client never needs to reach a server.
+	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+
+	store, err := NewStore(Args{
+		LeaseCollection: mongoClient.Database(t.Name()).Collection(t.Name()),
+		LeaseKey:        "test-lease-key",
+	}, WithStaleReadPreference(readpref.SecondaryPreferred()))
+	require.NoError(t, err, "Failed to create store")
+	primary, stale := newFakeCollection(), newFakeCollection()
+	store.collection, store.staleReads = primary, stale
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := store.WatchLeaderPolling(ctx, time.Hour)
+	require.NoError(t, err)
+	<-changes
+	_, err = store.LeaderPayload(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+
+	assert.Equal(t, 2, stale.callCount("FindOne"), "Observers should read from the stale collection")
+	assert.Zero(t, primary.callCount("FindOne"), "Observers should not load the primary")
+}
@@ -0,0 +1,103 @@
+package mongoleasestore
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrLeaseConflict is returned when the lease exists but is not in the state
+	// an operation was conditioned on.
+	ErrLeaseConflict = errors.New("lease conflict")
+	// ErrConflict is ErrLeaseConflict under a shorter name.
+	ErrConflict = ErrLeaseConflict
+	// ErrLeaseAlreadyExists is returned by CreateLease when the lease exists.
+	// It wraps ErrLeaseConflict.
+	ErrLeaseAlreadyExists = fmt.Errorf("lease already exists: %w", ErrLeaseConflict)
+	// ErrLeaseHeld is returned when acquiring a lease that another candidate
+	// holds and has not expired.
+	ErrLeaseHeld = errors.New("lease held by another candidate")
+	// ErrDraining is returned by writes that would acquire or renew the lease
+	// while the store is draining. It means "voluntarily not leading" rather
+	// than a failure.
+	ErrDraining = errors.New("store is draining")
+	// ErrDurationMismatch is returned when WithEnforceConsistentDuration or
+	// DurationError is set and a write requests a lease duration different from
+	// the stored one.
+	ErrDurationMismatch = errors.New("lease duration mismatch")
+	// ErrEmptyHolder is returned when acquiring a lease without a holder
+	// identity, which would make every ownership check match.
+	ErrEmptyHolder = errors.New("empty holder identity")
+	// ErrRegionMismatch is returned when WithStrictRegion is set and the holder
+	// renews its lease from a different region than it acquired it in.
+	ErrRegionMismatch = errors.New("lease region mismatch")
+	// ErrLeaseLost is returned when renewing a lease the caller no longer holds.
+	ErrLeaseLost = errors.New("lease lost")
+	// ErrRenewUnreliable is returned when WithRenewDeadlineGuard is set and a
+	// renew was written after the lease it renewed may already have expired.
+	// The renew is stored, but the caller should treat the lease as lost.
+	ErrRenewUnreliable = errors.New("renew outlived the lease")
+	// ErrInvalidLease is returned by Validate when the stored lease is not
+	// internally consistent.
+	ErrInvalidLease = errors.New("invalid lease")
+	// ErrAdminDisabled is returned by admin operations on a store created
+	// without WithAdminOperations.
+	ErrAdminDisabled = errors.New("admin operations are disabled")
+	// ErrRenewTooSoon is returned when WithMinRenewInterval is set and the
+	// holder renews its lease sooner than the interval after its last renew.
+	ErrRenewTooSoon = errors.New("lease renewed too soon")
+	// ErrOutsideAcquireWindow is returned by AcquireLease outside the window
+	// set with WithAcquireWindow.
+	ErrOutsideAcquireWindow = errors.New("outside the acquire window")
+	// ErrTransitionCooldown is returned by writes that would change the lease
+	// holder while WithMaxTransitionRate is cooling down flapping leadership.
+	ErrTransitionCooldown = errors.New("leader transitions cooling down")
+	// ErrStaleFencingToken is returned by AcquireIfTokenAtLeast when the stored
+	// fencing token is below the required minimum.
+	ErrStaleFencingToken = errors.New("stale fencing token")
+	// ErrCollectionMissing is returned when MongoDB fails an operation with
+	// NamespaceNotFound because the lease collection does not exist. Reads and
+	// writes of a document do not fail on a dropped collection, see
+	// WithEnsureCollection.
+	ErrCollectionMissing = errors.New("lease collection missing")
+	// ErrPayloadTooLarge is returned by SetLeaderPayload for a payload over the
+	// limit set with WithMaxPayloadBytes.
+	ErrPayloadTooLarge = errors.New("leader payload too large")
+	// ErrCustomDocument is returned by operations and options that need the
+	// default document layout on a store with a custom one, see
+	// WithDocumentEncoder.
+	ErrCustomDocument = errors.New("not supported with a custom document layout")
+	// ErrNoQuorum is returned by QuorumStore.AcquireLease when a majority of
+	// its stores could not be acquired.
+	ErrNoQuorum = errors.New("lease quorum not reached")
+	// ErrLeaseYielded is returned, along with ErrLeaseLost, by renews that
+	// released the lease to the preferred holder, see WithYieldToPreferred.
+	ErrLeaseYielded = errors.New("lease yielded to the preferred holder")
+	// ErrLeaseUnchanged is returned by UpdateLease when WithUnchangedUpdateError
+	// is set and the update matched the lease but left it unchanged.
+	ErrLeaseUnchanged = errors.New("lease unchanged")
+	// ErrStoreClosed is returned by operations on a store after Close.
+	ErrStoreClosed = errors.New("store closed")
+	// ErrCircuitOpen is returned, without contacting MongoDB, by operations on
+	// a store whose circuit breaker is open, see WithCircuitBreaker.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+	// ErrReadOnly is returned by writes to a store created with WithReadOnly.
+	ErrReadOnly = errors.New("store is read-only")
+	// ErrHistoryDisabled is returned by GetLeaseHistory on a store created
+	// without WithHistoryCollection.
+	ErrHistoryDisabled = errors.New("no history collection configured")
+)
+
+// IsTransient reports whether err is a failure of MongoDB rather than of the
+// election, such as a network error, a primary stepping down or an open
+// circuit breaker, so that the operation may succeed if tried again later.
+func IsTransient(err error) bool {
+	return isTransient(err) || errors.Is(err, ErrCircuitOpen)
+}
+
+// IsConflict reports whether err means that the lease is not in the state the
+// operation expected, typically because another candidate wrote it first:
+// ErrLeaseConflict, ErrLeaseAlreadyExists, ErrLeaseHeld or ErrLeaseLost.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrLeaseConflict) || errors.Is(err, ErrLeaseHeld) || errors.Is(err, ErrLeaseLost)
+}
@@ -0,0 +1,74 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// duplicateCollection fails every InsertOne with a duplicate key error.
+type duplicateCollection struct {
+	*fakeCollection
+}
+
+func (c *duplicateCollection) InsertOne(context.Context, interface{}, ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	return nil, mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+}
+
+func TestCreateLeaseAlreadyExists(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, &duplicateCollection{fakeCollection: newFakeCollection()})
+
+	now := time.Now()
+	err := store.CreateLease(context.Background(), &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	})
+	require.ErrorIs(t, err, ErrLeaseAlreadyExists)
+	assert.ErrorIs(t, err, ErrConflict, "ErrLeaseAlreadyExists should be a conflict")
+	assert.True(t, IsConflict(err))
+	assert.False(t, IsTransient(err))
+}
+
+func TestErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+		conflict  bool
+	}{
+		{name: "nil"},
+		{name: "other", err: errors.New("boom")},
+		{name: "not found", err: le.ErrLeaseNotFound},
+		{name: "conflict", err: fmt.Errorf("update lease %q: %w", "key", ErrLeaseConflict), conflict: true},
+		{name: "held", err: ErrLeaseHeld, conflict: true},
+		{name: "lost", err: ErrLeaseLost, conflict: true},
+		{name: "already exists", err: ErrLeaseAlreadyExists, conflict: true},
+		{name: "step down", err: fmt.Errorf("get lease %q: %w", "key", mongo.CommandError{Code: 189}), transient: true},
+		{name: "network", err: mongo.CommandError{Labels: []string{"NetworkError"}}, transient: true},
+		{name: "circuit open", err: ErrCircuitOpen, transient: true},
+		{name: "canceled", err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.transient, IsTransient(tt.err), "IsTransient")
+			assert.Equal(t, tt.conflict, IsConflict(tt.err), "IsConflict")
+		})
+	}
+}
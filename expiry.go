@@ -0,0 +1,118 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ExpiryPredicate reports whether lease is expired, and can therefore be taken
+// over, at now.
+type ExpiryPredicate func(lease *le.Lease, now time.Time) bool
+
+// CanAcquire reports whether candidate may acquire the current lease at now:
+// the lease does not exist (nil), has no holder, is held by candidate (a
+// renew), or expired more than grace ago. A lease expires at renew_time +
+// lease_duration.
+func CanAcquire(current *le.Lease, candidate string, now time.Time, grace time.Duration) bool {
+	if current == nil || !current.HasHolder() || current.HolderIdentity == candidate {
+		return true
+	}
+	return current.RenewTime.Add(current.LeaseDuration + grace).Before(now)
+}
+
+// canAcquire is CanAcquire with the store's clock and expiry predicate, if set.
+func (s *Store) canAcquire(current *le.Lease, candidate string) bool {
+	now := s.clock.Now()
+	if s.expiryPredicate != nil && current != nil && current.HasHolder() && current.HolderIdentity != candidate {
+		// Only the expiry rule is replaced.
+		return s.expiryPredicate(current, now)
+	}
+	return CanAcquire(current, candidate, now, s.grace)
+}
+
+// NextAcquirableAt returns when another candidate can take the lease over:
+// renew_time + lease_duration + the grace period set with WithGracePeriod. The
+// lease is read from the primary and the result is in the time frame of the
+// stored timestamps, which the server's clock follows when they are written
+// with server time. A released lease has been acquirable since its
+// renew_time. Returns ErrLeaseNotFound if the lease does not exist.
+func (s *Store) NextAcquirableAt(ctx context.Context) (time.Time, error) {
+	if err := s.checkContext(ctx, "get"); err != nil {
+		return time.Time{}, err
+	}
+
+	lease, err := s.fetchLease(ctx, s.collection)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !lease.HasHolder() {
+		return lease.RenewTime, nil
+	}
+	return lease.RenewTime.Add(lease.LeaseDuration + s.grace), nil
+}
+
+// withExpiresAt appends to pipeline, an update pipeline, a stage recomputing
+// expires_at from the updated renew_time and lease_duration, if WithExpiresAt
+// is set.
+func (s *Store) withExpiresAt(pipeline mongo.Pipeline) mongo.Pipeline {
+	if !s.expiresAt {
+		return pipeline
+	}
+	return append(pipeline, bson.D{{Key: "$set", Value: bson.M{"expires_at": bson.M{"$add": bson.A{
+		// lease_duration is stored in nanoseconds, dates are added in milliseconds.
+		"$renew_time", bson.M{"$divide": bson.A{"$lease_duration", int64(time.Millisecond)}},
+	}}}}})
+}
+
+// expiryOperands returns the operands of a comparison of the time a stored
+// lease expires with the time it must expire before to be expired at now, or
+// at the server's current time with WithServerTimestamps. The grace period is
+// included, but not a custom expiry predicate.
+func (s *Store) expiryOperands(now time.Time) bson.A {
+	var deadline interface{} = now.Add(-s.grace)
+	if s.serverTimestamps {
+		deadline = bson.M{"$subtract": bson.A{"$$NOW", s.grace.Milliseconds()}}
+	}
+	return bson.A{
+		// lease_duration is stored in nanoseconds, dates are added in milliseconds.
+		bson.M{"$add": bson.A{"$renew_time", bson.M{"$divide": bson.A{"$lease_duration", int64(time.Millisecond)}}}},
+		deadline,
+	}
+}
+
+// expiredFilter returns a filter matching the lease only if it is expired at
+// now, or at the server's current time with WithServerTimestamps. By default
+// expiry is evaluated by the server; with a custom predicate
+// the lease is read and evaluated locally, and the filter pins the state that
+// was judged expired so that a concurrent renew makes it miss.
+func (s *Store) expiredFilter(ctx context.Context, now time.Time) (bson.M, error) {
+	if s.expiryPredicate == nil {
+		return bson.M{"$expr": bson.M{"$lt": s.expiryOperands(now)}}, nil
+	}
+
+	var doc leaseDocument
+	err := s.collection.FindOne(ctx, s.leaseFilter(), s.findOneOptions()).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return neverMatch, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !s.expiryPredicate(doc.toLease(), now) {
+		return neverMatch, nil
+	}
+
+	return bson.M{
+		"holder_identity": doc.HolderIdentity,
+		"renew_time":      doc.RenewTime,
+		"lease_duration":  doc.LeaseDuration,
+	}, nil
+}
+
+var neverMatch = bson.M{"$expr": false}
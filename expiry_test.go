@@ -0,0 +1,212 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestExpiryPredicate(t *testing.T) {
+	t.Parallel()
+
+	// Leases expire at half their duration.
+	early := func(lease *le.Lease, now time.Time) bool {
+		return lease.RenewTime.Add(lease.LeaseDuration / 2).Before(now)
+	}
+
+	ctx := context.Background()
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithExpiryPredicate(early))
+
+	now := time.Now().Truncate(time.Millisecond) // Stored with millisecond precision.
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now.Add(-time.Minute),
+		RenewTime:      now.Add(-time.Minute),
+		LeaseDuration:  90 * time.Second,
+	}
+
+	filter, err := store.expiredFilter(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, neverMatch, filter, "Missing lease should never match")
+
+	require.NoError(t, store.CreateLease(ctx, lease))
+	assert.True(t, store.IsExpired(lease), "Lease should expire at half its duration")
+
+	filter, err = store.expiredFilter(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"holder_identity": "candidate-1",
+		"renew_time":      lease.RenewTime.UTC(),
+		"lease_duration":  lease.LeaseDuration,
+	}, filter, "Expired lease should be pinned to the state read")
+
+	lease.RenewTime = now
+	require.NoError(t, store.UpdateLease(ctx, lease))
+	assert.False(t, store.IsExpired(lease))
+
+	filter, err = store.expiredFilter(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, neverMatch, filter, "Live lease should never match")
+}
+
+func TestAcquireLeaseExpiryPredicate(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	early := func(lease *le.Lease, now time.Time) bool {
+		return lease.RenewTime.Add(lease.LeaseDuration / 10).Before(now)
+	}
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithExpiryPredicate(early))
+	require.NoError(t, err, "Failed to create store")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", 2*time.Second)
+	require.NoError(t, err)
+
+	_, err = store.AcquireLease(ctx, "candidate-2", 2*time.Second)
+	require.ErrorIs(t, err, ErrLeaseHeld)
+
+	time.Sleep(300 * time.Millisecond)
+
+	acquired, err := store.AcquireLease(ctx, "candidate-2", 2*time.Second)
+	require.NoError(t, err, "Lease should be taken over once the predicate reports it expired")
+	assert.Equal(t, "candidate-2", acquired.HolderIdentity)
+
+	require.ErrorIs(t, store.DeleteLeaseIf(ctx, Expired), ErrLeaseConflict)
+	time.Sleep(300 * time.Millisecond)
+	require.NoError(t, store.DeleteLeaseIf(ctx, Expired), "Lease should be deleted once the predicate reports it expired")
+}
+
+func TestCanAcquire(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	held := func(holder string, renewedAgo time.Duration) *le.Lease {
+		return &le.Lease{
+			HolderIdentity: holder,
+			AcquireTime:    now.Add(-time.Hour),
+			RenewTime:      now.Add(-renewedAgo),
+			LeaseDuration:  10 * time.Second,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		current *le.Lease
+		grace   time.Duration
+		want    bool
+	}{
+		{name: "missing", want: true},
+		{name: "released", current: held("", 0), want: true},
+		{name: "held by candidate", current: held("candidate-1", 0), want: true},
+		{name: "expired held by candidate", current: held("candidate-1", time.Minute), want: true},
+		{name: "held by other", current: held("candidate-2", 0), want: false},
+		{name: "held by other until now", current: held("candidate-2", 10*time.Second), want: false},
+		{name: "expired held by other", current: held("candidate-2", 11*time.Second), want: true},
+		{name: "within grace", current: held("candidate-2", 11*time.Second), grace: 5 * time.Second, want: false},
+		{name: "at grace boundary", current: held("candidate-2", 15*time.Second), grace: 5 * time.Second, want: false},
+		{name: "past grace", current: held("candidate-2", 16*time.Second), grace: 5 * time.Second, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, CanAcquire(tt.current, "candidate-1", now, tt.grace))
+		})
+	}
+}
+
+func TestNextAcquirableAt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newTestStore(t, newFakeCollection(), WithGracePeriod(5*time.Second))
+
+	_, err := store.NextAcquirableAt(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+
+	now := time.Now().Truncate(time.Millisecond) // Stored with millisecond precision.
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  10 * time.Second,
+	}
+	require.NoError(t, store.CreateLease(ctx, lease))
+
+	at, err := store.NextAcquirableAt(ctx)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(now.Add(15*time.Second)), "Lease should be acquirable after renew_time + duration + grace, got %s", at)
+	assert.False(t, store.IsExpired(&le.Lease{HolderIdentity: "candidate-1", RenewTime: now.Add(-12 * time.Second), LeaseDuration: 10 * time.Second}),
+		"Lease within its grace period should not be expired")
+
+	released := *lease
+	released.HolderIdentity = ""
+	require.NoError(t, store.UpdateLease(ctx, &released))
+	at, err = store.NextAcquirableAt(ctx)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(now), "Released lease should be acquirable since its renew_time")
+}
+
+func TestServerTimestampsExpiredFilter(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewStore(Args{LeaseKey: "test-lease-key"}, WithServerTimestamps(), WithGracePeriod(time.Second))
+	require.NoError(t, err, "Failed to create store")
+	assert.True(t, store.Config().ServerTimestamps)
+
+	filter, err := store.expiredFilter(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$expr": bson.M{"$lt": bson.A{
+		bson.M{"$add": bson.A{"$renew_time", bson.M{"$divide": bson.A{"$lease_duration", int64(time.Millisecond)}}}},
+		bson.M{"$subtract": bson.A{"$$NOW", int64(1000)}},
+	}}}, filter, "Expiry should be judged against the server's time")
+}
+
+func TestServerTimestamps(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	// Both clocks are an hour off, in opposite directions.
+	newStore := func(skew time.Duration) *Store {
+		store, err := NewStore(Args{
+			LeaseCollection: collection,
+			LeaseKey:        "test-lease-key",
+		}, WithServerTimestamps(), WithClock(&steppingClock{now: time.Now().Add(skew)}))
+		require.NoError(t, err, "Failed to create store")
+		return store
+	}
+	behind := newStore(-time.Hour)
+	ahead := newStore(time.Hour)
+
+	result, err := behind.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), result.RenewTime, 5*time.Second, "The lease should carry the server's time")
+	stored, err := behind.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, stored.RenewTime, result.RenewTime, "The returned lease should carry the stored time")
+	assert.Equal(t, stored.AcquireTime, result.AcquireTime)
+
+	_, err = ahead.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.ErrorIs(t, err, ErrLeaseHeld, "A clock ahead should not see the lease as expired")
+
+	renewed, err := behind.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, result.AcquireTime, renewed.AcquireTime, "A renew should keep the acquire time")
+	assert.False(t, renewed.RenewTime.Before(result.RenewTime))
+}
@@ -0,0 +1,108 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithMaxTransitionRate tracks the leader transitions (acquisitions and
+// takeovers) written by the store and signals flapping when there are more than
+// max within window: a warning is logged and the metrics hook is told if it
+// implements FlappingObserver. With a positive cooldown, the store also refuses
+// to change the holder for cooldown after flapping was signaled, returning
+// ErrTransitionCooldown; renews are still allowed.
+//
+// Tracking costs a findAndModify per update to read the replaced lease.
+func WithMaxTransitionRate(max int, window, cooldown time.Duration) Option {
+	return func(s *Store) {
+		s.transitions = &transitionRate{max: max, window: window, cooldown: cooldown}
+	}
+}
+
+// FlappingObserver is implemented by Metrics that want to be told about
+// flapping, see WithMaxTransitionRate.
+type FlappingObserver interface {
+	// ObserveFlapping is called when the store wrote transitions leader
+	// transitions within window, more than the configured maximum.
+	ObserveFlapping(leaseKey string, transitions int, window time.Duration)
+}
+
+// transitionRate tracks the leader transitions of the last window.
+type transitionRate struct {
+	max      int
+	window   time.Duration
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	times     []time.Time // Transitions within the window, oldest first.
+	coolUntil time.Time
+}
+
+// record adds a transition at now and returns the transitions within the
+// window, and whether they exceed the maximum.
+func (r *transitionRate) record(now time.Time) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.times = append(r.times, now)
+	cutoff := now.Add(-r.window)
+	for len(r.times) > 0 && !r.times[0].After(cutoff) {
+		r.times = r.times[1:]
+	}
+	if len(r.times) <= r.max {
+		return len(r.times), false
+	}
+
+	if r.cooldown > 0 {
+		r.coolUntil = now.Add(r.cooldown)
+	}
+	return len(r.times), true
+}
+
+// coolingDown reports whether transitions are refused at now.
+func (r *transitionRate) coolingDown(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return now.Before(r.coolUntil)
+}
+
+// observeTransition reports a leader transition written by the store to the
+// metrics hook, records it and signals flapping.
+func (s *Store) observeTransition(ctx context.Context, holder string) {
+	if observer, ok := s.metrics.(TransitionObserver); ok {
+		observer.ObserveTransition(s.leaseKey, holder)
+	}
+	if s.transitions == nil {
+		return
+	}
+
+	count, flapping := s.transitions.record(s.clock.Now())
+	if !flapping {
+		return
+	}
+
+	if s.logger != nil {
+		s.logger.WarnContext(ctx, "lease leadership is flapping",
+			"lease_key", s.leaseKey,
+			"holder", holder,
+			"transitions", count,
+			"window", s.transitions.window,
+		)
+	}
+	if observer, ok := s.metrics.(FlappingObserver); ok {
+		observer.ObserveFlapping(s.leaseKey, count, s.transitions.window)
+	}
+}
+
+// coolingDown reports whether the store currently refuses to change the lease
+// holder.
+func (s *Store) coolingDown() bool {
+	return s.transitions != nil && s.transitions.coolingDown(s.clock.Now())
+}
+
+func (s *Store) errTransitionCooldown(candidate string) error {
+	return fmt.Errorf("%w: %q cannot take lease %q while leadership is flapping",
+		ErrTransitionCooldown, candidate, s.leaseKey)
+}
@@ -0,0 +1,100 @@
+package mongoleasestore
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flappingMetrics records flapping signals on top of operations.
+type flappingMetrics struct {
+	recordingMetrics
+	flapping []int
+}
+
+func (m *flappingMetrics) ObserveFlapping(_ string, transitions int, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flapping = append(m.flapping, transitions)
+}
+
+func TestMaxTransitionRate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// Leases stamped an hour ago are expired, so that they may be taken over.
+	start := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	clock := &steppingClock{now: start, step: time.Second}
+	handler := &recordingHandler{}
+	metrics := &flappingMetrics{}
+	store := newTestStore(t, newFakeCollection(),
+		WithClock(clock),
+		WithLogger(slog.New(handler)),
+		WithMetrics(metrics),
+		WithMaxTransitionRate(2, time.Minute, time.Minute),
+	)
+
+	lease := func(holder string, transitions uint32) *le.Lease {
+		now := clock.Now()
+		return &le.Lease{HolderIdentity: holder, AcquireTime: now, RenewTime: now, LeaseDuration: time.Second, LeaderTransitions: transitions}
+	}
+
+	require.NoError(t, store.CreateLease(ctx, lease("candidate-1", 0)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-1", 0)), "Renews are not transitions")
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)))
+	assert.Empty(t, metrics.flapping, "Two transitions should be tolerated")
+	assert.False(t, store.transitions.coolingDown(clock.Now()))
+
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-1", 2)))
+	assert.Equal(t, []int{3}, metrics.flapping, "Third transition should signal flapping")
+	assert.Equal(t, 1, handler.count("lease leadership is flapping"))
+	assert.True(t, store.transitions.coolingDown(clock.Now()), "Transitions should cool down")
+}
+
+func TestTransitionRateWindow(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	rate := &transitionRate{max: 1, window: time.Minute}
+
+	_, flapping := rate.record(start)
+	assert.False(t, flapping)
+	count, flapping := rate.record(start.Add(2 * time.Minute))
+	assert.False(t, flapping, "Transitions outside the window should not count")
+	assert.Equal(t, 1, count)
+	count, flapping = rate.record(start.Add(2*time.Minute + time.Second))
+	assert.True(t, flapping)
+	assert.Equal(t, 2, count)
+	assert.False(t, rate.coolingDown(start.Add(3*time.Minute)), "Without a cool-down nothing should be refused")
+}
+
+func TestTransitionCooldown(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithMaxTransitionRate(1, time.Minute, time.Minute))
+	require.NoError(t, err, "Failed to create store")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Millisecond)
+	require.NoError(t, err, "Second transition should be written and signal flapping")
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.ErrorIs(t, err, ErrTransitionCooldown, "Takeovers should be refused during the cool-down")
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err, "Renews should be allowed during the cool-down")
+}
@@ -0,0 +1,59 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// checkRenewDeadline returns ErrRenewUnreliable when WithRenewDeadlineGuard is
+// set and a renew that started at start took longer than the time left on the
+// lease it renewed, before.
+func (s *Store) checkRenewDeadline(ctx context.Context, before *leaseDocument, start time.Time) error {
+	if !s.renewDeadlineGuard || before == nil {
+		return nil
+	}
+
+	remaining := before.RenewTime.Add(before.LeaseDuration).Sub(start)
+	latency := s.clock.Now().Sub(start)
+	if latency <= remaining {
+		return nil
+	}
+
+	if s.logger != nil {
+		s.logger.WarnContext(ctx, "lease renew outlived the lease",
+			"lease_key", s.leaseKey,
+			"holder", before.HolderIdentity,
+			"latency", latency,
+			"remaining", remaining,
+		)
+	}
+
+	return fmt.Errorf("renew lease %q: took %s with %s remaining: %w", s.leaseKey, latency, remaining, ErrRenewUnreliable)
+}
+
+// checkLateRenew signals a renew of before that completed less than the late
+// renew threshold before the renewed lease would have expired, to the logger
+// and to the metrics hook if it implements LateRenewObserver.
+func (s *Store) checkLateRenew(ctx context.Context, before *leaseDocument) {
+	if s.lateRenewThreshold <= 0 || before == nil {
+		return
+	}
+
+	margin := before.RenewTime.Add(before.LeaseDuration).Sub(s.clock.Now())
+	if margin >= s.lateRenewThreshold {
+		return
+	}
+
+	if s.logger != nil {
+		s.logger.WarnContext(ctx, "lease renewed late",
+			"lease_key", s.leaseKey,
+			"holder", before.HolderIdentity,
+			"margin", margin,
+			"threshold", s.lateRenewThreshold,
+		)
+	}
+	if observer, ok := s.metrics.(LateRenewObserver); ok {
+		observer.ObserveLateRenew(s.leaseKey, before.HolderIdentity, margin)
+	}
+}
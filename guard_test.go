@@ -0,0 +1,140 @@
+package mongoleasestore
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// steppingClock advances by step on every call to Now, simulating operations
+// that take step to complete.
+type steppingClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+func TestRenewDeadlineGuard(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	start := time.Now().Truncate(time.Millisecond)
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    start,
+		RenewTime:      start,
+		LeaseDuration:  time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		latency time.Duration
+		wantErr error
+	}{
+		{name: "fast renew", latency: 100 * time.Millisecond},
+		{name: "slow renew", latency: 2 * time.Second, wantErr: ErrRenewUnreliable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &steppingClock{now: start}
+			handler := &recordingHandler{}
+			store := newTestStore(t, newFakeCollection(),
+				WithClock(clock),
+				WithLogger(slog.New(handler)),
+				WithRenewDeadlineGuard(),
+			)
+			require.NoError(t, store.CreateLease(ctx, lease))
+
+			clock.step = tt.latency
+			renewed := *lease
+			renewed.RenewTime = start.Add(tt.latency)
+			err := store.UpdateLease(ctx, &renewed)
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.Equal(t, 1, handler.count("lease renew outlived the lease"))
+			}
+		})
+	}
+}
+
+// lateRenewMetrics records late renews on top of operations.
+type lateRenewMetrics struct {
+	recordingMetrics
+	margins []time.Duration
+}
+
+func (m *lateRenewMetrics) ObserveLateRenew(_, _ string, margin time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.margins = append(m.margins, margin)
+}
+
+func TestLateRenewThreshold(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	start := time.Now().Truncate(time.Millisecond)
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    start,
+		RenewTime:      start,
+		LeaseDuration:  time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		latency time.Duration
+		late    bool
+	}{
+		{name: "timely renew", latency: 100 * time.Millisecond},
+		{name: "late renew", latency: 900 * time.Millisecond, late: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &steppingClock{now: start}
+			handler := &recordingHandler{}
+			metrics := &lateRenewMetrics{}
+			store := newTestStore(t, newFakeCollection(),
+				WithClock(clock),
+				WithLogger(slog.New(handler)),
+				WithMetrics(metrics),
+				WithLateRenewThreshold(300*time.Millisecond),
+			)
+			require.NoError(t, store.CreateLease(ctx, lease))
+
+			clock.step = tt.latency
+			renewed := *lease
+			renewed.RenewTime = start.Add(tt.latency)
+			require.NoError(t, store.UpdateLease(ctx, &renewed), "Late renews should still succeed")
+
+			if !tt.late {
+				assert.Empty(t, metrics.margins)
+				assert.Zero(t, handler.count("lease renewed late"))
+				return
+			}
+			require.Len(t, metrics.margins, 1, "Late renew should be signaled")
+			assert.Equal(t, time.Second-tt.latency, metrics.margins[0])
+			assert.Equal(t, 1, handler.count("lease renewed late"))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// HealthStatus is the outcome of HealthCheck.
+type HealthStatus struct {
+	// Reachable reports whether the primary answered a ping.
+	Reachable bool
+	// PingLatency is the round trip of the ping, zero if it failed.
+	PingLatency time.Duration
+	// Readable reports whether the lease could be read.
+	Readable bool
+	// Writable reports whether the lease collection accepted a write.
+	Writable bool
+}
+
+// Healthy reports whether every check passed.
+func (h HealthStatus) Healthy() bool {
+	return h.Reachable && h.Readable && h.Writable
+}
+
+// HealthCheck pings the primary and checks that the lease collection can be
+// read and written, for readiness probes. The write is an update matching no
+// document, which the server authorizes and routes like a lease write but
+// never applies. The status reports every check; the error joins the failures.
+func (s *Store) HealthCheck(ctx context.Context) (HealthStatus, error) {
+	var status HealthStatus
+	collection := s.mongoCollection()
+	if collection == nil {
+		return status, errors.New("health check: the store is not backed by a MongoDB collection")
+	}
+
+	var errs []error
+	sent := time.Now()
+	if err := collection.Database().Client().Ping(ctx, readpref.Primary()); err != nil {
+		errs = append(errs, fmt.Errorf("health check: ping: %w", err))
+	} else {
+		status.Reachable = true
+		status.PingLatency = time.Since(sent)
+	}
+
+	err := s.collection.FindOne(ctx, s.leaseFilter(), s.findOneOptions()).Err()
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		errs = append(errs, fmt.Errorf("health check: read lease %q: %w", s.leaseKey, err))
+	} else {
+		status.Readable = true
+	}
+
+	filter := s.leaseFilter()
+	for k, v := range neverMatch {
+		filter[k] = v
+	}
+	_, err = s.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"health_check": true}}, s.updateOptions())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("health check: write lease %q: %w", s.leaseKey, err))
+	} else {
+		status.Writable = true
+	}
+
+	return status, errors.Join(errs...)
+}
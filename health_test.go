@@ -0,0 +1,51 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	status, err := store.HealthCheck(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.Healthy(), "A reachable deployment should be healthy")
+	assert.Positive(t, status.PingLatency)
+
+	count, err := collection.CountDocuments(ctx, bson.M{})
+	require.NoError(t, err)
+	assert.Zero(t, count, "The write check should not write anything")
+
+	require.NoError(t, store.Close(ctx))
+	status, err = store.HealthCheck(ctx)
+	require.ErrorIs(t, err, ErrStoreClosed)
+	assert.True(t, status.Reachable, "The caller's client is still connected")
+	assert.False(t, status.Readable)
+	assert.False(t, status.Writable)
+	assert.False(t, status.Healthy())
+}
+
+func TestHealthCheckWithoutCollection(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, newFakeCollection())
+
+	status, err := store.HealthCheck(context.Background())
+	require.Error(t, err, "A store not backed by MongoDB cannot be checked")
+	assert.False(t, status.Healthy())
+}
@@ -0,0 +1,66 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// StartHeartbeat logs a summary of the lease (current holder and time until
+// expiry) every interval until ctx is canceled or the store is closed. It is a non-blocking call and
+// returns a channel that is closed once the heartbeat has stopped. Nothing is
+// started when the store has no logger. interval must be positive.
+func (s *Store) StartHeartbeat(ctx context.Context, interval time.Duration) (<-chan struct{}, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("heartbeat of lease %q: interval %v must be positive", s.leaseKey, interval)
+	}
+
+	done := make(chan struct{})
+	if s.logger == nil {
+		close(done)
+		return done, nil
+	}
+
+	ctx, cancel := s.untilClosed(ctx)
+	go func() {
+		defer close(done)
+		defer cancel()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.logHeartbeat(ctx)
+			}
+		}
+	}()
+
+	return done, nil
+}
+
+func (s *Store) logHeartbeat(ctx context.Context) {
+	lease, err := s.GetLease(ctx)
+	if err != nil {
+		if errors.Is(err, le.ErrLeaseNotFound) {
+			s.logger.InfoContext(ctx, "lease heartbeat", "lease_key", s.leaseKey, "holder", "")
+			return
+		}
+		// Closing the store also stops the heartbeat.
+		if ctx.Err() == nil && !errors.Is(err, ErrStoreClosed) {
+			s.logger.WarnContext(ctx, "lease heartbeat failed", "lease_key", s.leaseKey, "error", err)
+		}
+		return
+	}
+
+	expiresIn := lease.RenewTime.Add(lease.LeaseDuration).Sub(s.clock.Now())
+	s.logger.InfoContext(ctx, "lease heartbeat",
+		"lease_key", s.leaseKey,
+		"holder", lease.HolderIdentity,
+		"expires_in", expiresIn,
+	)
+}
@@ -0,0 +1,144 @@
+package mongoleasestore
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+
+	logs := &recordingHandler{}
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithLogger(slog.New(logs)))
+	require.NoError(t, err, "Failed to create store")
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(context.Background(), &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done, err := store.StartHeartbeat(ctx, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return logs.count("lease heartbeat") > 0
+	}, 2*time.Second, 50*time.Millisecond, "At least one heartbeat should be logged")
+
+	record, ok := logs.last("lease heartbeat")
+	require.True(t, ok)
+	assert.Equal(t, "candidate-1", record["holder"])
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond, "Heartbeat should stop on cancel")
+}
+
+func TestHeartbeatStopsOnClose(t *testing.T) {
+	t.Parallel()
+
+	logs := &recordingHandler{}
+	store := newTestStore(t, newFakeCollection(), WithLogger(slog.New(logs)))
+	done, err := store.StartHeartbeat(context.Background(), 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return logs.count("lease heartbeat") > 0
+	}, 2*time.Second, 10*time.Millisecond, "At least one heartbeat should be logged")
+
+	require.NoError(t, store.Close(context.Background()))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "Close should stop the heartbeat")
+	}
+	assert.Zero(t, logs.count("lease heartbeat failed"), "A closed store should not be polled")
+}
+
+func TestHeartbeatInvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, newFakeCollection(), WithLogger(slog.New(&recordingHandler{})))
+	_, err := store.StartHeartbeat(context.Background(), 0)
+	require.Error(t, err, "A non-positive interval should be rejected")
+}
+
+// recordingHandler is a slog.Handler that keeps every record in memory.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []recordedLog
+}
+
+type recordedLog struct {
+	message string
+	attrs   map[string]any
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make(map[string]any)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, recordedLog{message: r.Message, attrs: attrs})
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) count(message string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, r := range h.records {
+		if r.message == message {
+			n++
+		}
+	}
+	return n
+}
+
+func (h *recordingHandler) last(message string) (map[string]any, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if h.records[i].message == message {
+			return h.records[i].attrs, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,169 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Reasons describing why the lease changed.
+const (
+	// ReasonAcquire means a candidate took a lease that had no holder.
+	ReasonAcquire = "acquire"
+	// ReasonRenew means the holder extended its own lease.
+	ReasonRenew = "renew"
+	// ReasonTakeover means a candidate took the lease from another holder.
+	ReasonTakeover = "takeover"
+	// ReasonRelease means the holder gave up the lease.
+	ReasonRelease = "release"
+	// ReasonDelete means the lease was deleted.
+	ReasonDelete = "delete"
+	// ReasonExtend means an admin pushed the lease's expiry forward.
+	ReasonExtend = "extend"
+)
+
+// HistoryRecord is an entry of the lease history kept in the collection set by
+// WithHistoryCollection.
+type HistoryRecord struct {
+	LeaseKey          string        `bson:"lease_key"`
+	Reason            string        `bson:"reason"`
+	HolderIdentity    string        `bson:"holder_identity"`
+	PreviousHolder    string        `bson:"previous_holder,omitempty"` // Empty if there was none.
+	AcquireTime       time.Time     `bson:"acquire_time"`
+	RenewTime         time.Time     `bson:"renew_time"`
+	LeaseDuration     time.Duration `bson:"lease_duration"`
+	LeaderTransitions uint64        `bson:"leader_transitions"` // The stored count, past 2^32 included.
+	RecordedAt        time.Time     `bson:"recorded_at"`
+}
+
+// GetLeaseHistory returns up to limit of the most recent history records of
+// the lease, newest first. A limit of zero returns the whole history. Returns
+// ErrHistoryDisabled if the store was created without WithHistoryCollection.
+func (s *Store) GetLeaseHistory(ctx context.Context, limit int64) ([]HistoryRecord, error) {
+	if err := s.checkContext(ctx, "get history of"); err != nil {
+		return nil, err
+	}
+	if s.history == nil {
+		return nil, fmt.Errorf("get history of lease %q: %w", s.leaseKey, ErrHistoryDisabled)
+	}
+
+	opts := s.findOptions().
+		SetSort(bson.D{{Key: "recorded_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := s.history.Find(ctx, bson.M{"lease_key": s.leaseKey}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []HistoryRecord{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// recordHistory appends a history record of a change of the lease from
+// previous, its holder before, to lease, whose stored transition count is
+// transitions, logging instead of failing.
+func (s *Store) recordHistory(ctx context.Context, reason, previous string, lease *le.Lease, transitions uint64) {
+	if s.historyTransitionsOnly && reason == ReasonRenew {
+		return
+	}
+
+	record := HistoryRecord{
+		LeaseKey:          s.leaseKey,
+		Reason:            reason,
+		HolderIdentity:    lease.HolderIdentity,
+		PreviousHolder:    previous,
+		AcquireTime:       lease.AcquireTime,
+		RenewTime:         lease.RenewTime,
+		LeaseDuration:     lease.LeaseDuration,
+		LeaderTransitions: transitions,
+		RecordedAt:        s.clock.Now(),
+	}
+
+	if _, err := s.history.InsertOne(ctx, record, s.insertOneOptions()); err != nil && s.logger != nil {
+		s.logger.WarnContext(ctx, "failed to record lease history",
+			"lease_key", s.leaseKey,
+			"reason", reason,
+			"error", err,
+		)
+	}
+}
+
+// updateReason classifies an update from its pre-image.
+func updateReason(before *leaseDocument, after *le.Lease) string {
+	switch {
+	case after.HolderIdentity == "":
+		return ReasonRelease
+	case before == nil || before.HolderIdentity == "":
+		return ReasonAcquire
+	case before.HolderIdentity == after.HolderIdentity:
+		return ReasonRenew
+	default:
+		return ReasonTakeover
+	}
+}
+
+// WithHistoryTransitionsOnly limits the history set with
+// WithHistoryCollection to changes of the holder: acquisitions, takeovers,
+// releases and deletes. Renews, which make up most writes of a stable leader,
+// are not recorded.
+func WithHistoryTransitionsOnly() Option {
+	return func(s *Store) {
+		s.historyTransitionsOnly = true
+	}
+}
+
+// WithHistoryRetention makes NewStore ensure a TTL index on recorded_at in the
+// history collection set with WithHistoryCollection, so that MongoDB deletes
+// history records retention after they were recorded. retention is rounded
+// up to the second. An existing index with another retention is resized. A
+// retention <= 0 leaves the history collection alone.
+func WithHistoryRetention(retention time.Duration) Option {
+	return func(s *Store) {
+		s.historyRetention = max(retention, 0)
+	}
+}
+
+// ensureHistoryTTLIndex ensures the TTL index requested by
+// WithHistoryRetention, if any.
+func (s *Store) ensureHistoryTTLIndex() error {
+	if s.history == nil || s.historyRetention <= 0 || s.readOnly {
+		return nil
+	}
+
+	timeout := ttlIndexTimeout
+	if s.timeout > 0 {
+		timeout = s.timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	expireAfter := int32((s.historyRetention + time.Second - 1) / time.Second)
+	_, err := s.history.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "recorded_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(expireAfter),
+	})
+	if hasErrorCode(err, indexOptionsConflictCode) {
+		// The index exists with another retention.
+		err = s.history.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: s.history.Name()},
+			{Key: "index", Value: bson.D{
+				{Key: "keyPattern", Value: bson.D{{Key: "recorded_at", Value: 1}}},
+				{Key: "expireAfterSeconds", Value: expireAfter},
+			}},
+		}).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("ensure history ttl index on %s: %w", namespace(s.history), err)
+	}
+	return nil
+}
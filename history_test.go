@@ -0,0 +1,176 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestLeaseHistoryDisabled(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, newFakeCollection())
+	_, err := store.GetLeaseHistory(context.Background(), 0)
+	require.ErrorIs(t, err, ErrHistoryDisabled)
+}
+
+func TestLeaseHistory(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	database := mongoClient.Database(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: database.Collection(t.Name()),
+		LeaseKey:        "test-lease-key",
+	}, WithHistoryCollection(database.Collection("history")))
+	require.NoError(t, err, "Failed to create store")
+
+	lease := func(holder string, transitions uint32) *le.Lease {
+		// Expired, so that candidate-2 may take it over.
+		now := time.Now().Add(-time.Minute)
+		return &le.Lease{
+			HolderIdentity:    holder,
+			AcquireTime:       now,
+			RenewTime:         now,
+			LeaseDuration:     time.Second,
+			LeaderTransitions: transitions,
+		}
+	}
+
+	require.NoError(t, store.CreateLease(ctx, lease("candidate-1", 0)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-1", 0)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)))
+	require.NoError(t, store.UpdateLease(ctx, lease("", 1)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-3", 2)))
+
+	records, err := store.GetLeaseHistory(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, records, 5, "Every mutation should be recorded")
+
+	reasons := make([]string, 0, len(records))
+	holders := make([]string, 0, len(records))
+	for _, r := range records {
+		reasons = append(reasons, r.Reason)
+		holders = append(holders, r.HolderIdentity)
+	}
+	assert.Equal(t, []string{ReasonAcquire, ReasonRelease, ReasonTakeover, ReasonRenew, ReasonAcquire}, reasons)
+	assert.Equal(t, []string{"candidate-3", "", "candidate-2", "candidate-1", "candidate-1"}, holders)
+	assert.Equal(t, "candidate-2", records[1].PreviousHolder, "Releases should record the previous holder")
+	assert.Equal(t, "candidate-1", records[2].PreviousHolder, "Takeovers should record the previous holder")
+	assert.EqualValues(t, 2, records[0].LeaderTransitions)
+
+	recent, err := store.GetLeaseHistory(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, records[:2], recent, "Limit should return the most recent records")
+}
+
+func TestLeaseHistoryTouchAndExtend(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	database := mongoClient.Database(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: database.Collection(t.Name()),
+		LeaseKey:        "test-lease-key",
+	}, WithHistoryCollection(database.Collection("history")), WithAdminOperations())
+	require.NoError(t, err, "Failed to create store")
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}))
+	require.NoError(t, store.Touch(ctx, "candidate-1"))
+	require.NoError(t, store.AdminExtend(ctx, time.Minute))
+
+	records, err := store.GetLeaseHistory(ctx, 0)
+	require.NoError(t, err)
+	reasons := make([]string, 0, len(records))
+	for _, r := range records {
+		reasons = append(reasons, r.Reason)
+	}
+	assert.Equal(t, []string{ReasonExtend, ReasonRenew, ReasonAcquire}, reasons, "Touches and extensions should be recorded")
+	assert.Equal(t, "candidate-1", records[0].HolderIdentity)
+	assert.True(t, records[0].RenewTime.Equal(records[1].RenewTime.Add(time.Minute)), "Extensions should record the extended renew time")
+}
+
+func TestLeaseHistoryTransitionsOnly(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	database := mongoClient.Database(t.Name())
+	ctx := context.Background()
+
+	history := database.Collection("history")
+	store, err := NewStore(Args{
+		LeaseCollection: database.Collection(t.Name()),
+		LeaseKey:        "test-lease-key",
+	}, WithHistoryCollection(history), WithHistoryTransitionsOnly(), WithHistoryRetention(90*time.Minute))
+	require.NoError(t, err, "Failed to create store")
+
+	lease := func(holder string, transitions uint32) *le.Lease {
+		// Expired, so that candidate-2 may take it over.
+		now := time.Now().Add(-time.Minute)
+		return &le.Lease{
+			HolderIdentity:    holder,
+			AcquireTime:       now,
+			RenewTime:         now,
+			LeaseDuration:     time.Second,
+			LeaderTransitions: transitions,
+		}
+	}
+
+	require.NoError(t, store.CreateLease(ctx, lease("candidate-1", 0)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-1", 0)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)))
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)))
+	require.NoError(t, store.DeleteLease(ctx))
+
+	records, err := store.GetLeaseHistory(ctx, 0)
+	require.NoError(t, err)
+	reasons := make([]string, 0, len(records))
+	for _, r := range records {
+		reasons = append(reasons, r.Reason)
+	}
+	assert.Equal(t, []string{ReasonDelete, ReasonTakeover, ReasonAcquire}, reasons, "Renews should not be recorded")
+	assert.Equal(t, "candidate-2", records[0].PreviousHolder, "Deletes should record the deleted holder")
+	assert.EqualValues(t, 1, records[0].LeaderTransitions, "Deletes should record the deleted count")
+	assert.Equal(t, "candidate-1", records[1].PreviousHolder)
+	assert.EqualValues(t, 1, records[1].LeaderTransitions)
+
+	var indexes []bson.M
+	cursor, err := history.Indexes().List(ctx)
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(ctx, &indexes))
+	ttl := func() any {
+		for _, index := range indexes {
+			if index["name"] == "recorded_at_1" {
+				return index["expireAfterSeconds"]
+			}
+		}
+		return nil
+	}
+	assert.EqualValues(t, 5400, ttl(), "Records should expire after the retention")
+
+	// A new retention resizes the index.
+	_, err = NewStore(Args{
+		LeaseCollection: database.Collection(t.Name()),
+		LeaseKey:        "test-lease-key",
+	}, WithHistoryCollection(history), WithHistoryRetention(time.Hour))
+	require.NoError(t, err, "Failed to create store")
+	cursor, err = history.Indexes().List(ctx)
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(ctx, &indexes))
+	assert.EqualValues(t, 3600, ttl())
+}
@@ -0,0 +1,182 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureHolderIndex creates, if missing, an index on holder_identity and
+// renew_time so that FindLeasesByHolder, and queries on the leases of a holder
+// by age, do not scan the collection. It is idempotent.
+func (s *Store) EnsureHolderIndex(ctx context.Context) error {
+	return s.ensureIndex(ctx, "holder", mongo.IndexModel{
+		Keys: bson.D{{Key: "holder_identity", Value: 1}, {Key: "renew_time", Value: 1}},
+	})
+}
+
+// EnsureExpiryIndex creates, if missing, an index on renew_time so that range
+// queries over lease ages do not scan the collection. It is idempotent.
+//
+// Expiry is renew_time + lease_duration, which the store evaluates with an
+// aggregation expression ($expr) that can only use the index once a plain
+// bound on renew_time narrows it down: a lease expired at t was renewed
+// before t, so such queries should add {renew_time: {$lt: t}}. Collections
+// with many leases of varying durations are better served by the expires_at
+// field maintained with WithExpiresAt, see EnsureExpiresAtIndex.
+func (s *Store) EnsureExpiryIndex(ctx context.Context) error {
+	return s.ensureIndex(ctx, "expiry", mongo.IndexModel{Keys: bson.D{{Key: "renew_time", Value: 1}}})
+}
+
+// EnsureExpiresAtIndex creates, if missing, a TTL index on the expires_at
+// field maintained with WithExpiresAt, so that MongoDB deletes leases
+// expireAfter past their expiry. The index also serves range queries on
+// expires_at. expireAfter is rounded down to the second, and should exceed the
+// grace period (see WithGracePeriod) so that only leases nobody can still
+// renew are deleted. It is idempotent, but fails if the index exists with
+// another expireAfter.
+func (s *Store) EnsureExpiresAtIndex(ctx context.Context, expireAfter time.Duration) error {
+	if !s.expiresAt {
+		return errors.New("ensure expires_at index: WithExpiresAt is not set")
+	}
+	if expireAfter < 0 {
+		return fmt.Errorf("ensure expires_at index: negative expiry %s", expireAfter)
+	}
+
+	return s.ensureIndex(ctx, "expires_at", mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(expireAfter / time.Second)),
+	})
+}
+
+// indexOptionsConflictCode is the server error code of an index created with
+// the key pattern of an existing one but other options.
+const indexOptionsConflictCode = 85
+
+// EnsureTTLIndex creates, if missing, a TTL index on renew_time so that
+// MongoDB deletes abandoned leases instead of keeping them forever. The expiry
+// is sized from the stored lease: its duration plus the grace period (see
+// WithGracePeriod), rounded up to the second, so that only leases nobody can
+// still renew are deleted. Without a stored lease there is nothing to size it
+// from, nor to delete, and nothing is done: call it again once the lease
+// exists. Called after the lease duration changed, it resizes the index.
+//
+// The TTL index also serves as the index of EnsureExpiryIndex, which cannot be
+// combined with it. Leases of other keys in the collection are deleted with
+// the same expiry; use EnsureExpiresAtIndex for leases of varying durations.
+func (s *Store) EnsureTTLIndex(ctx context.Context) error {
+	lease, err := s.fetchLease(ctx, s.collection)
+	if errors.Is(err, le.ErrLeaseNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ensure ttl index: %w", err)
+	}
+
+	expireAfter := int32((lease.LeaseDuration + s.grace + time.Second - 1) / time.Second)
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "renew_time", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(expireAfter),
+	}
+	err = s.ensureIndex(ctx, "ttl", index)
+	if !hasErrorCode(err, indexOptionsConflictCode) {
+		return err
+	}
+
+	// The index exists with another expiry, or is not a TTL index.
+	collection := s.mongoCollection()
+	err = collection.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection.Name()},
+		{Key: "index", Value: bson.D{
+			{Key: "keyPattern", Value: bson.D{{Key: "renew_time", Value: 1}}},
+			{Key: "expireAfterSeconds", Value: expireAfter},
+		}},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("resize ttl index on %s: %w", namespace(collection), err)
+	}
+	s.indexes.add(index)
+	return nil
+}
+
+// ttlIndexTimeout bounds the creation of the TTL index by NewStore, unless
+// WithOperationTimeout says otherwise.
+const ttlIndexTimeout = 30 * time.Second
+
+// ensureTTLIndex ensures the TTL index requested by Args.ExpireAfter, if any.
+func (s *Store) ensureTTLIndex() error {
+	if s.expireAfter <= 0 || s.readOnly {
+		return nil
+	}
+
+	timeout := ttlIndexTimeout
+	if s.timeout > 0 {
+		timeout = s.timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return s.EnsureExpiresAtIndex(ctx, s.expireAfter)
+}
+
+// ensureIndex creates index unless it exists already.
+func (s *Store) ensureIndex(ctx context.Context, kind string, index mongo.IndexModel) error {
+	collection := s.mongoCollection()
+	if collection == nil {
+		return fmt.Errorf("ensure %s index: the store is not backed by a MongoDB collection", kind)
+	}
+	if s.encoder != nil {
+		return fmt.Errorf("ensure %s index: %w", kind, ErrCustomDocument)
+	}
+	if s.readOnly {
+		return fmt.Errorf("ensure %s index: %w", kind, ErrReadOnly)
+	}
+
+	// Creating an index identical to an existing one is a no-op.
+	if _, err := collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("ensure %s index on %s: %w", kind, namespace(collection), err)
+	}
+	s.indexes.add(index)
+	return nil
+}
+
+// ensuredIndexes records the indexes created through the store, so that they
+// can be restored after a drop, see WithEnsureCollection. Indexes are keyed by
+// key pattern, of which a collection has one index: the last one created or
+// modified is kept.
+type ensuredIndexes struct {
+	mu      sync.Mutex
+	indexes map[string]mongo.IndexModel
+}
+
+func (e *ensuredIndexes) add(index mongo.IndexModel) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.indexes == nil {
+		e.indexes = make(map[string]mongo.IndexModel)
+	}
+	e.indexes[fmt.Sprint(index.Keys)] = index
+}
+
+// all returns the recorded indexes, sorted by key pattern.
+func (e *ensuredIndexes) all() []mongo.IndexModel {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := slices.Sorted(maps.Keys(e.indexes))
+	indexes := make([]mongo.IndexModel, 0, len(keys))
+	for _, key := range keys {
+		indexes = append(indexes, e.indexes[key])
+	}
+	return indexes
+}
@@ -0,0 +1,185 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestEnsureIndexes(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	for range 2 {
+		require.NoError(t, store.EnsureHolderIndex(ctx), "Ensuring the holder index should be idempotent")
+		require.NoError(t, store.EnsureExpiryIndex(ctx), "Ensuring the expiry index should be idempotent")
+	}
+
+	info, err := store.DescribeCollection(ctx)
+	require.NoError(t, err)
+	keys := make([]bson.D, 0, len(info.Indexes))
+	for _, index := range info.Indexes {
+		keys = append(keys, index.Keys)
+	}
+	assert.ElementsMatch(t, []bson.D{
+		{{Key: "_id", Value: int32(1)}},
+		{{Key: "holder_identity", Value: int32(1)}, {Key: "renew_time", Value: int32(1)}},
+		{{Key: "renew_time", Value: int32(1)}},
+	}, keys)
+}
+
+func TestExpiresAt(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	// Run the TTL monitor every second instead of every minute.
+	err := mongoClient.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "setParameter", Value: 1},
+		{Key: "ttlMonitorSleepSecs", Value: 1},
+	}).Err()
+	require.NoError(t, err)
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithExpiresAt(), WithAdminOperations())
+	require.NoError(t, err, "Failed to create store")
+
+	assertExpiresAt := func(msg string) {
+		t.Helper()
+		var doc leaseDocument
+		require.NoError(t, collection.FindOne(ctx, bson.M{"_id": "test-lease-key"}).Decode(&doc))
+		require.NotNil(t, doc.ExpiresAt, msg)
+		assert.True(t, doc.RenewTime.Add(doc.LeaseDuration).Equal(*doc.ExpiresAt), msg)
+	}
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Second)
+	require.NoError(t, err)
+	assertExpiresAt("Acquired lease should have expires_at")
+
+	require.NoError(t, store.Touch(ctx, "candidate-1"))
+	assertExpiresAt("Touched lease should have expires_at")
+
+	require.NoError(t, store.AdminExtend(ctx, time.Minute))
+	assertExpiresAt("Extended lease should have expires_at")
+
+	lease, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	lease.RenewTime = time.Now().Truncate(time.Millisecond)
+	lease.LeaseDuration = 100 * time.Millisecond
+	require.NoError(t, store.UpdateLease(ctx, lease))
+	assertExpiresAt("Updated lease should have expires_at")
+
+	require.NoError(t, store.EnsureExpiresAtIndex(ctx, 0))
+	require.NoError(t, store.EnsureExpiresAtIndex(ctx, 0), "Ensuring the index should be idempotent")
+	require.Eventually(t, func() bool {
+		_, err := store.GetLease(ctx)
+		return errors.Is(err, le.ErrLeaseNotFound)
+	}, 10*time.Second, 100*time.Millisecond, "Expired lease should be deleted by the TTL index")
+}
+
+func TestArgsExpireAfter(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	args := Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+		ExpireAfter:     time.Hour,
+	}
+	_, err := NewStore(args)
+	require.NoError(t, err, "Failed to create store")
+	store, err := NewStore(args)
+	require.NoError(t, err, "Ensuring the TTL index should be idempotent")
+	assert.True(t, store.Config().ExpiresAt)
+
+	info, err := store.DescribeCollection(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, info.TTLIndex, "TTL index should be created")
+	assert.Equal(t, bson.D{{Key: "expires_at", Value: int32(1)}}, info.TTLIndex.Keys)
+	require.NotNil(t, info.TTLIndex.ExpireAfter)
+	assert.Equal(t, time.Hour, *info.TTLIndex.ExpireAfter)
+
+	now := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+	var doc leaseDocument
+	require.NoError(t, collection.FindOne(ctx, bson.M{"_id": "test-lease-key"}).Decode(&doc))
+	require.NotNil(t, doc.ExpiresAt, "Created lease should have expires_at")
+	assert.True(t, now.Add(time.Second).Equal(*doc.ExpiresAt))
+}
+
+func TestArgsExpireAfterWithoutCollection(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewStore(Args{LeaseKey: "test-lease-key", ExpireAfter: time.Hour})
+	require.Error(t, err, "The TTL index needs a collection")
+}
+
+func TestEnsureTTLIndex(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithGracePeriod(500*time.Millisecond))
+	require.NoError(t, err, "Failed to create store")
+
+	ttlIndex := func() *IndexInfo {
+		t.Helper()
+		info, err := store.DescribeCollection(ctx)
+		require.NoError(t, err)
+		return info.TTLIndex
+	}
+
+	require.NoError(t, store.EnsureTTLIndex(ctx), "A missing lease should be tolerated")
+	assert.Nil(t, ttlIndex(), "Nothing should be created without a lease to size it from")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Second)
+	require.NoError(t, err)
+	for range 2 {
+		require.NoError(t, store.EnsureTTLIndex(ctx), "Ensuring the TTL index should be idempotent")
+	}
+	index := ttlIndex()
+	require.NotNil(t, index, "TTL index should be created")
+	assert.Equal(t, bson.D{{Key: "renew_time", Value: int32(1)}}, index.Keys)
+	require.NotNil(t, index.ExpireAfter)
+	assert.Equal(t, 2*time.Second, *index.ExpireAfter, "Expiry should cover the duration and grace period")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, store.EnsureTTLIndex(ctx))
+	index = ttlIndex()
+	require.NotNil(t, index)
+	require.NotNil(t, index.ExpireAfter)
+	assert.Equal(t, time.Minute+time.Second, *index.ExpireAfter, "A longer lease should resize the index")
+}
@@ -0,0 +1,228 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// WithLeadership acquires the lease for candidate with AcquireLease and runs fn
+// while holding it, then releases the lease, whether fn failed or not. fn is
+// not run if the lease cannot be acquired.
+//
+// The lease is renewed with Touch every third of duration, which also checks
+// that candidate still holds it. If the lease is lost, or could not be renewed
+// before it would expire, the context passed to fn is canceled and
+// ErrLeaseLost is returned along with fn's error; fn must stop its work
+// promptly then, as another candidate may already be leading. Closing the
+// store cancels that context too. duration must be positive.
+func (s *Store) WithLeadership(ctx context.Context, candidate string, duration time.Duration, fn func(ctx context.Context) error) error {
+	if duration <= 0 {
+		return fmt.Errorf("lead lease %q for %q: duration %v must be positive", s.leaseKey, candidate, duration)
+	}
+	if _, err := s.AcquireLease(ctx, candidate, duration); err != nil {
+		return err
+	}
+
+	runCtx, cancel := s.untilClosed(ctx)
+	defer cancel()
+	lost := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := s.keepLeadership(runCtx, candidate, duration); err != nil {
+			lost <- err
+			cancel()
+		}
+	}()
+
+	err := fn(runCtx)
+	cancel()
+	<-done
+
+	// Released with a context that outlives ctx, so that a canceled caller does
+	// not leave the lease to expire.
+	releaseCtx, cancelRelease := context.WithTimeout(context.WithoutCancel(ctx), duration)
+	defer cancelRelease()
+	if releaseErr := s.releaseLease(releaseCtx, candidate); releaseErr != nil && s.logger != nil {
+		s.logger.WarnContext(ctx, "lease release failed",
+			"lease_key", s.leaseKey,
+			"holder", candidate,
+			"error", releaseErr,
+		)
+	}
+
+	select {
+	case lostErr := <-lost:
+		return errors.Join(lostErr, err)
+	default:
+		return err
+	}
+}
+
+// keepLeadership renews the lease held by candidate until ctx is done. Returns
+// an error wrapping ErrLeaseLost once candidate lost the lease or failed to
+// renew it in time.
+func (s *Store) keepLeadership(ctx context.Context, candidate string, duration time.Duration) error {
+	interval := max(duration/3, 1)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	renewed := s.clock.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		err := s.Touch(ctx, candidate)
+		switch {
+		case err == nil, errors.Is(err, ErrRenewTooSoon):
+			renewed = s.clock.Now()
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, ErrLeaseLost):
+			return err
+		case s.clock.Now().Add(interval).After(renewed.Add(duration)):
+			// The lease would expire before the next attempt.
+			return fmt.Errorf("renew lease %q for %q: %w: %w", s.leaseKey, candidate, ErrLeaseLost, err)
+		}
+	}
+}
+
+// releaseLease gives up the lease if holder still holds it, keeping its other
+// fields as a release by UpdateLease does.
+func (s *Store) releaseLease(ctx context.Context, holder string) error {
+	lease, err := s.fetchLease(ctx, s.collection)
+	if err != nil {
+		return err
+	}
+	if lease.HolderIdentity != holder {
+		return nil
+	}
+
+	released := *lease
+	released.HolderIdentity = ""
+	err = s.UpdateLeaseCAS(ctx, lease.RenewTime, &released)
+	if errors.Is(err, ErrLeaseConflict) || errors.Is(err, le.ErrLeaseNotFound) {
+		// Taken or deleted in between: not ours to release anymore.
+		return nil
+	}
+	return err
+}
+
+// LeadershipSession acquires and renews a lease in the background. It is
+// started with StartAutoRenew.
+type LeadershipSession struct {
+	store     *Store
+	candidate string
+	duration  time.Duration
+	cancel    context.CancelFunc
+	done      chan struct{}
+	stopOnce  sync.Once
+	stopErr   error
+
+	mu          sync.Mutex
+	leaderUntil time.Time // Zero when not leading.
+}
+
+// StartAutoRenew starts a session trying to acquire the lease for candidate
+// with AcquireLease every renewInterval, which renews it once held, until the
+// session is stopped, ctx is done or the store is closed. renewInterval must be
+// positive and shorter than leaseDuration.
+//
+// A session is leader from an acquisition until another candidate holds the
+// lease, or until leaseDuration after the last successful attempt if the
+// lease cannot be renewed in the meantime. Stop the session to release the
+// lease; if ctx is done first, the lease is left to expire.
+func (s *Store) StartAutoRenew(ctx context.Context, candidate string, leaseDuration, renewInterval time.Duration) (*LeadershipSession, error) {
+	if renewInterval <= 0 || renewInterval >= leaseDuration {
+		return nil, fmt.Errorf("auto-renew lease %q for %q: renew interval %v must be positive and shorter than the lease duration %v",
+			s.leaseKey, candidate, renewInterval, leaseDuration)
+	}
+
+	runCtx, cancel := s.untilClosed(ctx)
+	session := &LeadershipSession{
+		store:     s,
+		candidate: candidate,
+		duration:  leaseDuration,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	go session.run(runCtx, renewInterval)
+
+	return session, nil
+}
+
+// IsLeader reports whether the session currently holds the lease.
+func (l *LeadershipSession) IsLeader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.store.clock.Now().Before(l.leaderUntil)
+}
+
+// Stop stops renewing the lease and releases it if the session still holds
+// it. Later calls return the result of the first.
+func (l *LeadershipSession) Stop() error {
+	l.stopOnce.Do(func() {
+		l.cancel()
+		<-l.done
+		l.setLeaderUntil(time.Time{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.duration)
+		defer cancel()
+		l.stopErr = l.store.releaseLease(ctx, l.candidate)
+	})
+	return l.stopErr
+}
+
+// run attempts an acquisition every interval, starting immediately, until ctx
+// is done.
+func (l *LeadershipSession) run(ctx context.Context, interval time.Duration) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		l.attempt(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// attempt acquires or renews the lease once and updates the leadership state.
+func (l *LeadershipSession) attempt(ctx context.Context) {
+	start := l.store.clock.Now()
+	_, err := l.store.AcquireLease(ctx, l.candidate, l.duration)
+	switch {
+	case err == nil:
+		l.setLeaderUntil(start.Add(l.duration))
+	case ctx.Err() != nil, errors.Is(err, ErrRenewTooSoon):
+		// Stopping, or renewed recently enough.
+	case errors.Is(err, ErrLeaseHeld), errors.Is(err, ErrLeaseLost):
+		l.setLeaderUntil(time.Time{})
+	default:
+		// Still leading until the last renewal expires.
+		if l.store.logger != nil {
+			l.store.logger.WarnContext(ctx, "lease auto-renew failed",
+				"lease_key", l.store.leaseKey,
+				"holder", l.candidate,
+				"error", err,
+			)
+		}
+	}
+}
+
+func (l *LeadershipSession) setLeaderUntil(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.leaderUntil = until
+}
@@ -0,0 +1,141 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLeadership(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	t.Run("Completion", func(t *testing.T) {
+		ran := false
+		err := store.WithLeadership(ctx, "candidate-1", time.Minute, func(ctx context.Context) error {
+			ran = true
+			lease, err := store.GetLease(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "candidate-1", lease.HolderIdentity, "Callback should run as leader")
+			return errors.New("callback failed")
+		})
+		require.EqualError(t, err, "callback failed")
+		assert.True(t, ran)
+
+		lease, err := store.GetLease(ctx)
+		require.NoError(t, err)
+		assert.False(t, lease.HasHolder(), "Lease should be released after the callback")
+	})
+
+	t.Run("Refused", func(t *testing.T) {
+		_, err := store.AcquireLease(ctx, "candidate-2", time.Minute)
+		require.NoError(t, err)
+
+		err = store.WithLeadership(ctx, "candidate-1", time.Minute, func(context.Context) error {
+			require.FailNow(t, "Callback should not run without the lease")
+			return nil
+		})
+		require.ErrorIs(t, err, ErrLeaseHeld)
+
+		require.NoError(t, store.DeleteLease(ctx))
+	})
+
+	t.Run("Lost", func(t *testing.T) {
+		err := store.WithLeadership(ctx, "candidate-1", 300*time.Millisecond, func(ctx context.Context) error {
+			// An operator forcibly hands the lease over.
+			admin, err := NewStore(Args{
+				LeaseCollection: collection,
+				LeaseKey:        "test-lease-key",
+			}, WithAdminOperations())
+			require.NoError(t, err, "Failed to create store")
+			require.NoError(t, admin.AdminTransfer(context.Background(), "candidate-2"))
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(2 * time.Second):
+				return errors.New("context was not canceled")
+			}
+		})
+		require.ErrorIs(t, err, ErrLeaseLost)
+		require.ErrorIs(t, err, context.Canceled, "Callback's context should be canceled")
+
+		lease, err := store.GetLease(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "candidate-2", lease.HolderIdentity, "New holder's lease should not be released")
+	})
+}
+
+func TestStartAutoRenew(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	session, err := store.StartAutoRenew(ctx, "candidate-1", 600*time.Millisecond, 100*time.Millisecond)
+	require.NoError(t, err)
+	require.Eventually(t, session.IsLeader, 2*time.Second, 20*time.Millisecond, "Session should become leader")
+
+	// Renewed past the initial lease duration.
+	time.Sleep(time.Second)
+	assert.True(t, session.IsLeader(), "Session should keep leadership")
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.ErrorIs(t, err, ErrLeaseHeld, "Lease should stay held by the session")
+
+	require.NoError(t, session.Stop())
+	assert.False(t, session.IsLeader(), "Session should lose leadership once stopped")
+	require.NoError(t, session.Stop(), "Stop should be idempotent")
+
+	lease, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.False(t, lease.HasHolder(), "Lease should be released")
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err, "Released lease should be acquirable")
+}
+
+func TestWithLeadershipInvalidDuration(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, newFakeCollection())
+
+	err := store.WithLeadership(context.Background(), "candidate-1", 0, func(context.Context) error {
+		require.FailNow(t, "The callback should not run")
+		return nil
+	})
+	require.Error(t, err, "A non-positive duration should be rejected")
+	_, err = store.GetLease(context.Background())
+	assert.ErrorIs(t, err, le.ErrLeaseNotFound, "The lease should not be acquired")
+}
+
+func TestStartAutoRenewInvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewStore(Args{LeaseKey: "test-lease-key"})
+	require.NoError(t, err, "Failed to create store")
+
+	_, err = store.StartAutoRenew(context.Background(), "candidate-1", time.Second, 0)
+	require.Error(t, err, "A non-positive interval should be rejected")
+	_, err = store.StartAutoRenew(context.Background(), "candidate-1", time.Second, time.Second)
+	require.Error(t, err, "An interval not shorter than the lease should be rejected")
+}
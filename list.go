@@ -0,0 +1,143 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LeaseInfo describes a lease document stored in the collection.
+type LeaseInfo struct {
+	Key   string
+	Lease *le.Lease
+	// DeletedAt is set when the lease has been soft-deleted.
+	DeletedAt *time.Time
+	// Region is the region of the last write, see WithRegion.
+	Region string
+}
+
+// ListLeases returns every lease stored in the collection, regardless of the
+// store's lease key. Soft-deleted leases are only returned when includeDeleted
+// is set.
+func (s *Store) ListLeases(ctx context.Context, includeDeleted bool) ([]LeaseInfo, error) {
+	if err := s.checkContext(ctx, "list"); err != nil {
+		return nil, err
+	}
+	if s.encoder != nil {
+		return nil, fmt.Errorf("list leases: %w", ErrCustomDocument)
+	}
+
+	filter := bson.M{}
+	if !includeDeleted {
+		filter["deleted_at"] = bson.M{"$exists": false}
+	}
+
+	cursor, err := s.readCollection().Find(ctx, filter, s.findOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []leaseDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	leases := make([]LeaseInfo, 0, len(docs))
+	for i := range docs {
+		leases = append(leases, docs[i].toLeaseInfo())
+	}
+
+	return leases, nil
+}
+
+func (ld *leaseDocument) toLeaseInfo() LeaseInfo {
+	return LeaseInfo{
+		Key:       ld.ID,
+		Lease:     ld.toLease(),
+		DeletedAt: ld.DeletedAt,
+		Region:    ld.Region,
+	}
+}
+
+// FindLeasesByHolder returns the keys of every lease in the collection held by
+// holder, sorted. Expired leases still naming holder are left out, judged by
+// their duration and the grace period only: a predicate set with
+// WithExpiryPredicate is not applied. A single query filtering on
+// holder_identity is issued, so an index on that field keeps it cheap on large
+// collections.
+func (s *Store) FindLeasesByHolder(ctx context.Context, holder string) ([]string, error) {
+	if err := s.checkContext(ctx, "find leases of"); err != nil {
+		return nil, err
+	}
+	if s.encoder != nil {
+		return nil, fmt.Errorf("find leases of %q: %w", holder, ErrCustomDocument)
+	}
+
+	filter := bson.M{
+		"holder_identity": holder,
+		"deleted_at":      bson.M{"$exists": false},
+		"$expr":           bson.M{"$gte": s.expiryOperands(s.clock.Now())},
+	}
+	opts := s.findOptions().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.M{"_id": 1})
+
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		keys = append(keys, doc.ID)
+	}
+
+	return keys, nil
+}
+
+// ListLeasesByPrefix returns the leases in the collection whose key starts with
+// prefix, sorted by key. Soft-deleted leases are not returned. The prefix is
+// matched with an anchored regular expression, which the _id index serves as a
+// range scan.
+func (s *Store) ListLeasesByPrefix(ctx context.Context, prefix string) ([]LeaseInfo, error) {
+	if err := s.checkContext(ctx, "list"); err != nil {
+		return nil, err
+	}
+	if s.encoder != nil {
+		return nil, fmt.Errorf("list leases under %q: %w", prefix, ErrCustomDocument)
+	}
+
+	filter := bson.M{
+		"_id":        bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)},
+		"deleted_at": bson.M{"$exists": false},
+	}
+	opts := s.findOptions().SetSort(bson.M{"_id": 1})
+
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []leaseDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	leases := make([]LeaseInfo, 0, len(docs))
+	for i := range docs {
+		leases = append(leases, docs[i].toLeaseInfo())
+	}
+
+	return leases, nil
+}
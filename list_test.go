@@ -0,0 +1,103 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindLeasesByHolder(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	holders := map[string]string{
+		"shard-1": "candidate-1",
+		"shard-2": "candidate-2",
+		"shard-3": "candidate-1",
+	}
+	var store *Store
+	for key, holder := range holders {
+		var err error
+		store, err = NewStore(Args{
+			LeaseCollection: collection,
+			LeaseKey:        key,
+		})
+		require.NoError(t, err, "Failed to create store")
+
+		now := time.Now()
+		require.NoError(t, store.CreateLease(ctx, &le.Lease{
+			HolderIdentity: holder,
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  time.Minute,
+		}))
+	}
+
+	// A lease still naming candidate-1 after it stopped renewing.
+	expired, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "shard-4",
+	})
+	require.NoError(t, err, "Failed to create store")
+	stale := time.Now().Add(-time.Hour)
+	require.NoError(t, expired.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    stale,
+		RenewTime:      stale,
+		LeaseDuration:  time.Minute,
+	}))
+
+	keys, err := store.FindLeasesByHolder(ctx, "candidate-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shard-1", "shard-3"}, keys, "Every key currently held by the holder should be returned")
+
+	keys, err = store.FindLeasesByHolder(ctx, "candidate-3")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestListLeasesByPrefix(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	var store *Store
+	for _, key := range []string{"T-2/scheduler", "U-1/scheduler", "T-1/scheduler", "T.1/scheduler"} {
+		var err error
+		store, err = NewStore(Args{
+			LeaseCollection: collection,
+			LeaseKey:        key,
+		})
+		require.NoError(t, err, "Failed to create store")
+
+		now := time.Now()
+		require.NoError(t, store.CreateLease(ctx, &le.Lease{
+			HolderIdentity: "candidate-1",
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  time.Minute,
+		}))
+	}
+
+	leases, err := store.ListLeasesByPrefix(ctx, "T-")
+	require.NoError(t, err)
+	keys := make([]string, 0, len(leases))
+	for _, lease := range leases {
+		keys = append(keys, lease.Key)
+	}
+	assert.Equal(t, []string{"T-1/scheduler", "T-2/scheduler"}, keys, "Only keys under the prefix should be returned, sorted")
+
+	leases, err = store.ListLeasesByPrefix(ctx, "T.")
+	require.NoError(t, err)
+	require.Len(t, leases, 1, "Prefix should be matched literally")
+	assert.Equal(t, "T.1/scheduler", leases[0].Key)
+}
@@ -0,0 +1,92 @@
+package mongoleasestore
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger is a minimal leveled logger taking alternating keys and values, for
+// applications logging with something else than slog. The zapadapter and
+// logrusadapter packages adapt the corresponding loggers. See
+// WithLoggerAdapter.
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+}
+
+// WithLoggerAdapter is WithLogger for a Logger. Records above the warn level
+// are logged as warnings; filtering by level is left to logger.
+func WithLoggerAdapter(logger Logger) Option {
+	return func(s *Store) {
+		if logger == nil {
+			s.logger = nil
+			return
+		}
+		s.logger = slog.New(&adapterHandler{logger: logger})
+	}
+}
+
+// adapterHandler is a slog.Handler writing to a Logger. Attributes of groups
+// are logged with the group names as key prefixes, separated by dots.
+type adapterHandler struct {
+	logger Logger
+	attrs  []any  // Keys and values added with WithAttrs.
+	prefix string // Group prefix of the attributes to come.
+}
+
+func (h *adapterHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *adapterHandler) Handle(_ context.Context, record slog.Record) error {
+	keysAndValues := append([]any(nil), h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		keysAndValues = appendAttr(keysAndValues, h.prefix, attr)
+		return true
+	})
+
+	switch {
+	case record.Level < slog.LevelInfo:
+		h.logger.Debug(record.Message, keysAndValues...)
+	case record.Level < slog.LevelWarn:
+		h.logger.Info(record.Message, keysAndValues...)
+	default:
+		h.logger.Warn(record.Message, keysAndValues...)
+	}
+	return nil
+}
+
+func (h *adapterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := *h
+	child.attrs = append([]any(nil), h.attrs...)
+	for _, attr := range attrs {
+		child.attrs = appendAttr(child.attrs, h.prefix, attr)
+	}
+	return &child
+}
+
+func (h *adapterHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.prefix = h.prefix + name + "."
+	return &child
+}
+
+// appendAttr appends the key and value of attr, or of the attributes of a
+// group, to keysAndValues.
+func appendAttr(keysAndValues []any, prefix string, attr slog.Attr) []any {
+	value := attr.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return append(keysAndValues, prefix+attr.Key, value.Any())
+	}
+	if attr.Key != "" {
+		prefix += attr.Key + "."
+	}
+	for _, member := range value.Group() {
+		keysAndValues = appendAttr(keysAndValues, prefix, member)
+	}
+	return keysAndValues
+}
@@ -0,0 +1,59 @@
+package mongoleasestore
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLogger is a Logger keeping every entry in memory.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []loggedEntry
+}
+
+type loggedEntry struct {
+	level         string
+	msg           string
+	keysAndValues []any
+}
+
+func (l *recordingLogger) log(level, msg string, keysAndValues []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, loggedEntry{level: level, msg: msg, keysAndValues: keysAndValues})
+}
+
+func (l *recordingLogger) Debug(msg string, keysAndValues ...any) { l.log("debug", msg, keysAndValues) }
+func (l *recordingLogger) Info(msg string, keysAndValues ...any)  { l.log("info", msg, keysAndValues) }
+func (l *recordingLogger) Warn(msg string, keysAndValues ...any)  { l.log("warn", msg, keysAndValues) }
+
+func TestLoggerAdapter(t *testing.T) {
+	t.Parallel()
+
+	logger := &recordingLogger{}
+	store, err := NewStore(Args{LeaseKey: "test-lease-key"}, WithLoggerAdapter(logger))
+	require.NoError(t, err, "Failed to create store")
+	require.NotNil(t, store.logger)
+
+	ctx := context.Background()
+	store.logger.DebugContext(ctx, "debug", "lease_key", "k")
+	store.logger.InfoContext(ctx, "info")
+	store.logger.With("lease_key", "k").WithGroup("lease").WarnContext(ctx, "warn", "holder", "h")
+	store.logger.ErrorContext(ctx, "error", slog.Group("retry", "attempt", 2))
+
+	assert.Equal(t, []loggedEntry{
+		{level: "debug", msg: "debug", keysAndValues: []any{"lease_key", "k"}},
+		{level: "info", msg: "info"},
+		{level: "warn", msg: "warn", keysAndValues: []any{"lease_key", "k", "lease.holder", "h"}},
+		{level: "warn", msg: "error", keysAndValues: []any{"retry.attempt", int64(2)}},
+	}, logger.entries)
+
+	store, err = NewStore(Args{LeaseKey: "test-lease-key"}, WithLoggerAdapter(nil))
+	require.NoError(t, err, "Failed to create store")
+	assert.Nil(t, store.logger, "A nil Logger should disable logging")
+}
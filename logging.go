@@ -0,0 +1,62 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// logWrite logs an applied write of newLease by op at debug level, unless it
+// is a renew, which would flood the log. Updates whose pre-image was not
+// captured cannot be told from renews and are not logged either.
+func (s *Store) logWrite(ctx context.Context, op string, newLease *le.Lease, before *leaseDocument, reason string) {
+	if s.logger == nil || reason == ReasonRenew || (before == nil && op == "update") {
+		return
+	}
+
+	attrs := []any{"lease_key", s.leaseKey, "holder", newLease.HolderIdentity, "op", op}
+	if before != nil && before.HolderIdentity != "" && reason == ReasonTakeover {
+		attrs = append(attrs, "previous_holder", before.HolderIdentity)
+	}
+	s.logger.DebugContext(ctx, "lease "+reasonVerb(reason), attrs...)
+}
+
+// reasonVerb returns the past tense of a write reason for log messages.
+func reasonVerb(reason string) string {
+	switch reason {
+	case ReasonTakeover:
+		return "taken over"
+	case ReasonRelease:
+		return "released"
+	default:
+		return "acquired"
+	}
+}
+
+// logRejection logs the failure of a write by op for holder, given its error:
+// conflicts, a normal outcome of an election, and writes refused while
+// draining at debug level, other failures, such as a renew that did not reach
+// MongoDB, at warn level. A missing lease is not logged. It is meant to be
+// deferred with a pointer to the operation's named error.
+func (s *Store) logRejection(ctx context.Context, op, holder string, err *error) {
+	if s.logger == nil || *err == nil || errors.Is(*err, le.ErrLeaseNotFound) {
+		return
+	}
+
+	if IsConflict(*err) || errors.Is(*err, ErrDraining) {
+		s.logger.DebugContext(ctx, "lease write rejected",
+			"lease_key", s.leaseKey,
+			"holder", holder,
+			"op", op,
+			"error", *err,
+		)
+		return
+	}
+	s.logger.WarnContext(ctx, "lease write failed",
+		"lease_key", s.leaseKey,
+		"holder", holder,
+		"op", op,
+		"error", *err,
+	)
+}
@@ -0,0 +1,54 @@
+package mongoleasestore
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestLogging(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	handler := &recordingHandler{}
+	collection := &flakyCollection{fakeCollection: newFakeCollection()}
+	store := newTestStore(t, collection,
+		WithLogger(slog.New(handler)),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	require.NoError(t, store.CreateLease(ctx, lease))
+	attrs, ok := handler.last("lease acquired")
+	require.True(t, ok, "Acquisitions should be logged")
+	assert.Equal(t, "test-lease-key", attrs["lease_key"])
+	assert.Equal(t, "candidate-1", attrs["holder"])
+
+	collection.mu.Lock()
+	collection.failures, collection.err = 2, mongo.CommandError{Code: 10107, Name: "NotWritablePrimary"}
+	collection.mu.Unlock()
+	lease.RenewTime = now.Add(time.Millisecond)
+	require.Error(t, store.UpdateLease(ctx, lease))
+	assert.Equal(t, 1, handler.count("retrying lease operation"))
+	attrs, ok = handler.last("lease write failed")
+	require.True(t, ok, "Failed renews should be logged")
+	assert.Equal(t, "candidate-1", attrs["holder"])
+	assert.Equal(t, "update", attrs["op"])
+
+	require.ErrorIs(t, store.CreateLease(ctx, lease), ErrLeaseAlreadyExists)
+	assert.Equal(t, 1, handler.count("lease write rejected"), "Conflicts should be logged")
+	assert.Equal(t, 1, handler.count("lease write failed"), "Conflicts are not failures")
+	assert.Equal(t, 1, handler.count("lease acquired"))
+}
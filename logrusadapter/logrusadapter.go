@@ -0,0 +1,50 @@
+// Package logrusadapter routes the logs of a mongoleasestore.Store to a logrus
+// logger:
+//
+//	store, err := mongoleasestore.NewStore(args,
+//		mongoleasestore.WithLoggerAdapter(logrusadapter.New(logger)))
+package logrusadapter
+
+import (
+	"fmt"
+
+	"github.com/rbroggi/mongoleasestore"
+	"github.com/sirupsen/logrus"
+)
+
+// New returns a mongoleasestore.Logger writing to logger, a *logrus.Logger or
+// a *logrus.Entry.
+func New(logger logrus.FieldLogger) mongoleasestore.Logger {
+	return adapter{logger: logger}
+}
+
+type adapter struct {
+	logger logrus.FieldLogger
+}
+
+func (a adapter) Debug(msg string, keysAndValues ...any) {
+	a.logger.WithFields(fields(keysAndValues)).Debug(msg)
+}
+
+func (a adapter) Info(msg string, keysAndValues ...any) {
+	a.logger.WithFields(fields(keysAndValues)).Info(msg)
+}
+
+func (a adapter) Warn(msg string, keysAndValues ...any) {
+	a.logger.WithFields(fields(keysAndValues)).Warn(msg)
+}
+
+// fields converts alternating keys and values to logrus fields. A key without
+// a value is logged with an empty one.
+func fields(keysAndValues []any) logrus.Fields {
+	fields := make(logrus.Fields, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		if i+1 < len(keysAndValues) {
+			fields[key] = keysAndValues[i+1]
+		} else {
+			fields[key] = ""
+		}
+	}
+	return fields
+}
@@ -0,0 +1,30 @@
+package logrusadapter
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	base, hook := test.NewNullLogger()
+	base.SetLevel(logrus.DebugLevel)
+	logger := New(base)
+	logger.Debug("lease acquired", "lease_key", "k", "holder", "h")
+	logger.Info("lease heartbeat")
+	logger.Warn("lease write failed", "attempt", 2, "dangling")
+
+	entries := hook.AllEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, logrus.DebugLevel, entries[0].Level)
+	assert.Equal(t, "lease acquired", entries[0].Message)
+	assert.Equal(t, logrus.Fields{"lease_key": "k", "holder": "h"}, entries[0].Data)
+	assert.Equal(t, logrus.InfoLevel, entries[1].Level)
+	assert.Equal(t, logrus.WarnLevel, entries[2].Level)
+	assert.Equal(t, logrus.Fields{"attempt": 2, "dangling": ""}, entries[2].Data)
+}
@@ -0,0 +1,180 @@
+package mongoleasestore
+
+import (
+	"errors"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// Metrics receives a measurement for every lease operation of a store.
+// Implementations adapt it to their metrics backend; the package imports
+// none. Implementations may also implement LateRenewObserver,
+// FlappingObserver, TransitionObserver and ExpiryObserver to be told about
+// the corresponding events. Backends recording counters, histograms and gauges
+// can implement MetricsSink instead and use NewSinkMetrics.
+//
+// Every call carries the store's lease key so that a process running many
+// leases can keep per-key series. Each distinct key is a distinct label value:
+// with keys derived from unbounded data, such as tenant or job IDs, aggregate
+// them or drop the label to keep the backend's cardinality in check.
+type Metrics interface {
+	// ObserveOperation is called once an operation (get, update, create,
+	// acquire, confirm, touch or delete) returns, with its latency and error,
+	// if any.
+	ObserveOperation(leaseKey, op string, latency time.Duration, err error)
+}
+
+// observeOperation reports an operation started at start to the metrics hook.
+// It is meant to be deferred with a pointer to the operation's named error.
+func (s *Store) observeOperation(op string, start time.Time, err *error) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.ObserveOperation(s.leaseKey, op, time.Since(start), *err)
+}
+
+// LateRenewObserver is implemented by Metrics that want to be told about late
+// renews, see WithLateRenewThreshold.
+type LateRenewObserver interface {
+	// ObserveLateRenew is called when holder renewed the lease margin before it
+	// would have expired, margin being below the late renew threshold. A
+	// negative margin means the lease had already expired.
+	ObserveLateRenew(leaseKey, holder string, margin time.Duration)
+}
+
+// TransitionObserver is implemented by Metrics that want to be told about
+// leader transitions. Telling transitions from renews costs a findAndModify
+// per update to read the replaced lease.
+type TransitionObserver interface {
+	// ObserveTransition is called when the store wrote an acquisition or a
+	// takeover of the lease by holder.
+	ObserveTransition(leaseKey, holder string)
+}
+
+// observesTransitions reports whether the metrics hook implements
+// TransitionObserver.
+func (s *Store) observesTransitions() bool {
+	_, ok := s.metrics.(TransitionObserver)
+	return ok
+}
+
+// ExpiryObserver is implemented by Metrics that want to track when the lease
+// expires, for example to export the time left before it does.
+type ExpiryObserver interface {
+	// ObserveExpiry is called whenever the store reads or writes the lease,
+	// with the time the holder's lease duration runs out, grace period
+	// excluded, or the zero time when the lease is not held.
+	ObserveExpiry(leaseKey string, expiresAt time.Time)
+}
+
+// observeExpiry reports the expiry of lease, as read or written, to the
+// metrics hook.
+func (s *Store) observeExpiry(lease *le.Lease) {
+	observer, ok := s.metrics.(ExpiryObserver)
+	if !ok {
+		return
+	}
+
+	var expiresAt time.Time
+	if lease.HasHolder() {
+		expiresAt = lease.RenewTime.Add(lease.LeaseDuration)
+	}
+	observer.ObserveExpiry(s.leaseKey, expiresAt)
+}
+
+// provideMetrics sets the metrics hook from the metrics provider, if any.
+func (s *Store) provideMetrics() error {
+	if s.metricsProvider == nil {
+		return nil
+	}
+	metrics, err := s.metricsProvider()
+	if err != nil {
+		return err
+	}
+	s.metrics = metrics
+	return nil
+}
+
+// ErrorClass classifies the error of a lease operation for error metrics:
+// "not_found", "conflict", "transient" or "other". Metrics backends label
+// their error counts with it.
+func ErrorClass(err error) string {
+	switch {
+	case errors.Is(err, le.ErrLeaseNotFound):
+		return "not_found"
+	case IsConflict(err):
+		return "conflict"
+	case IsTransient(err):
+		return "transient"
+	default:
+		return "other"
+	}
+}
+
+// MetricsSink is a minimal metrics backend, such as a StatsD or Datadog
+// client, recording counters, histograms and gauges by name and labels. See
+// NewSinkMetrics for the metrics it receives. Calls must not block.
+type MetricsSink interface {
+	IncCounter(name string, labels map[string]string)
+	ObserveHistogram(name string, value float64, labels map[string]string)
+	SetGauge(name string, value float64, labels map[string]string)
+}
+
+// NewSinkMetrics returns Metrics recording the lease operations of a store to
+// sink, labeled with lease_key and, where it applies, op:
+//
+//   - counter mongoleasestore_operations_total, every operation.
+//   - counter mongoleasestore_operation_errors_total, every failed operation,
+//     also labeled with its class: "not_found", "conflict", "transient" or
+//     "other".
+//   - histogram mongoleasestore_operation_duration_seconds, the latency of
+//     every operation.
+//   - counter mongoleasestore_leader_transitions_total, the acquisitions and
+//     takeovers written, see TransitionObserver.
+//   - gauge mongoleasestore_lease_expiry_timestamp_seconds, the Unix time the
+//     lease last read or written expires at, zero when it is not held.
+//   - histogram mongoleasestore_late_renew_margin_seconds, the margin of late
+//     renews, see WithLateRenewThreshold.
+//   - counter mongoleasestore_flapping_total, the times flapping was
+//     signaled, see WithMaxTransitionRate.
+func NewSinkMetrics(sink MetricsSink) Metrics {
+	return sinkMetrics{sink: sink}
+}
+
+type sinkMetrics struct {
+	sink MetricsSink
+}
+
+func (m sinkMetrics) ObserveOperation(leaseKey, op string, latency time.Duration, err error) {
+	labels := map[string]string{"lease_key": leaseKey, "op": op}
+	m.sink.IncCounter("mongoleasestore_operations_total", labels)
+	m.sink.ObserveHistogram("mongoleasestore_operation_duration_seconds", latency.Seconds(), labels)
+	if err != nil {
+		m.sink.IncCounter("mongoleasestore_operation_errors_total", map[string]string{
+			"lease_key": leaseKey,
+			"op":        op,
+			"class":     ErrorClass(err),
+		})
+	}
+}
+
+func (m sinkMetrics) ObserveTransition(leaseKey, _ string) {
+	m.sink.IncCounter("mongoleasestore_leader_transitions_total", map[string]string{"lease_key": leaseKey})
+}
+
+func (m sinkMetrics) ObserveExpiry(leaseKey string, expiresAt time.Time) {
+	var timestamp float64
+	if !expiresAt.IsZero() {
+		timestamp = float64(expiresAt.UnixMilli()) / 1e3
+	}
+	m.sink.SetGauge("mongoleasestore_lease_expiry_timestamp_seconds", timestamp, map[string]string{"lease_key": leaseKey})
+}
+
+func (m sinkMetrics) ObserveLateRenew(leaseKey, _ string, margin time.Duration) {
+	m.sink.ObserveHistogram("mongoleasestore_late_renew_margin_seconds", margin.Seconds(), map[string]string{"lease_key": leaseKey})
+}
+
+func (m sinkMetrics) ObserveFlapping(leaseKey string, _ int, _ time.Duration) {
+	m.sink.IncCounter("mongoleasestore_flapping_total", map[string]string{"lease_key": leaseKey})
+}
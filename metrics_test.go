@@ -0,0 +1,128 @@
+package mongoleasestore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics is a Metrics that keeps every observation in memory.
+type recordingMetrics struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+type observation struct {
+	leaseKey string
+	op       string
+	err      error
+}
+
+func (m *recordingMetrics) ObserveOperation(leaseKey, op string, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observations = append(m.observations, observation{leaseKey: leaseKey, op: op, err: err})
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	metrics := &recordingMetrics{}
+	newStore := func(key string) *Store {
+		store, err := NewStore(Args{LeaseKey: key}, withBaseCollection(newFakeCollection()), WithMetrics(metrics))
+		require.NoError(t, err, "Failed to create store")
+		return store
+	}
+	first := newStore("lease-1")
+	second := newStore("lease-2")
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	_, err := first.GetLease(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+	require.NoError(t, first.CreateLease(ctx, lease))
+	require.NoError(t, second.CreateLease(ctx, lease))
+	require.NoError(t, second.UpdateLease(ctx, lease))
+
+	assert.Equal(t, []observation{
+		{leaseKey: "lease-1", op: "get", err: le.ErrLeaseNotFound},
+		{leaseKey: "lease-1", op: "create"},
+		{leaseKey: "lease-2", op: "create"},
+		{leaseKey: "lease-2", op: "update"},
+	}, metrics.observations, "Every operation should be reported with its lease key")
+}
+
+// recordingSink is a MetricsSink keeping the last value and count of every
+// series in memory.
+type recordingSink struct {
+	mu     sync.Mutex
+	counts map[string]int
+	values map[string]float64
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{counts: make(map[string]int), values: make(map[string]float64)}
+}
+
+func (s *recordingSink) record(name string, value float64, labels map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series := name + "{" + labels["lease_key"] + "," + labels["op"] + "," + labels["class"] + "}"
+	s.counts[series]++
+	s.values[series] = value
+}
+
+func (s *recordingSink) IncCounter(name string, labels map[string]string) {
+	s.record(name, 1, labels)
+}
+
+func (s *recordingSink) ObserveHistogram(name string, value float64, labels map[string]string) {
+	s.record(name, value, labels)
+}
+
+func (s *recordingSink) SetGauge(name string, value float64, labels map[string]string) {
+	s.record(name, value, labels)
+}
+
+func TestSinkMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sink := newRecordingSink()
+	store, err := NewStore(Args{LeaseKey: "lease-1"}, withBaseCollection(newFakeCollection()), WithMetrics(NewSinkMetrics(sink)))
+	require.NoError(t, err, "Failed to create store")
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}
+	_, err = store.GetLease(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+	require.NoError(t, store.CreateLease(ctx, lease))
+	lease.RenewTime = now.Add(time.Second)
+	require.NoError(t, store.UpdateLease(ctx, lease))
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	assert.Equal(t, 1, sink.counts["mongoleasestore_operations_total{lease-1,get,}"])
+	assert.Equal(t, 1, sink.counts["mongoleasestore_operations_total{lease-1,update,}"])
+	assert.Equal(t, 1, sink.counts["mongoleasestore_operation_duration_seconds{lease-1,create,}"])
+	assert.Equal(t, 1, sink.counts["mongoleasestore_operation_errors_total{lease-1,get,not_found}"])
+	assert.Equal(t, 1, sink.counts["mongoleasestore_leader_transitions_total{lease-1,,}"], "A renew is not a transition")
+	assert.InDelta(t, float64(lease.RenewTime.Add(time.Minute).UnixMilli())/1e3,
+		sink.values["mongoleasestore_lease_expiry_timestamp_seconds{lease-1,,}"], 0.001)
+}
@@ -0,0 +1,231 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Server error codes of a missing collection.
+const (
+	namespaceNotFoundCode = 26
+	namespaceExistsCode   = 48
+)
+
+// WithEnsureCollection makes the store restore the lease collection after it
+// was dropped. MongoDB recreates a dropped collection by itself on the next
+// insert or upsert, and reads and writes of a document do not fail in
+// between, but the indexes are lost: once the store inserts a lease, it
+// recreates the indexes created through it, by EnsureIndexes, EnsureTTLIndex,
+// Args.ExpireAfter and the like. Failures to do so are logged.
+//
+// Operations that do fail on a missing collection, with NamespaceNotFound,
+// are retried once after creating it; without the option they are returned as
+// ErrCollectionMissing.
+func WithEnsureCollection() Option {
+	return func(s *Store) {
+		s.ensureCollection = true
+	}
+}
+
+// applyCollectionGuard makes the lease collections report a missing
+// collection as ErrCollectionMissing, recreating it if ensureCollection is set.
+// It must run after the collections are cloned, before any other wrapper.
+func (s *Store) applyCollectionGuard() {
+	collection, ok := s.collection.(*mongo.Collection)
+	if !ok || collection == nil {
+		return
+	}
+
+	var create func(ctx context.Context) error
+	var restore func(ctx context.Context)
+	if s.ensureCollection {
+		create = func(ctx context.Context) error {
+			err := collection.Database().CreateCollection(ctx, collection.Name())
+			if hasErrorCode(err, namespaceExistsCode) {
+				// Recreated concurrently.
+				return nil
+			}
+			return err
+		}
+		restore = func(ctx context.Context) {
+			s.restoreIndexes(ctx, collection)
+		}
+	}
+
+	s.collection = &guardedCollection{leaseCollection: s.collection, create: create, restore: restore}
+	if s.staleReads != nil {
+		s.staleReads = &guardedCollection{leaseCollection: s.staleReads, create: create}
+	}
+	if s.confirmReads != nil {
+		s.confirmReads = &guardedCollection{leaseCollection: s.confirmReads, create: create}
+	}
+}
+
+func hasErrorCode(err error, code int) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(code)
+}
+
+// restoreIndexes recreates the indexes created through the store, which a
+// collection recreated after a drop lacks. Existing indexes are left as they
+// are.
+func (s *Store) restoreIndexes(ctx context.Context, collection *mongo.Collection) {
+	indexes := s.indexes.all()
+	if len(indexes) == 0 {
+		return
+	}
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil && s.logger != nil {
+		s.logger.WarnContext(ctx, "failed to restore lease indexes", "lease_key", s.leaseKey, "error", err)
+	}
+}
+
+// guardedCollection classifies missing collection errors of the wrapped
+// collection, recreating the collection with create and retrying once if it is
+// set. Once a document is inserted, restore, if set, recreates the indexes the
+// collection may have lost.
+type guardedCollection struct {
+	leaseCollection
+	create  func(ctx context.Context) error // Nil to only classify.
+	restore func(ctx context.Context)       // Nil to leave indexes alone.
+}
+
+// inserted calls restore after a document was inserted.
+func (c *guardedCollection) inserted(ctx context.Context) {
+	if c.restore != nil {
+		c.restore(ctx)
+	}
+}
+
+// retry reports whether a call that failed with err should be retried, after
+// recreating the collection.
+func (c *guardedCollection) retry(ctx context.Context, err error) bool {
+	if c.create == nil || !hasErrorCode(err, namespaceNotFoundCode) {
+		return false
+	}
+	return c.create(ctx) == nil
+}
+
+// classify wraps an error of a missing collection with ErrCollectionMissing.
+func classify(err error) error {
+	if hasErrorCode(err, namespaceNotFoundCode) {
+		return fmt.Errorf("%w: %w", ErrCollectionMissing, err)
+	}
+	return err
+}
+
+func classifyResult(result *mongo.SingleResult) *mongo.SingleResult {
+	raw, err := result.Raw()
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, classify(err), nil)
+	}
+	return mongo.NewSingleResultFromDocument(raw, nil, nil)
+}
+
+func (c *guardedCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	result := classifyResult(c.leaseCollection.FindOne(ctx, filter, opts...))
+	if c.retry(ctx, result.Err()) {
+		result = classifyResult(c.leaseCollection.FindOne(ctx, filter, opts...))
+	}
+	return result
+}
+
+func (c *guardedCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	cursor, err := c.leaseCollection.Find(ctx, filter, opts...)
+	if c.retry(ctx, err) {
+		cursor, err = c.leaseCollection.Find(ctx, filter, opts...)
+	}
+	return cursor, classify(err)
+}
+
+func (c *guardedCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	count, err := c.leaseCollection.CountDocuments(ctx, filter, opts...)
+	if c.retry(ctx, err) {
+		count, err = c.leaseCollection.CountDocuments(ctx, filter, opts...)
+	}
+	return count, classify(err)
+}
+
+func (c *guardedCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	result, err := c.leaseCollection.InsertOne(ctx, document, opts...)
+	if c.retry(ctx, err) {
+		result, err = c.leaseCollection.InsertOne(ctx, document, opts...)
+	}
+	if err == nil {
+		c.inserted(ctx)
+	}
+	return result, classify(err)
+}
+
+func (c *guardedCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	result, err := c.leaseCollection.UpdateOne(ctx, filter, update, opts...)
+	if c.retry(ctx, err) {
+		result, err = c.leaseCollection.UpdateOne(ctx, filter, update, opts...)
+	}
+	if err == nil && result.UpsertedCount > 0 {
+		c.inserted(ctx)
+	}
+	return result, classify(err)
+}
+
+func (c *guardedCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	result := classifyResult(c.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts...))
+	if c.retry(ctx, result.Err()) {
+		result = classifyResult(c.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts...))
+	}
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) && upserts(opts) {
+		// Nothing matched, so the document before the update is missing: it
+		// was inserted.
+		c.inserted(ctx)
+	}
+	return result
+}
+
+func (c *guardedCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	result, err := c.leaseCollection.ReplaceOne(ctx, filter, replacement, opts...)
+	if c.retry(ctx, err) {
+		result, err = c.leaseCollection.ReplaceOne(ctx, filter, replacement, opts...)
+	}
+	if err == nil && result.UpsertedCount > 0 {
+		c.inserted(ctx)
+	}
+	return result, classify(err)
+}
+
+func (c *guardedCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	result, err := c.leaseCollection.DeleteOne(ctx, filter, opts...)
+	if c.retry(ctx, err) {
+		result, err = c.leaseCollection.DeleteOne(ctx, filter, opts...)
+	}
+	return result, classify(err)
+}
+
+func (c *guardedCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	result := classifyResult(c.leaseCollection.FindOneAndDelete(ctx, filter, opts...))
+	if c.retry(ctx, result.Err()) {
+		result = classifyResult(c.leaseCollection.FindOneAndDelete(ctx, filter, opts...))
+	}
+	return result
+}
+
+// upserts reports whether opts request an upsert returning the document before
+// the update, which is missing exactly when the upsert inserted it.
+func upserts(opts []*options.FindOneAndUpdateOptions) bool {
+	upsert, before := false, true
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Upsert != nil {
+			upsert = *opt.Upsert
+		}
+		if opt.ReturnDocument != nil {
+			before = *opt.ReturnDocument == options.Before
+		}
+	}
+	return upsert && before
+}
@@ -0,0 +1,137 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// droppedCollection fails FindOne as a dropped collection until it is
+// recreated.
+type droppedCollection struct {
+	*fakeCollection
+	dropped bool
+}
+
+func (c *droppedCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	if c.dropped {
+		err := mongo.CommandError{Code: namespaceNotFoundCode, Name: "NamespaceNotFound", Message: "ns not found"}
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	return c.fakeCollection.FindOne(ctx, filter, opts...)
+}
+
+func TestCollectionMissing(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+
+	t.Run("Classified", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := NewStore(Args{LeaseKey: "test-lease-key"})
+		require.NoError(t, err, "Failed to create store")
+		collection := &droppedCollection{fakeCollection: newFakeCollection(), dropped: true}
+		store.collection = &guardedCollection{leaseCollection: collection}
+
+		_, err = store.GetLease(context.Background())
+		require.ErrorIs(t, err, ErrCollectionMissing)
+	})
+
+	t.Run("Recreated", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := NewStore(Args{LeaseKey: "test-lease-key"})
+		require.NoError(t, err, "Failed to create store")
+		collection := &droppedCollection{fakeCollection: newFakeCollection()}
+		created := 0
+		store.collection = &guardedCollection{
+			leaseCollection: collection,
+			create: func(context.Context) error {
+				created++
+				collection.dropped = false
+				return nil
+			},
+		}
+		require.NoError(t, store.CreateLease(context.Background(), lease))
+		collection.dropped = true
+
+		got, err := store.GetLease(context.Background())
+		require.NoError(t, err, "Read should be retried once the collection is recreated")
+		assert.Equal(t, "candidate-1", got.HolderIdentity)
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("Indexes Restored", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		restored := 0
+		store := newTestStore(t, &guardedCollection{
+			leaseCollection: newFakeCollection(),
+			restore:         func(context.Context) { restored++ },
+		})
+
+		require.NoError(t, store.CreateLease(ctx, lease))
+		assert.Equal(t, 1, restored, "Indexes should be restored once a lease is inserted")
+		renewed := *lease
+		renewed.RenewTime = now.Add(time.Millisecond)
+		require.NoError(t, store.UpdateLease(ctx, &renewed))
+		assert.Equal(t, 1, restored, "Updates do not recreate the collection")
+
+		require.NoError(t, store.DeleteLease(ctx))
+		_, err := store.AcquireLease(ctx, "candidate-2", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 2, restored, "Indexes should be restored once a lease is upserted")
+	})
+}
+
+func TestEnsureCollection(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithEnsureCollection())
+	require.NoError(t, err, "Failed to create store")
+	require.NoError(t, store.EnsureIndexes(ctx))
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+	require.NoError(t, collection.Drop(ctx))
+
+	_, err = store.GetLease(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound, "Dropped lease should be gone, not fail the store")
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err, "Lease should be acquirable in the recreated collection")
+
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	require.NoError(t, err)
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	assert.ElementsMatch(t, []string{"_id_", "holder_identity_1_renew_time_1", "renew_time_1"}, names, "Indexes should be restored")
+}
@@ -0,0 +1,59 @@
+package mongoleasestore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// serverNowProjection returns every lease field plus the server's current time
+// as server_now, so that reads sample the server clock for free.
+var serverNowProjection = bson.M{
+	"holder_identity":    1,
+	"acquire_time":       1,
+	"renew_time":         1,
+	"lease_duration":     1,
+	"leader_transitions": 1,
+	"deleted_at":         1,
+	"region":             1,
+	"request_id":         1,
+	"server_now":         "$$NOW",
+}
+
+// serverClockCheck tracks the latest server time observed by the store.
+type serverClockCheck struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// observe records now and returns the latest earlier observation if now is
+// before it.
+func (c *serverClockCheck) observe(now time.Time) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Before(c.last) {
+		return c.last, true
+	}
+	c.last = now
+	return time.Time{}, false
+}
+
+// checkServerClock warns when the server time sampled by a read went back
+// compared to an earlier read. Expiry decisions are made with server times, so
+// a primary whose clock jumped backwards makes leases outlive their duration.
+func (s *Store) checkServerClock(ctx context.Context, serverNow time.Time) {
+	last, regressed := s.serverClockCheck.observe(serverNow)
+	if !regressed || s.logger == nil {
+		return
+	}
+
+	s.logger.WarnContext(ctx, "server clock went backwards",
+		"lease_key", s.leaseKey,
+		"server_time", serverNow,
+		"last_server_time", last,
+		"regression", last.Sub(serverNow),
+	)
+}
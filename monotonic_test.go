@@ -0,0 +1,55 @@
+package mongoleasestore
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerClockMonotonicityCheck(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	handler := &recordingHandler{}
+	collection := newFakeCollection()
+	store := newTestStore(t, collection,
+		WithLogger(slog.New(handler)),
+		WithServerClockMonotonicityCheck(),
+	)
+
+	now := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+
+	// The fake returns the stored document as is, so the server time it
+	// reports is set by hand.
+	read := func(serverNow time.Time) {
+		collection.mu.Lock()
+		collection.doc.ServerNow = &serverNow
+		collection.mu.Unlock()
+
+		_, err := store.GetLease(ctx)
+		require.NoError(t, err)
+	}
+
+	read(now)
+	read(now.Add(time.Second))
+	assert.Zero(t, handler.count("server clock went backwards"), "Advancing server time should not warn")
+
+	read(now.Add(500 * time.Millisecond))
+	require.Equal(t, 1, handler.count("server clock went backwards"), "Decreasing server time should warn")
+	attrs, _ := handler.last("server clock went backwards")
+	assert.Equal(t, 500*time.Millisecond, attrs["regression"])
+
+	read(now.Add(2 * time.Second))
+	assert.Equal(t, 1, handler.count("server clock went backwards"))
+}
@@ -0,0 +1,59 @@
+package mongoleasestore
+
+import (
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// Observer is told about every GetLease, UpdateLease and CreateLease of a
+// store, the operations the elector drives, for dashboards of leadership
+// changes and operation latencies. See Metrics for every operation.
+//
+// Observers are called synchronously once the operation completed, with its
+// outcome; they cannot change it. The store does not recover panics of an
+// observer: they propagate to the caller of the operation.
+type Observer interface {
+	// OnGetLease is called with the holder of the lease read, empty when the
+	// read failed or the lease has none.
+	OnGetLease(holder string, latency time.Duration, err error)
+	// OnUpdateLease is called with the holder and leader transitions of the
+	// lease written.
+	OnUpdateLease(holder string, transitions uint32, latency time.Duration, err error)
+	// OnCreateLease is called with the holder of the lease created.
+	OnCreateLease(holder string, latency time.Duration, err error)
+}
+
+// WithObserver reports GetLease, UpdateLease and CreateLease to observer.
+func WithObserver(observer Observer) Option {
+	return func(s *Store) {
+		s.observer = observer
+	}
+}
+
+// observeGet reports a GetLease started at start to the observer. It is meant
+// to be deferred with pointers to the operation's named results.
+func (s *Store) observeGet(start time.Time, lease **le.Lease, err *error) {
+	if s.observer == nil {
+		return
+	}
+	var holder string
+	if *lease != nil {
+		holder = (*lease).HolderIdentity
+	}
+	s.observer.OnGetLease(holder, time.Since(start), *err)
+}
+
+// observeWrite reports an UpdateLease or CreateLease of newLease started at
+// start to the observer. It is meant to be deferred with a pointer to the
+// operation's named error.
+func (s *Store) observeWrite(op string, newLease *le.Lease, start time.Time, err *error) {
+	if s.observer == nil {
+		return
+	}
+	if op == "create" {
+		s.observer.OnCreateLease(newLease.HolderIdentity, time.Since(start), *err)
+		return
+	}
+	s.observer.OnUpdateLease(newLease.HolderIdentity, newLease.LeaderTransitions, time.Since(start), *err)
+}
@@ -0,0 +1,85 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver is an Observer that keeps every call in memory, panicking
+// with panicWith when set.
+type recordingObserver struct {
+	calls     []observedCall
+	panicWith string
+}
+
+type observedCall struct {
+	op          string
+	holder      string
+	transitions uint32
+	err         error
+}
+
+func (o *recordingObserver) record(call observedCall) {
+	o.calls = append(o.calls, call)
+	if o.panicWith != "" {
+		panic(o.panicWith)
+	}
+}
+
+func (o *recordingObserver) OnGetLease(holder string, _ time.Duration, err error) {
+	o.record(observedCall{op: "get", holder: holder, err: err})
+}
+
+func (o *recordingObserver) OnUpdateLease(holder string, transitions uint32, _ time.Duration, err error) {
+	o.record(observedCall{op: "update", holder: holder, transitions: transitions, err: err})
+}
+
+func (o *recordingObserver) OnCreateLease(holder string, _ time.Duration, err error) {
+	o.record(observedCall{op: "create", holder: holder, err: err})
+}
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	observer := &recordingObserver{}
+	store := newTestStore(t, newFakeCollection(), WithObserver(observer))
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity:    "candidate-1",
+		AcquireTime:       now,
+		RenewTime:         now,
+		LeaseDuration:     time.Second,
+		LeaderTransitions: 1,
+	}
+	_, err := store.GetLease(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+	require.NoError(t, store.CreateLease(ctx, lease))
+	_, err = store.GetLease(ctx)
+	require.NoError(t, err)
+	require.NoError(t, store.UpdateLease(ctx, lease))
+
+	assert.Equal(t, []observedCall{
+		{op: "get", err: le.ErrLeaseNotFound},
+		{op: "create", holder: "candidate-1"},
+		{op: "get", holder: "candidate-1"},
+		{op: "update", holder: "candidate-1", transitions: 1},
+	}, observer.calls, "Every elector operation should be reported with its outcome")
+}
+
+func TestObserverPanic(t *testing.T) {
+	t.Parallel()
+
+	observer := &recordingObserver{panicWith: "observer failed"}
+	store := newTestStore(t, newFakeCollection(), WithObserver(observer))
+
+	assert.PanicsWithValue(t, "observer failed", func() {
+		_, _ = store.GetLease(context.Background())
+	}, "A panicking observer should not be recovered by the store")
+}
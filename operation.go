@@ -0,0 +1,112 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultOperationComment tags the store's operations in the MongoDB profiler
+// and slow-query log.
+const defaultOperationComment = "mongoleasestore"
+
+// checkContext fails fast, without contacting MongoDB, when ctx is already
+// done. Drivers report this inconsistently across versions otherwise.
+func (s *Store) checkContext(ctx context.Context, op string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s lease %q: %w", op, s.leaseKey, err)
+	}
+	return nil
+}
+
+// The helpers below return the options shared by every operation of a kind.
+// Operations that accept maxTimeMS get the operation timeout as well, so that
+// the server abandons them when the client does; see WithOperationTimeout.
+
+func (s *Store) findOneOptions() *options.FindOneOptions {
+	opts := options.FindOne()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	if s.timeout > 0 {
+		opts.SetMaxTime(s.timeout)
+	}
+	return opts
+}
+
+func (s *Store) findOptions() *options.FindOptions {
+	opts := options.Find()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	if s.timeout > 0 {
+		opts.SetMaxTime(s.timeout)
+	}
+	return opts
+}
+
+func (s *Store) countOptions() *options.CountOptions {
+	opts := options.Count()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	if s.timeout > 0 {
+		opts.SetMaxTime(s.timeout)
+	}
+	return opts
+}
+
+func (s *Store) insertOneOptions() *options.InsertOneOptions {
+	opts := options.InsertOne()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	return opts
+}
+
+func (s *Store) updateOptions() *options.UpdateOptions {
+	opts := options.Update()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	return opts
+}
+
+func (s *Store) findOneAndUpdateOptions() *options.FindOneAndUpdateOptions {
+	opts := options.FindOneAndUpdate()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	if s.timeout > 0 {
+		opts.SetMaxTime(s.timeout)
+	}
+	return opts
+}
+
+func (s *Store) findOneAndDeleteOptions() *options.FindOneAndDeleteOptions {
+	opts := options.FindOneAndDelete()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	if s.timeout > 0 {
+		opts.SetMaxTime(s.timeout)
+	}
+	return opts
+}
+
+func (s *Store) replaceOptions() *options.ReplaceOptions {
+	opts := options.Replace()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	return opts
+}
+
+func (s *Store) deleteOptions() *options.DeleteOptions {
+	opts := options.Delete()
+	if s.comment != "" {
+		opts.SetComment(s.comment)
+	}
+	return opts
+}
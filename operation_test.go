@@ -0,0 +1,156 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestOperationComment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		comment string
+	}{
+		{
+			name:    "default",
+			comment: "mongoleasestore",
+		},
+		{
+			name:    "custom",
+			opts:    []Option{WithOperationComment("billing-leader")},
+			comment: "billing-leader",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			collection := newFakeCollection()
+			store := newTestStore(t, collection, tt.opts...)
+
+			now := time.Now()
+			lease := &le.Lease{
+				HolderIdentity: "candidate-1",
+				AcquireTime:    now,
+				RenewTime:      now,
+				LeaseDuration:  time.Second,
+			}
+			require.NoError(t, store.CreateLease(ctx, lease))
+			require.NoError(t, store.UpdateLease(ctx, lease))
+			_, err := store.GetLease(ctx)
+			require.NoError(t, err)
+
+			findOpts, ok := collection.lastOptions("FindOne").(*options.FindOneOptions)
+			require.True(t, ok, "FindOne should receive options")
+			require.NotNil(t, findOpts.Comment)
+			assert.Equal(t, tt.comment, *findOpts.Comment)
+
+			updateOpts, ok := collection.lastOptions("UpdateOne").(*options.UpdateOptions)
+			require.True(t, ok, "UpdateOne should receive options")
+			assert.Equal(t, tt.comment, updateOpts.Comment)
+		})
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context, store *Store) error
+	}{
+		{
+			name: "GetLease",
+			call: func(ctx context.Context, store *Store) error {
+				_, err := store.GetLease(ctx)
+				return err
+			},
+		},
+		{
+			name: "UpdateLease",
+			call: func(ctx context.Context, store *Store) error {
+				return store.UpdateLease(ctx, lease)
+			},
+		},
+		{
+			name: "CreateLease",
+			call: func(ctx context.Context, store *Store) error {
+				return store.CreateLease(ctx, lease)
+			},
+		},
+		{
+			name: "DeleteLease",
+			call: func(ctx context.Context, store *Store) error {
+				return store.DeleteLease(ctx)
+			},
+		},
+		{
+			name: "DeleteLeaseIf",
+			call: func(ctx context.Context, store *Store) error {
+				return store.DeleteLeaseIf(ctx, Expired)
+			},
+		},
+		{
+			name: "ListLeases",
+			call: func(ctx context.Context, store *Store) error {
+				_, err := store.ListLeases(ctx, true)
+				return err
+			},
+		},
+		{
+			name: "FindLeasesByHolder",
+			call: func(ctx context.Context, store *Store) error {
+				_, err := store.FindLeasesByHolder(ctx, "candidate-1")
+				return err
+			},
+		},
+		{
+			name: "ListLeasesByPrefix",
+			call: func(ctx context.Context, store *Store) error {
+				_, err := store.ListLeasesByPrefix(ctx, "shard-")
+				return err
+			},
+		},
+		{
+			name: "GetLeaseHistory",
+			call: func(ctx context.Context, store *Store) error {
+				_, err := store.GetLeaseHistory(ctx, 1)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			collection := newFakeCollection()
+			store := newTestStore(t, collection)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := tt.call(ctx, store)
+			require.ErrorIs(t, err, context.Canceled)
+			assert.Empty(t, collection.calls, "Mongo should not be contacted")
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package mongoleasestore
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Option configures optional behavior of a Store.
+//
+// Every field of Args has an Option counterpart, so a store can be configured
+// with options alone: NewStore(Args{}, WithLeaseCollection(c), WithLeaseKey(k)).
+// Options override Args.
+type Option func(*Store)
+
+// WithLeaseCollection sets the collection holding the lease, see
+// Args.LeaseCollection.
+func WithLeaseCollection(collection *mongo.Collection) Option {
+	return func(s *Store) {
+		s.collection = collection
+	}
+}
+
+// WithLeaseKey sets the key of the lease, see Args.LeaseKey.
+func WithLeaseKey(key string) Option {
+	return func(s *Store) {
+		s.leaseKey = key
+	}
+}
+
+// WithExpireAfter makes the store maintain the expires_at field and NewStore
+// ensure a TTL index deleting leases expireAfter past their expiry, see
+// Args.ExpireAfter. A zero expireAfter leaves the index alone.
+func WithExpireAfter(expireAfter time.Duration) Option {
+	return func(s *Store) {
+		s.expiresAt = s.expiresAt || expireAfter > 0
+		s.expireAfter = expireAfter
+	}
+}
+
+// WithClock sets the clock used for client-side expiry checks. Defaults to the
+// local wall clock.
+func WithClock(clock Clock) Option {
+	return func(s *Store) {
+		s.clock = clock
+	}
+}
+
+// WithSoftDelete makes DeleteLease tombstone the lease document with a
+// deleted_at marker and clear its holder instead of removing it. Tombstoned
+// leases read as not found and are replaced by CreateLease.
+func WithSoftDelete() Option {
+	return func(s *Store) {
+		s.softDelete = true
+	}
+}
+
+// WithLogger sets the logger used for diagnostics, with the lease key and,
+// where it applies, the holder as the lease_key and holder attributes.
+// Acquisitions, takeovers, releases, conflicts and retries are logged at debug
+// level; failed writes, such as renews that did not reach MongoDB, and other
+// anomalies at warn level. Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Store) {
+		s.logger = logger
+	}
+}
+
+// WithReadCache makes GetLease reuse its last result for ttl. Every write
+// through the store invalidates the cache, but writes made by other stores are
+// only observed once the cached result expires.
+func WithReadCache(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.readCache = newReadCache(ttl)
+	}
+}
+
+// WithEnforceConsistentDuration makes UpdateLease reject acquires and renews
+// whose lease duration differs from the one stored in the lease, returning
+// ErrDurationMismatch. This surfaces electors configured with different
+// durations contending for the same lease. Releases are not checked.
+func WithEnforceConsistentDuration() Option {
+	return func(s *Store) {
+		s.enforceConsistentDuration = true
+	}
+}
+
+// WithHistoryCollection appends a HistoryRecord to collection for every
+// mutation of the lease. Recording is best-effort: failures are logged and do
+// not fail the mutation. See WithHistoryTransitionsOnly and
+// WithHistoryRetention to keep the history to a size worth auditing.
+func WithHistoryCollection(collection *mongo.Collection) Option {
+	return func(s *Store) {
+		s.history = collection
+	}
+}
+
+// WithMinimalDocument omits fields that can be defaulted on read from the
+// stored lease: acquire_time when it equals renew_time and leader_transitions
+// when zero. This trims documents in collections holding many leases at the
+// cost of a less self-describing schema; readers that do not go through a
+// Store must apply the same defaults.
+func WithMinimalDocument() Option {
+	return func(s *Store) {
+		s.minimalDocument = true
+	}
+}
+
+// WithOnRenew registers a callback invoked after every successful UpdateLease
+// in which the holder renewed its own lease, with the lease as written. It is
+// not invoked for acquisitions, takeovers, releases or failed updates.
+//
+// The callback runs synchronously on the renew path, after the write has been
+// applied: it should return quickly and hand slow work (such as network
+// keepalives) off to another goroutine, as the elector waits for it before
+// its next retry.
+func WithOnRenew(onRenew func(lease *le.Lease)) Option {
+	return func(s *Store) {
+		s.onRenew = onRenew
+	}
+}
+
+// WithOperationComment sets the comment attached to every MongoDB operation of
+// the store, which tags them in the profiler and slow-query log. Defaults to
+// "mongoleasestore"; an empty comment attaches none.
+func WithOperationComment(comment string) Option {
+	return func(s *Store) {
+		s.comment = comment
+	}
+}
+
+// WithRegion stamps region on the lease on every write, surfaced in LeaseInfo.
+func WithRegion(region string) Option {
+	return func(s *Store) {
+		s.region = region
+	}
+}
+
+// WithStrictRegion makes UpdateLease reject, with ErrRegionMismatch, a holder
+// renewing its lease from a different region (see WithRegion) than the one it
+// acquired it in. In active-active setups this reveals the same candidate
+// identity configured in two regions. Takeovers and releases are not checked.
+func WithStrictRegion() Option {
+	return func(s *Store) {
+		s.strictRegion = true
+	}
+}
+
+// WithExpiryPredicate replaces the default expiry rule, renew_time +
+// lease_duration < now, wherever the store decides whether a lease can be
+// taken over: IsExpired, AcquireLease, DeleteLeaseIf(Expired), WaitUntilFree
+// and WatchLeaderPolling. It allows grace periods, skew margins or fixed TTLs.
+//
+// The default rule is evaluated by the server in the conditional write; a
+// custom predicate is evaluated on a fresh read and the write is conditioned on
+// the lease not having changed since.
+func WithExpiryPredicate(predicate ExpiryPredicate) Option {
+	return func(s *Store) {
+		s.expiryPredicate = predicate
+	}
+}
+
+// WithRenewDeadlineGuard makes a renew by UpdateLease or AcquireLease fail with
+// ErrRenewUnreliable when it took longer than the time left on the lease when
+// it started: by the time it was written the lease may already have been lost.
+// The check costs a findAndModify per update to read the renewed lease.
+func WithRenewDeadlineGuard() Option {
+	return func(s *Store) {
+		s.renewDeadlineGuard = true
+	}
+}
+
+// WithLateRenewThreshold signals renews that completed less than threshold
+// before the lease they renewed would have expired: they are logged as
+// warnings and reported to the metrics hook if it implements
+// LateRenewObserver. Such near misses surface instability before it costs the
+// lease. The check costs a findAndModify per update to read the renewed lease.
+func WithLateRenewThreshold(threshold time.Duration) Option {
+	return func(s *Store) {
+		s.lateRenewThreshold = threshold
+	}
+}
+
+// WithMaxPayloadBytes limits the payloads accepted by SetLeaderPayload to n
+// bytes, 64KiB by default. Larger payloads are rejected with
+// ErrPayloadTooLarge before anything is written.
+func WithMaxPayloadBytes(n int) Option {
+	return func(s *Store) {
+		s.maxPayloadBytes = n
+	}
+}
+
+// WithUpdateUpserts makes UpdateLease create the lease, as CreateLease would,
+// when it does not exist instead of returning ErrLeaseNotFound. Releases of a
+// missing lease still return ErrLeaseNotFound.
+func WithUpdateUpserts() Option {
+	return func(s *Store) {
+		s.updateUpserts = true
+	}
+}
+
+// WithUnchangedUpdateError makes UpdateLease return ErrLeaseUnchanged when the
+// lease matched but the update left it unchanged, for example a renew at the
+// same millisecond as the previous one. By default such an update succeeds,
+// since the lease is as requested.
+func WithUnchangedUpdateError() Option {
+	return func(s *Store) {
+		s.unchangedUpdateError = true
+	}
+}
+
+// WithServerTimestamps makes AcquireLease stamp acquire_time and renew_time
+// with the server's current time ($$NOW) instead of the store's clock, and
+// judge expiry against it, so that candidates with drifting clocks agree on
+// when a lease expires. The returned lease carries the stored times; creating
+// the lease reads it back to learn them. Leases written by the elector through
+// UpdateLease and CreateLease still carry its own timestamps, see ClusterClock
+// for those. AcquireUntil derives the duration from the server's time too.
+func WithServerTimestamps() Option {
+	return func(s *Store) {
+		s.serverTimestamps = true
+	}
+}
+
+// WithExpiresAt makes every write maintain an expires_at field holding
+// renew_time + lease_duration, which a TTL index can expire abandoned leases
+// on (see EnsureExpiresAtIndex) and expiry range queries can use an index on.
+// Expiry decisions of the store are unaffected: they are still computed from
+// renew_time and lease_duration, plus the grace period.
+func WithExpiresAt() Option {
+	return func(s *Store) {
+		s.expiresAt = true
+	}
+}
+
+// WithLatencyStats makes the store keep the latencies of its last window lease
+// operations, which SuggestRenewInterval is based on. A window <= 0 keeps the
+// last 32.
+func WithLatencyStats(window int) Option {
+	return func(s *Store) {
+		if window <= 0 {
+			window = defaultLatencyWindow
+		}
+		s.stats = newLatencyStats(window)
+	}
+}
+
+// WithMetrics reports every lease operation of the store to metrics, labeled
+// with the store's lease key.
+func WithMetrics(metrics Metrics) Option {
+	return func(s *Store) {
+		s.metrics = metrics
+	}
+}
+
+// WithMetricsProvider reports every lease operation of the store to the
+// metrics provide returns when NewStore runs, which fails if provide does. It
+// lets metrics backends that register with a global registry, such as
+// prometheusmetrics, plug in as an option. It replaces the hook set with
+// WithMetrics.
+func WithMetricsProvider(provide func() (Metrics, error)) Option {
+	return func(s *Store) {
+		s.metricsProvider = provide
+	}
+}
+
+// WithStaleReadPreference routes GetLease and ListLeases to servers selected by
+// readPref, such as the nearest secondary, while writes and the reads the store
+// bases expiry decisions on (WaitUntilFree, AcquireLease, RenewWithCASRetry and
+// conflict explanations) stay on the primary. Observers built on GetLease
+// (WatchLeaderPolling, StartHeartbeat) and LeaderPayload follow it too, so
+// that large fleets of watchers, given a store of their own with
+// secondaryPreferred, do not load the primary.
+//
+// Secondaries lag behind the primary, so GetLease may return a lease that has
+// since been renewed, taken over or released. Only use it for status and
+// introspection: a store handed to an elector decides takeovers on GetLease
+// and must not set it.
+func WithStaleReadPreference(readPref *readpref.ReadPref) Option {
+	return func(s *Store) {
+		s.staleReadPreference = readPref
+	}
+}
+
+// WithAdminOperations enables admin operations, such as AdminExtend,
+// AdminForceRelease and AdminTransfer, that modify the lease regardless of its
+// holder. They are disabled by default so that a store handed to an elector
+// cannot be used to override the election by accident. See WithActor to
+// attribute them in the audit trail.
+func WithAdminOperations() Option {
+	return func(s *Store) {
+		s.adminOperations = true
+	}
+}
+
+// WithMinRenewInterval makes renews by UpdateLease, AcquireLease and Touch
+// fail with ErrRenewTooSoon when the holder renewed less than interval ago,
+// according to the server's clock. It protects the deployment from a client
+// renewing in a tight loop. Takeovers and releases are not limited.
+func WithMinRenewInterval(interval time.Duration) Option {
+	return func(s *Store) {
+		s.minRenewInterval = interval
+	}
+}
+
+// WithOnWrite calls onWrite after every write of the lease by CreateLease,
+// UpdateLease, UpdateLeaseCAS and AcquireLease, with the lease before the
+// write (nil if it did not exist), the lease as written and the reason of the
+// change (ReasonAcquire, ReasonRenew, ReasonTakeover or ReasonRelease). The
+// pre-image is captured atomically with the write, at the cost of a
+// findAndModify per update. Touch, deletes and admin operations are not
+// reported. The callback runs synchronously and must not block.
+func WithOnWrite(onWrite func(before, after *le.Lease, reason string)) Option {
+	return func(s *Store) {
+		s.onWrite = onWrite
+	}
+}
+
+// WithAuditWriter writes an AuditEvent as a JSON line to w for every mutation
+// of the lease made through the store. Lines are written whole, one Write call
+// each, and never interleaved; failed writes are logged and do not fail the
+// mutation. Writes are synchronous, so a slow w slows mutations down: buffer
+// it if needed.
+func WithAuditWriter(w io.Writer) Option {
+	return func(s *Store) {
+		s.auditLog = &auditLog{w: w}
+	}
+}
+
+// WithServerClockMonotonicityCheck makes every lease read from the primary
+// also sample the server's clock ($$NOW) and log a warning when it is earlier
+// than a previously sampled one. A primary whose clock jumped backwards, for
+// instance after a failover to a badly synchronized node, breaks expiry.
+// Requires MongoDB 4.4 or later.
+func WithServerClockMonotonicityCheck() Option {
+	return func(s *Store) {
+		s.serverClockCheck = &serverClockCheck{}
+	}
+}
+
+// WithAcquireWindow restricts AcquireLease to the window [start, end) of the
+// store's clock: outside of it, acquisitions and renews fail with
+// ErrOutsideAcquireWindow without contacting MongoDB, so that the lease lapses
+// once the window closes. Inside it, AcquireLease behaves as usual.
+func WithAcquireWindow(start, end time.Time) Option {
+	return func(s *Store) {
+		s.acquireWindow = &acquireWindow{start: start, end: end}
+	}
+}
+
+// WithGracePeriod delays the expiry of every lease by grace past renew_time +
+// lease_duration, wherever the store decides whether a lease can be taken over
+// (see CanAcquire). A margin for clock skew between candidates, for instance.
+// It does not apply on top of a predicate set with WithExpiryPredicate.
+func WithGracePeriod(grace time.Duration) Option {
+	return func(s *Store) {
+		s.grace = grace
+	}
+}
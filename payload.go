@@ -0,0 +1,89 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultMaxPayloadBytes caps leader payloads unless WithMaxPayloadBytes says
+// otherwise. It keeps the lease document small, far below MongoDB's 16MB limit.
+const defaultMaxPayloadBytes = 64 << 10
+
+// leaderPayload is the payload stored in the lease document, tagged with the
+// holder that set it so that it is not reported for later holders.
+type leaderPayload struct {
+	Holder string `bson:"holder"`
+	Data   []byte `bson:"data"`
+}
+
+// SetLeaderPayload stores payload in the lease for as long as holder holds it,
+// for example the address other processes should reach the leader at. Returns
+// ErrPayloadTooLarge if payload exceeds the limit set with WithMaxPayloadBytes
+// and ErrLeaseLost if holder does not hold the lease.
+func (s *Store) SetLeaderPayload(ctx context.Context, holder string, payload []byte) error {
+	if err := s.checkContext(ctx, "set payload of"); err != nil {
+		return err
+	}
+	if err := s.requireDefaultDocument("set payload of"); err != nil {
+		return err
+	}
+	if holder == "" {
+		return fmt.Errorf("set payload of lease %q: %w", s.leaseKey, ErrEmptyHolder)
+	}
+	if len(payload) > s.maxPayloadBytes {
+		return fmt.Errorf("%w: payload of %d bytes for lease %q exceeds the limit of %d bytes",
+			ErrPayloadTooLarge, len(payload), s.leaseKey, s.maxPayloadBytes)
+	}
+
+	filter := s.leaseFilter()
+	filter["holder_identity"] = holder
+	update := bson.M{"$set": bson.M{"payload": leaderPayload{Holder: holder, Data: payload}}}
+
+	sent := time.Now()
+	result, err := s.collection.UpdateOne(ctx, filter, update, s.updateOptions())
+	s.stats.observe(sent)
+	if err != nil {
+		return fmt.Errorf("set payload of lease %q: %w", s.leaseKey, err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("set payload of lease %q for %q: %w", s.leaseKey, holder, ErrLeaseLost)
+	}
+
+	return nil
+}
+
+// LeaderPayload returns the payload set by the current holder of the lease with
+// SetLeaderPayload, or nil if it set none. Returns ErrLeaseNotFound if the
+// lease does not exist.
+func (s *Store) LeaderPayload(ctx context.Context) ([]byte, error) {
+	if err := s.checkContext(ctx, "get payload of"); err != nil {
+		return nil, err
+	}
+	if err := s.requireDefaultDocument("get payload of"); err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		HolderIdentity string         `bson:"holder_identity"`
+		Payload        *leaderPayload `bson:"payload"`
+	}
+	opts := s.findOneOptions().SetProjection(bson.M{"holder_identity": 1, "payload": 1})
+	err := s.readCollection().FindOne(ctx, s.leaseFilter(), opts).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, le.ErrLeaseNotFound
+		}
+		return nil, err
+	}
+
+	if doc.Payload == nil || doc.HolderIdentity == "" || doc.Payload.Holder != doc.HolderIdentity {
+		return nil, nil
+	}
+	return doc.Payload.Data, nil
+}
@@ -0,0 +1,87 @@
+package mongoleasestore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderPayload(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", 300*time.Millisecond)
+	require.NoError(t, err)
+
+	payload, err := store.LeaderPayload(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, payload)
+
+	require.NoError(t, store.SetLeaderPayload(ctx, "candidate-1", []byte("10.0.0.1:8080")))
+	payload, err = store.LeaderPayload(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("10.0.0.1:8080"), payload)
+
+	err = store.SetLeaderPayload(ctx, "candidate-2", []byte("10.0.0.2:8080"))
+	require.ErrorIs(t, err, ErrLeaseLost, "Only the holder should set the payload")
+
+	time.Sleep(400 * time.Millisecond) // Let the lease expire.
+	now := time.Now()
+	require.NoError(t, store.UpdateLease(ctx, &le.Lease{
+		HolderIdentity:    "candidate-2",
+		AcquireTime:       now,
+		RenewTime:         now,
+		LeaseDuration:     time.Minute,
+		LeaderTransitions: 1,
+	}))
+	payload, err = store.LeaderPayload(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, payload, "Previous holder's payload should not be reported")
+}
+
+func TestMaxPayloadBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		size    int
+		wantErr error
+	}{
+		{name: "default cap", size: defaultMaxPayloadBytes + 1, wantErr: ErrPayloadTooLarge},
+		{name: "custom cap", opts: []Option{WithMaxPayloadBytes(16)}, size: 17, wantErr: ErrPayloadTooLarge},
+		{name: "within cap", opts: []Option{WithMaxPayloadBytes(16)}, size: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			collection := newFakeCollection()
+			store := newTestStore(t, collection, tt.opts...)
+
+			err := store.SetLeaderPayload(context.Background(), "candidate-1", bytes.Repeat([]byte("x"), tt.size))
+			if tt.wantErr == nil {
+				// The fake holds no lease.
+				require.ErrorIs(t, err, ErrLeaseLost)
+				return
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Zero(t, collection.callCount("UpdateOne"), "Oversized payload should not be written")
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// addUpdatePreconditions narrows filter to the stored states newLease may be
+// written over, according to the configured checks. Reports whether any was
+// added.
+func (s *Store) addUpdatePreconditions(filter bson.M, newLease *le.Lease) bool {
+	if newLease.HolderIdentity == "" {
+		// Releases are never checked.
+		return false
+	}
+
+	conditioned := false
+	if s.coolingDown() {
+		// Only renews are allowed during the cool-down.
+		filter["holder_identity"] = newLease.HolderIdentity
+		conditioned = true
+	}
+	if s.enforceConsistentDuration {
+		filter["lease_duration"] = newLease.LeaseDuration
+		conditioned = true
+	}
+
+	// The checks below only apply to the holder's own renews.
+	takeover := bson.M{"holder_identity": bson.M{"$ne": newLease.HolderIdentity}}
+	var renewChecks bson.A
+	if s.strictRegion {
+		// Renews must come from the region the lease was acquired in.
+		renewChecks = append(renewChecks, bson.M{"$or": bson.A{takeover, bson.M{"region": s.region}}})
+	}
+	if s.minRenewInterval > 0 {
+		renewChecks = append(renewChecks, bson.M{"$or": bson.A{takeover, s.minRenewIntervalElapsed()}})
+	}
+	if s.durationReconciliation == DurationError {
+		renewChecks = append(renewChecks, bson.M{"$or": bson.A{takeover, bson.M{"lease_duration": newLease.LeaseDuration}}})
+	}
+	if len(renewChecks) > 0 {
+		filter["$and"] = renewChecks
+		conditioned = true
+	}
+
+	return conditioned
+}
+
+// addExpectedState narrows filter to the stored states UpdateLease may write
+// newLease over: leader_transitions is the version of the lease holder, kept
+// by renews and releases and incremented by takeovers. A takeover also needs
+// the lease to be released or expired by the server's clock, so that a renew
+// by the holder after the challenger read the lease makes it miss: otherwise
+// both would lead until the holder's next renew. A lease renewed by its own
+// holder in between still matches a renew or a release. Only the low 32 bits
+// of leader_transitions are compared, le.Lease carrying no more.
+func (s *Store) addExpectedState(filter bson.M, newLease *le.Lease) {
+	transitions := newLease.LeaderTransitions
+
+	var expected bson.M
+	if !newLease.HasHolder() {
+		expected = transitionsMatch(transitions)
+	} else {
+		expected = bson.M{"$or": bson.A{
+			bson.M{"$and": bson.A{bson.M{"holder_identity": newLease.HolderIdentity}, transitionsMatch(transitions)}},
+			bson.M{"$and": bson.A{
+				bson.M{"holder_identity": bson.M{"$ne": newLease.HolderIdentity}},
+				// The low bits wrap around along with the count.
+				transitionsMatch(transitions - 1),
+				bson.M{"$or": bson.A{bson.M{"holder_identity": ""}, expiredByServer()}},
+			}},
+		}}
+	}
+
+	clauses, _ := filter["$and"].(bson.A)
+	filter["$and"] = append(clauses, expected)
+}
+
+// transitionsModulus is the modulus of the leader transitions carried by
+// le.Lease, a uint32.
+const transitionsModulus = int64(1) << 32
+
+// transitionsMatch matches a leader_transitions whose low 32 bits are n.
+// Minimal documents omit it when zero.
+func transitionsMatch(n uint32) bson.M {
+	match := bson.M{"leader_transitions": bson.M{"$mod": bson.A{transitionsModulus, int64(n)}}}
+	if n == 0 {
+		return bson.M{"$or": bson.A{match, bson.M{"leader_transitions": bson.M{"$exists": false}}}}
+	}
+	return match
+}
+
+// transitionsUpdate is the leader_transitions written by an update carrying
+// the low 32 bits n of the count: the least count not below the stored one
+// with these low bits. Renews and releases keep the stored count and
+// takeovers increment it, past 2^32 included, so that it never goes
+// backwards.
+func transitionsUpdate(n uint32) bson.M {
+	stored := bson.M{"$ifNull": bson.A{"$leader_transitions", int64(0)}}
+	// (n - stored) mod 2^32, kept positive.
+	ahead := bson.M{"$mod": bson.A{
+		bson.M{"$add": bson.A{
+			bson.M{"$subtract": bson.A{int64(n), bson.M{"$mod": bson.A{stored, transitionsModulus}}}},
+			transitionsModulus,
+		}},
+		transitionsModulus,
+	}}
+	return bson.M{"$add": bson.A{stored, ahead}}
+}
+
+// storedTransitions is the leader_transitions written by transitionsUpdate(n)
+// over the pre-image before, nil if there was none.
+func storedTransitions(before *leaseDocument, n uint32) uint64 {
+	if before == nil {
+		return uint64(n)
+	}
+	return before.LeaderTransitions + uint64(n-uint32(before.LeaderTransitions))
+}
+
+// expiredByServer matches a lease past renew_time + lease_duration according
+// to the server's clock. Neither the grace period nor an expiry predicate is
+// applied: they delay takeovers on the client side.
+func expiredByServer() bson.M {
+	return bson.M{"$expr": bson.M{"$lte": bson.A{
+		// lease_duration is stored in nanoseconds, dates are added in milliseconds.
+		bson.M{"$add": bson.A{"$renew_time", bson.M{"$divide": bson.A{"$lease_duration", int64(time.Millisecond)}}}},
+		"$$NOW",
+	}}}
+}
+
+// minRenewIntervalElapsed matches a lease renewed at least the minimum renew
+// interval ago according to the server's clock.
+func (s *Store) minRenewIntervalElapsed() bson.M {
+	return bson.M{"$expr": bson.M{"$gte": bson.A{
+		bson.M{"$subtract": bson.A{"$$NOW", "$renew_time"}}, // In milliseconds.
+		s.minRenewInterval.Milliseconds(),
+	}}}
+}
+
+// renewTooSoon explains a renew by holder that matched nothing although the
+// minimum renew interval is set: if holder still holds the lease, the renew
+// came too soon. Returns nil otherwise.
+func (s *Store) renewTooSoon(ctx context.Context, holder string) error {
+	filter := s.leaseFilter()
+	filter["holder_identity"] = holder
+	count, err := s.collection.CountDocuments(ctx, filter, s.countOptions())
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	return s.errRenewTooSoon(holder)
+}
+
+func (s *Store) errRenewTooSoon(holder string) error {
+	return fmt.Errorf("%w: lease %q was renewed by %q less than %s ago",
+		ErrRenewTooSoon, s.leaseKey, holder, s.minRenewInterval)
+}
+
+// explainUpdateMiss explains why an update narrowed by addUpdatePreconditions
+// matched nothing: ErrDurationMismatch, ErrRegionMismatch or ErrRenewTooSoon
+// if the stored lease violates a precondition, ErrLeaseNotFound if there is no lease and
+// ErrLeaseConflict if the lease changed in between.
+func (s *Store) explainUpdateMiss(ctx context.Context, newLease *le.Lease) error {
+	var doc leaseDocument
+	err := s.collection.FindOne(ctx, s.leaseFilter(), s.findOneOptions()).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return le.ErrLeaseNotFound
+		}
+		return err
+	}
+
+	renew := doc.HolderIdentity == newLease.HolderIdentity
+	if !renew && s.coolingDown() {
+		return s.errTransitionCooldown(newLease.HolderIdentity)
+	}
+	if (s.enforceConsistentDuration || s.durationReconciliation == DurationError && renew) &&
+		doc.LeaseDuration != newLease.LeaseDuration {
+		return fmt.Errorf("%w: lease %q is stored with duration %s but %q requested %s",
+			ErrDurationMismatch, s.leaseKey, doc.LeaseDuration, newLease.HolderIdentity, newLease.LeaseDuration)
+	}
+
+	if s.strictRegion && renew && doc.Region != s.region {
+		return fmt.Errorf("%w: lease %q was acquired by %q in region %q but renewed from region %q",
+			ErrRegionMismatch, s.leaseKey, newLease.HolderIdentity, doc.Region, s.region)
+	}
+
+	if s.minRenewInterval > 0 && renew {
+		// The only other check a renew can fail.
+		return s.errRenewTooSoon(newLease.HolderIdentity)
+	}
+
+	return ErrLeaseConflict
+}
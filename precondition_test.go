@@ -0,0 +1,152 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnforceConsistentDuration(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	retryPeriod := 200 * time.Millisecond
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithEnforceConsistentDuration())
+	require.NoError(t, err, "Failed to create store")
+
+	run := func(candidateID string, leaseDuration time.Duration) leaderAndCnl {
+		elector, err := le.NewElector(le.ElectorConfig{
+			LeaseDuration:   leaseDuration,
+			RetryPeriod:     retryPeriod,
+			LeaseStore:      store,
+			CandidateID:     candidateID,
+			ReleaseOnCancel: true,
+		})
+		require.NoError(t, err, "Failed to create elector")
+		ctx, cancel := context.WithCancel(context.Background())
+		return leaderAndCnl{cancel: cancel, elector: elector, done: elector.Run(ctx)}
+	}
+
+	first := run("candidate-1", time.Second)
+	require.Eventually(t, first.elector.IsLeader, 2*time.Second, 50*time.Millisecond, "First elector should lead")
+
+	// Releasing keeps the stored duration of the first elector.
+	first.cancel()
+	<-first.done
+
+	second := run("candidate-2", 2*time.Second)
+	defer func() {
+		second.cancel()
+		<-second.done
+	}()
+	assert.Never(t, second.elector.IsLeader, 4*retryPeriod, 50*time.Millisecond,
+		"Elector with a different duration should not acquire the lease")
+
+	now := time.Now()
+	err = store.UpdateLease(context.Background(), &le.Lease{
+		HolderIdentity: "candidate-2",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  2 * time.Second,
+	})
+	require.ErrorIs(t, err, ErrDurationMismatch)
+}
+
+func TestStrictRegion(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	newStore := func(region string) *Store {
+		store, err := NewStore(Args{
+			LeaseCollection: collection,
+			LeaseKey:        "test-lease-key",
+		}, WithRegion(region), WithStrictRegion())
+		require.NoError(t, err, "Failed to create store")
+		return store
+	}
+	euStore := newStore("eu-west-1")
+	usStore := newStore("us-east-1")
+
+	lease := func(holder string) *le.Lease {
+		// Expired, so that candidate-2 may take it over.
+		now := time.Now().Add(-time.Minute)
+		return &le.Lease{
+			HolderIdentity: holder,
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  time.Minute,
+		}
+	}
+
+	require.NoError(t, euStore.CreateLease(ctx, lease("candidate-1")))
+	require.NoError(t, euStore.UpdateLease(ctx, lease("candidate-1")), "Renew from the same region should succeed")
+
+	err := usStore.UpdateLease(ctx, lease("candidate-1"))
+	require.ErrorIs(t, err, ErrRegionMismatch, "Renew from another region should be rejected")
+
+	leases, err := usStore.ListLeases(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, "eu-west-1", leases[0].Region, "Rejected renew should not restamp the region")
+
+	takeover := lease("candidate-2")
+	takeover.LeaderTransitions = 1
+	require.NoError(t, usStore.UpdateLease(ctx, takeover), "Takeover from another region should succeed")
+	leases, err = usStore.ListLeases(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, "us-east-1", leases[0].Region)
+}
+
+func TestMinRenewInterval(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithMinRenewInterval(time.Minute))
+	require.NoError(t, err, "Failed to create store")
+
+	lease := func(holder string) *le.Lease {
+		now := time.Now()
+		return &le.Lease{
+			HolderIdentity: holder,
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  time.Minute,
+		}
+	}
+
+	_, err = store.AcquireLease(ctx, "candidate-1", 300*time.Millisecond)
+	require.NoError(t, err)
+
+	_, err = store.AcquireLease(ctx, "candidate-1", 300*time.Millisecond)
+	require.ErrorIs(t, err, ErrRenewTooSoon, "Rapid renew by AcquireLease should be rejected")
+
+	err = store.UpdateLease(ctx, lease("candidate-1"))
+	require.ErrorIs(t, err, ErrRenewTooSoon, "Rapid renew by UpdateLease should be rejected")
+
+	err = store.Touch(ctx, "candidate-1")
+	require.ErrorIs(t, err, ErrRenewTooSoon, "Rapid renew by Touch should be rejected")
+
+	time.Sleep(400 * time.Millisecond) // Let the lease expire.
+	takeover := lease("candidate-2")
+	takeover.LeaderTransitions = 1
+	require.NoError(t, store.UpdateLease(ctx, takeover), "Takeovers should not be limited")
+}
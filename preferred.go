@@ -0,0 +1,114 @@
+package mongoleasestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// WithYieldToPreferred makes holders step down in favor of the preferred
+// holder named with SetPreferredHolder. A renew by another holder, through
+// AcquireLease, UpdateLease, UpdateLeaseCAS or Touch, then releases the lease
+// instead and fails with ErrLeaseYielded. Until the released lease expires,
+// only the preferred holder can acquire it with AcquireLease or UpdateLease,
+// so that the holder that yielded does not take it back; if the preferred
+// holder does not show up, others take over once it expires as usual.
+//
+// Every renew costs an extra write to check the hint. Every store of the lease
+// should be configured alike.
+func WithYieldToPreferred() Option {
+	return func(s *Store) {
+		s.yieldToPreferred = true
+	}
+}
+
+// SetPreferredHolder names holder as the preferred holder of the lease, which
+// stores configured with WithYieldToPreferred steer leadership to. An empty
+// holder clears the hint. The hint is kept until changed, whoever holds the
+// lease. Returns ErrLeaseNotFound if the lease does not exist.
+func (s *Store) SetPreferredHolder(ctx context.Context, holder string) error {
+	if err := s.checkContext(ctx, "set preferred holder of"); err != nil {
+		return err
+	}
+	if err := s.requireDefaultDocument("set preferred holder of"); err != nil {
+		return err
+	}
+
+	defer s.readCache.invalidate()
+
+	update := bson.M{"$set": bson.M{"preferred_holder": holder}}
+	if holder == "" {
+		update = bson.M{"$unset": bson.M{"preferred_holder": ""}}
+	}
+
+	sent := time.Now()
+	result, err := s.collection.UpdateOne(ctx, s.leaseFilter(), update, s.updateOptions())
+	s.stats.observe(sent)
+	if err != nil {
+		return fmt.Errorf("set preferred holder of lease %q: %w", s.leaseKey, err)
+	}
+	if result.MatchedCount == 0 {
+		return le.ErrLeaseNotFound
+	}
+
+	return nil
+}
+
+// yieldIfNotPreferred releases the lease for op if holder holds it while the
+// preferred holder is someone else, and returns ErrLeaseYielded then. Returns
+// nil, writing nothing, otherwise or without WithYieldToPreferred.
+func (s *Store) yieldIfNotPreferred(ctx context.Context, op, holder string) error {
+	if !s.yieldToPreferred || holder == "" {
+		return nil
+	}
+
+	filter := s.leaseFilter()
+	filter["holder_identity"] = holder
+	filter["preferred_holder"] = bson.M{"$exists": true, "$nin": bson.A{"", holder}}
+	// renew_time is kept, so that the released lease expires when it would
+	// have and the preferred holder has until then to acquire it.
+	update := bson.M{"$set": bson.M{"holder_identity": ""}}
+
+	sent := time.Now()
+	result, err := s.collection.UpdateOne(ctx, filter, update, s.updateOptions())
+	s.stats.observe(sent)
+	if err != nil {
+		return fmt.Errorf("%s lease %q: %w", op, s.leaseKey, err)
+	}
+	if result.MatchedCount == 0 {
+		return nil
+	}
+
+	s.readCache.invalidate()
+	s.audit(ctx, op, "", ReasonRelease)
+	s.recordDecision(op, holder, DecisionReleased)
+	return fmt.Errorf("%s lease %q for %q: %w: %w", op, s.leaseKey, holder, ErrLeaseYielded, ErrLeaseLost)
+}
+
+// addPreferenceCondition narrows filter, under WithYieldToPreferred, to the
+// leases candidate may acquire, see preferredOrExpired.
+func (s *Store) addPreferenceCondition(ctx context.Context, filter bson.M, candidate string) error {
+	expiredClause, err := s.expiredFilter(ctx, s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	clauses, _ := filter["$and"].(bson.A)
+	filter["$and"] = append(clauses, s.preferredOrExpired(candidate, expiredClause))
+	return nil
+}
+
+// preferredOrExpired matches a lease candidate may acquire without waiting for
+// it to expire, under WithYieldToPreferred: one whose preferred holder is
+// unset or candidate, or which candidate already holds. expiredClause matches
+// an expired lease.
+func (s *Store) preferredOrExpired(candidate string, expiredClause bson.M) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"preferred_holder": bson.M{"$in": bson.A{nil, "", candidate}}},
+		bson.M{"holder_identity": candidate},
+		expiredClause,
+	}}
+}
@@ -0,0 +1,55 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYieldToPreferred(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithYieldToPreferred())
+	require.NoError(t, err, "Failed to create store")
+
+	require.ErrorIs(t, store.SetPreferredHolder(ctx, "candidate-2"), le.ErrLeaseNotFound)
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, store.SetPreferredHolder(ctx, "candidate-1"))
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.NoError(t, err, "The preferred holder should keep renewing")
+
+	require.NoError(t, store.SetPreferredHolder(ctx, "candidate-2"))
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.ErrorIs(t, err, ErrLeaseYielded, "The leader should yield to the preferred holder")
+	require.ErrorIs(t, err, ErrLeaseLost)
+
+	lease, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.False(t, lease.HasHolder(), "The lease should be released")
+
+	_, err = store.AcquireLease(ctx, "candidate-1", time.Minute)
+	require.ErrorIs(t, err, ErrLeaseHeld, "The yielded lease should be kept for the preferred holder")
+	now := time.Now()
+	err = store.UpdateLease(ctx, &le.Lease{HolderIdentity: "candidate-3", AcquireTime: now, RenewTime: now, LeaseDuration: time.Minute})
+	require.ErrorIs(t, err, ErrLeaseConflict, "The yielded lease should be kept for the preferred holder")
+
+	_, err = store.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.NoError(t, err, "The preferred holder should acquire the yielded lease")
+	require.NoError(t, store.Touch(ctx, "candidate-2"))
+
+	require.NoError(t, store.SetPreferredHolder(ctx, "candidate-1"))
+	require.ErrorIs(t, store.Touch(ctx, "candidate-2"), ErrLeaseYielded, "Touch should yield too")
+}
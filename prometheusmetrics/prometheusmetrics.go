@@ -0,0 +1,172 @@
+// Package prometheusmetrics exports the lease operations of mongoleasestore
+// stores as Prometheus metrics:
+//
+//	store, err := mongoleasestore.NewStore(args,
+//		prometheusmetrics.WithPrometheusRegisterer(prometheus.DefaultRegisterer))
+package prometheusmetrics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rbroggi/mongoleasestore"
+)
+
+var (
+	_ mongoleasestore.Metrics            = (*Metrics)(nil)
+	_ mongoleasestore.TransitionObserver = (*Metrics)(nil)
+	_ mongoleasestore.ExpiryObserver     = (*Metrics)(nil)
+	_ mongoleasestore.LateRenewObserver  = (*Metrics)(nil)
+	_ mongoleasestore.FlappingObserver   = (*Metrics)(nil)
+)
+
+// Metrics is a mongoleasestore.Metrics exporting the lease operations of one
+// or more stores as Prometheus metrics labeled with their lease keys, the
+// same as mongoleasestore.NewSinkMetrics records:
+//
+//   - mongoleasestore_operations_total, the operations by op.
+//   - mongoleasestore_operation_errors_total, the failed operations by op and
+//     class, see mongoleasestore.ErrorClass.
+//   - mongoleasestore_operation_duration_seconds, the latencies by op.
+//   - mongoleasestore_leader_transitions_total, the acquisitions and
+//     takeovers written.
+//   - mongoleasestore_lease_expiry_timestamp_seconds, the Unix time the lease
+//     last read or written expires at, zero when it is not held.
+//   - mongoleasestore_late_renew_margin_seconds, the margin of late renews,
+//     see mongoleasestore.WithLateRenewThreshold.
+//   - mongoleasestore_flapping_total, the times flapping was signaled, see
+//     mongoleasestore.WithMaxTransitionRate.
+//
+// Counting transitions costs a findAndModify per update, see
+// mongoleasestore.TransitionObserver.
+type Metrics struct {
+	operations  *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+	durations   *prometheus.HistogramVec
+	transitions *prometheus.CounterVec
+	expiry      *prometheus.GaugeVec
+	lateRenews  *prometheus.HistogramVec
+	flapping    *prometheus.CounterVec
+}
+
+// New creates an unregistered Metrics.
+func New() *Metrics {
+	return &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongoleasestore_operations_total",
+			Help: "Lease operations by lease key and operation.",
+		}, []string{"lease_key", "op"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongoleasestore_operation_errors_total",
+			Help: "Failed lease operations by lease key, operation and error class.",
+		}, []string{"lease_key", "op", "class"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mongoleasestore_operation_duration_seconds",
+			Help:    "Latency of lease operations by lease key and operation.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+		}, []string{"lease_key", "op"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongoleasestore_leader_transitions_total",
+			Help: "Acquisitions and takeovers of the lease written by the store.",
+		}, []string{"lease_key"}),
+		expiry: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mongoleasestore_lease_expiry_timestamp_seconds",
+			Help: "Unix time the lease last read or written expires at, zero when it is not held.",
+		}, []string{"lease_key"}),
+		lateRenews: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mongoleasestore_late_renew_margin_seconds",
+			Help:    "Time left before the lease expired when it was renewed late.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+		}, []string{"lease_key"}),
+		flapping: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongoleasestore_flapping_total",
+			Help: "Times the leadership of the lease was signaled as flapping.",
+		}, []string{"lease_key"}),
+	}
+}
+
+// WithPrometheusRegisterer exports the metrics of a store's lease operations
+// to Prometheus through a Metrics registered with reg, which NewStore
+// registers once per registerer and stores share. It replaces the hook set
+// with mongoleasestore.WithMetrics. Counting transitions costs a findAndModify
+// per update, see mongoleasestore.TransitionObserver.
+func WithPrometheusRegisterer(reg prometheus.Registerer) mongoleasestore.Option {
+	return mongoleasestore.WithMetricsProvider(func() (mongoleasestore.Metrics, error) {
+		return Register(reg)
+	})
+}
+
+// Register registers a Metrics with reg, or returns the one already
+// registered there, so that the stores of a process can share it.
+func Register(reg prometheus.Registerer) (*Metrics, error) {
+	metrics := New()
+	if err := reg.Register(metrics); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			return nil, fmt.Errorf("register prometheus metrics: %w", err)
+		}
+		existing, ok := registered.ExistingCollector.(*Metrics)
+		if !ok {
+			return nil, fmt.Errorf("register prometheus metrics: %w", err)
+		}
+		metrics = existing
+	}
+	return metrics, nil
+}
+
+// ObserveOperation implements mongoleasestore.Metrics.
+func (m *Metrics) ObserveOperation(leaseKey, op string, latency time.Duration, err error) {
+	m.operations.WithLabelValues(leaseKey, op).Inc()
+	m.durations.WithLabelValues(leaseKey, op).Observe(latency.Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(leaseKey, op, mongoleasestore.ErrorClass(err)).Inc()
+	}
+}
+
+// ObserveTransition implements mongoleasestore.TransitionObserver.
+func (m *Metrics) ObserveTransition(leaseKey, _ string) {
+	m.transitions.WithLabelValues(leaseKey).Inc()
+}
+
+// ObserveExpiry implements mongoleasestore.ExpiryObserver.
+func (m *Metrics) ObserveExpiry(leaseKey string, expiresAt time.Time) {
+	var timestamp float64
+	if !expiresAt.IsZero() {
+		timestamp = float64(expiresAt.UnixMilli()) / 1e3
+	}
+	m.expiry.WithLabelValues(leaseKey).Set(timestamp)
+}
+
+// ObserveLateRenew implements mongoleasestore.LateRenewObserver.
+func (m *Metrics) ObserveLateRenew(leaseKey, _ string, margin time.Duration) {
+	m.lateRenews.WithLabelValues(leaseKey).Observe(margin.Seconds())
+}
+
+// ObserveFlapping implements mongoleasestore.FlappingObserver.
+func (m *Metrics) ObserveFlapping(leaseKey string, _ int, _ time.Duration) {
+	m.flapping.WithLabelValues(leaseKey).Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.operations.Describe(ch)
+	m.errors.Describe(ch)
+	m.durations.Describe(ch)
+	m.transitions.Describe(ch)
+	m.expiry.Describe(ch)
+	m.lateRenews.Describe(ch)
+	m.flapping.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.operations.Collect(ch)
+	m.errors.Collect(ch)
+	m.durations.Collect(ch)
+	m.transitions.Collect(ch)
+	m.expiry.Collect(ch)
+	m.lateRenews.Collect(ch)
+	m.flapping.Collect(ch)
+}
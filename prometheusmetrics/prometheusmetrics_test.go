@@ -0,0 +1,74 @@
+package prometheusmetrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	le "github.com/rbroggi/leaderelection"
+	"github.com/rbroggi/mongoleasestore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	first, err := Register(registry)
+	require.NoError(t, err)
+	second, err := Register(registry)
+	require.NoError(t, err, "Stores should share the registered collector")
+	assert.Same(t, first, second)
+}
+
+func TestWithPrometheusRegisterer(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	newStore := func(key string) *mongoleasestore.Store {
+		store, err := mongoleasestore.NewStore(mongoleasestore.Args{LeaseKey: key}, WithPrometheusRegisterer(registry))
+		require.NoError(t, err, "Stores should share the registered collector")
+		return store
+	}
+	first := newStore("lease-1")
+	second := newStore("lease-2")
+	require.IsType(t, &Metrics{}, first.Config().Metrics)
+	assert.Same(t, first.Config().Metrics, second.Config().Metrics)
+
+	conflicting := prometheus.NewRegistry()
+	require.NoError(t, conflicting.Register(prometheus.NewGauge(prometheus.GaugeOpts{Name: "mongoleasestore_operations_total"})))
+	_, err := mongoleasestore.NewStore(mongoleasestore.Args{LeaseKey: "lease-1"}, WithPrometheusRegisterer(conflicting))
+	require.Error(t, err, "A failed registration should fail NewStore")
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	metrics, err := Register(registry)
+	require.NoError(t, err)
+
+	metrics.ObserveOperation("lease-1", "get", time.Millisecond, fmt.Errorf("get lease: %w", le.ErrLeaseNotFound))
+	metrics.ObserveOperation("lease-2", "update", time.Millisecond, nil)
+	metrics.ObserveOperation("lease-2", "update", time.Millisecond, mongoleasestore.ErrLeaseConflict)
+	metrics.ObserveTransition("lease-1", "candidate-1")
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	metrics.ObserveExpiry("lease-1", expiresAt)
+	metrics.ObserveExpiry("lease-2", time.Time{})
+	metrics.ObserveLateRenew("lease-1", "candidate-1", 100*time.Millisecond)
+	metrics.ObserveFlapping("lease-1", 4, time.Minute)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.operations.WithLabelValues("lease-1", "get")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.operations.WithLabelValues("lease-2", "update")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.errors.WithLabelValues("lease-1", "get", "not_found")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.errors.WithLabelValues("lease-2", "update", "conflict")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.transitions.WithLabelValues("lease-1")))
+	assert.InDelta(t, float64(expiresAt.UnixMilli())/1e3, testutil.ToFloat64(metrics.expiry.WithLabelValues("lease-1")), 0.001)
+	assert.Zero(t, testutil.ToFloat64(metrics.expiry.WithLabelValues("lease-2")), "An unheld lease should not expire")
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics, "mongoleasestore_late_renew_margin_seconds"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.flapping.WithLabelValues("lease-1")))
+	assert.Equal(t, 1, testutil.CollectAndCount(registry, "mongoleasestore_flapping_total"))
+}
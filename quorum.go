@@ -0,0 +1,119 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// QuorumStore holds a lease replicated over several stores, typically backed
+// by independent MongoDB clusters, so that leadership survives the loss of a
+// minority of them. A candidate leads while it holds the lease in a majority
+// of the stores.
+//
+// The stores are written independently, without a distributed transaction,
+// so the guarantees are weaker than those of a single store: leases acquired
+// at different times on each store expire at different times, a rollback can
+// fail and leave a minority lease to expire on its own, and a candidate only
+// knows it lost its majority at its next renewal. Two candidates never both
+// hold a majority at once as long as every store enforces expiry with the
+// same duration; callers should still renew well within the lease duration
+// and use the same duration for every acquisition.
+type QuorumStore struct {
+	stores []*Store
+}
+
+// NewQuorumStore returns a QuorumStore over stores, which should each hold the
+// lease in a different cluster. An odd number of stores is recommended, as an
+// even one tolerates no more failures than the odd number below it.
+func NewQuorumStore(stores ...*Store) (*QuorumStore, error) {
+	if len(stores) == 0 {
+		return nil, errors.New("quorum store: no stores")
+	}
+	for i, store := range stores {
+		if store == nil {
+			return nil, fmt.Errorf("quorum store: store %d is nil", i)
+		}
+	}
+	return &QuorumStore{stores: stores}, nil
+}
+
+// Quorum returns the number of stores a candidate must hold the lease in to
+// lead, a strict majority.
+func (q *QuorumStore) Quorum() int {
+	return len(q.stores)/2 + 1
+}
+
+// AcquireLease acquires, or renews, the lease for candidate in every store
+// concurrently with Store.AcquireLease. The results are indexed like the
+// stores, nil where the acquisition failed.
+//
+// If fewer than Quorum stores were acquired, the acquired ones are released so
+// that the candidate does not hold a minority blocking the others, and an
+// error wrapping ErrNoQuorum and the failures of every store is returned.
+// Failures of a minority of stores are not reported.
+func (q *QuorumStore) AcquireLease(ctx context.Context, candidate string, duration time.Duration, opts ...AcquireOption) ([]*AcquireResult, error) {
+	results := make([]*AcquireResult, len(q.stores))
+	errs := make([]error, len(q.stores))
+	var wg sync.WaitGroup
+	for i, store := range q.stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = store.AcquireLease(ctx, candidate, duration, opts...)
+		}()
+	}
+	wg.Wait()
+
+	acquired := 0
+	for _, err := range errs {
+		if err == nil {
+			acquired++
+		}
+	}
+	if acquired >= q.Quorum() {
+		return results, nil
+	}
+
+	// Rolled back even if ctx is done, so that the minority does not block
+	// other candidates until it expires.
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), duration)
+	defer cancel()
+	rollbackErrs := q.release(rollbackCtx, candidate, func(i int) bool { return errs[i] == nil })
+
+	return nil, fmt.Errorf("%w: acquired %d of %d stores for %q: %w",
+		ErrNoQuorum, acquired, len(q.stores), candidate, errors.Join(append(errs, rollbackErrs)...))
+}
+
+// Release gives up the lease in every store where candidate holds it, leaving
+// the others untouched, missing leases included. Returns the failures of every store, joined.
+func (q *QuorumStore) Release(ctx context.Context, candidate string) error {
+	return q.release(ctx, candidate, func(int) bool { return true })
+}
+
+// release releases the lease held by candidate in the stores selected by
+// include, concurrently.
+func (q *QuorumStore) release(ctx context.Context, candidate string, include func(i int) bool) error {
+	errs := make([]error, len(q.stores))
+	var wg sync.WaitGroup
+	for i, store := range q.stores {
+		if !include(i) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := store.releaseLease(ctx, candidate)
+			if err != nil && !errors.Is(err, le.ErrLeaseNotFound) {
+				errs[i] = fmt.Errorf("release lease %q for %q: %w", store.leaseKey, candidate, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// unavailableCollection fails acquisitions, as a cluster that cannot be
+// reached would.
+type unavailableCollection struct {
+	leaseCollection
+}
+
+func (unavailableCollection) FindOneAndUpdate(context.Context, interface{}, interface{}, ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	return mongo.NewSingleResultFromDocument(bson.D{}, errors.New("cluster unavailable"), nil)
+}
+
+func TestQuorumStore(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	ctx := context.Background()
+
+	newStores := func(t *testing.T, unavailable int) []*Store {
+		stores := make([]*Store, 3)
+		for i := range stores {
+			collection := mongoClient.Database(t.Name()).Collection(fmt.Sprintf("cluster-%d", i))
+			store, err := NewStore(Args{LeaseCollection: collection, LeaseKey: "test-lease-key"})
+			require.NoError(t, err, "Failed to create store")
+			if i < unavailable {
+				store.collection = unavailableCollection{leaseCollection: store.collection}
+			}
+			stores[i] = store
+		}
+		return stores
+	}
+
+	t.Run("majority", func(t *testing.T) {
+		stores := newStores(t, 1)
+		quorum, err := NewQuorumStore(stores...)
+		require.NoError(t, err)
+		assert.Equal(t, 2, quorum.Quorum())
+
+		results, err := quorum.AcquireLease(ctx, "candidate-1", time.Minute)
+		require.NoError(t, err, "Leadership should be granted by the majority")
+		assert.Nil(t, results[0], "The unavailable store should not be acquired")
+		assert.NotNil(t, results[1])
+		assert.NotNil(t, results[2])
+
+		_, err = quorum.AcquireLease(ctx, "candidate-2", time.Minute)
+		require.ErrorIs(t, err, ErrNoQuorum, "Another candidate should not lead")
+
+		require.NoError(t, quorum.Release(ctx, "candidate-1"))
+		_, err = quorum.AcquireLease(ctx, "candidate-2", time.Minute)
+		require.NoError(t, err, "Released lease should be acquirable")
+	})
+
+	t.Run("minority", func(t *testing.T) {
+		stores := newStores(t, 2)
+		quorum, err := NewQuorumStore(stores...)
+		require.NoError(t, err)
+
+		_, err = quorum.AcquireLease(ctx, "candidate-1", time.Minute)
+		require.ErrorIs(t, err, ErrNoQuorum, "Leadership should need a majority")
+
+		lease, err := stores[2].GetLease(ctx)
+		require.NoError(t, err)
+		assert.False(t, lease.HasHolder(), "The minority acquisition should be rolled back")
+	})
+}
+
+func TestNewQuorumStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewQuorumStore()
+	require.Error(t, err, "A quorum of no stores should be rejected")
+	_, err = NewQuorumStore(nil)
+	require.Error(t, err, "Nil stores should be rejected")
+}
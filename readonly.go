@@ -0,0 +1,61 @@
+package mongoleasestore
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithReadOnly makes the store an observer of the lease: it can read and
+// watch it, but every write, from renews to admin operations, Bootstrap and
+// index creation included, fails with ErrReadOnly without contacting MongoDB.
+// It is meant for components that need to know who leads and must never take
+// part in the election by accident. The TTL index of Args.ExpireAfter is not
+// ensured.
+func WithReadOnly() Option {
+	return func(s *Store) {
+		s.readOnly = true
+	}
+}
+
+// applyReadOnly makes the lease collection refuse writes if WithReadOnly is
+// set. It must run after applyCollectionGuard, so that a dropped collection is
+// not recreated by a refused write.
+func (s *Store) applyReadOnly() {
+	if !s.readOnly {
+		return
+	}
+	s.collection = &readOnlyCollection{leaseCollection: s.collection}
+}
+
+// readOnlyCollection fails the writes to the wrapped collection with
+// ErrReadOnly and passes reads through.
+type readOnlyCollection struct {
+	leaseCollection
+}
+
+func (c *readOnlyCollection) InsertOne(context.Context, interface{}, ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *readOnlyCollection) UpdateOne(context.Context, interface{}, interface{}, ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *readOnlyCollection) FindOneAndUpdate(context.Context, interface{}, interface{}, ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	return mongo.NewSingleResultFromDocument(bson.D{}, ErrReadOnly, nil)
+}
+
+func (c *readOnlyCollection) ReplaceOne(context.Context, interface{}, interface{}, ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *readOnlyCollection) DeleteOne(context.Context, interface{}, ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *readOnlyCollection) FindOneAndDelete(context.Context, interface{}, ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	return mongo.NewSingleResultFromDocument(bson.D{}, ErrReadOnly, nil)
+}
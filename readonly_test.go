@@ -0,0 +1,47 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	collection := newFakeCollection()
+	writer := newTestStore(t, collection)
+	observer := newTestStore(t, collection, WithReadOnly())
+	assert.True(t, observer.Config().ReadOnly)
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}
+	require.ErrorIs(t, observer.CreateLease(ctx, lease), ErrReadOnly)
+	require.NoError(t, writer.CreateLease(ctx, lease))
+
+	got, err := observer.GetLease(ctx)
+	require.NoError(t, err, "Reads should be allowed")
+	assert.Equal(t, "candidate-1", got.HolderIdentity)
+
+	lease.HolderIdentity = "candidate-2"
+	require.ErrorIs(t, observer.UpdateLease(ctx, lease), ErrReadOnly)
+	_, err = observer.AcquireLease(ctx, "candidate-2", time.Minute)
+	require.ErrorIs(t, err, ErrReadOnly)
+	assert.Equal(t, 1, collection.callCount("InsertOne"), "Refused writes should not reach the collection")
+	assert.Zero(t, collection.callCount("UpdateOne"))
+	assert.Zero(t, collection.callCount("FindOneAndUpdate"))
+
+	got, err = writer.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "candidate-1", got.HolderIdentity, "The lease should be left alone")
+}
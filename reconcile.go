@@ -0,0 +1,60 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DurationReconciliation decides which lease duration UpdateLease writes when a
+// holder renews its lease with a duration that differs from the stored one.
+type DurationReconciliation int
+
+const (
+	// DurationAdoptIncoming writes the renew's duration. It is the default.
+	DurationAdoptIncoming DurationReconciliation = iota
+	// DurationAdoptStored keeps the stored duration. The lease is read before
+	// the renew to learn it, which costs a round trip.
+	DurationAdoptStored
+	// DurationError rejects the renew with ErrDurationMismatch.
+	DurationError
+)
+
+// WithDurationReconciliation sets how UpdateLease reconciles a renew whose
+// lease duration differs from the stored one, see DurationReconciliation.
+// Acquisitions from another holder and releases always write their own
+// duration.
+func WithDurationReconciliation(mode DurationReconciliation) Option {
+	return func(s *Store) {
+		s.durationReconciliation = mode
+	}
+}
+
+// adoptStoredDuration returns newLease with the stored duration if it renews
+// the stored lease with a different one and the store adopts stored durations.
+// filter is then pinned to the state that was read, so that a concurrent change
+// makes the update miss. Reports whether filter was narrowed.
+func (s *Store) adoptStoredDuration(ctx context.Context, filter bson.M, newLease *le.Lease) (*le.Lease, bool, error) {
+	if s.durationReconciliation != DurationAdoptStored || !newLease.HasHolder() {
+		return newLease, false, nil
+	}
+
+	stored, err := s.fetchLease(ctx, s.collection)
+	if errors.Is(err, le.ErrLeaseNotFound) {
+		return newLease, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if stored.HolderIdentity != newLease.HolderIdentity || stored.LeaseDuration == newLease.LeaseDuration {
+		return newLease, false, nil
+	}
+
+	adopted := *newLease
+	adopted.LeaseDuration = stored.LeaseDuration
+	filter["holder_identity"] = stored.HolderIdentity
+	filter["lease_duration"] = stored.LeaseDuration
+	return &adopted, true, nil
+}
@@ -0,0 +1,99 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurationReconciliation(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+
+	tests := []struct {
+		name    string
+		mode    DurationReconciliation
+		want    time.Duration
+		wantErr error
+	}{
+		{name: "adopt incoming", mode: DurationAdoptIncoming, want: 2 * time.Second},
+		{name: "adopt stored", mode: DurationAdoptStored, want: time.Second},
+		{name: "error", mode: DurationError, want: time.Second, wantErr: ErrDurationMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			collection := mongoClient.Database(t.Name()).Collection(t.Name())
+			ctx := context.Background()
+
+			store, err := NewStore(Args{
+				LeaseCollection: collection,
+				LeaseKey:        "test-lease-key",
+			}, WithDurationReconciliation(tt.mode))
+			require.NoError(t, err, "Failed to create store")
+
+			now := time.Now().Add(-time.Minute) // Expired, so that candidate-2 may take it over.
+			lease := func(holder string, duration time.Duration) *le.Lease {
+				return &le.Lease{
+					HolderIdentity: holder,
+					AcquireTime:    now,
+					RenewTime:      now,
+					LeaseDuration:  duration,
+				}
+			}
+			require.NoError(t, store.CreateLease(ctx, lease("candidate-1", time.Second)))
+
+			err = store.UpdateLease(ctx, lease("candidate-1", 2*time.Second))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			stored, err := store.GetLease(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stored.LeaseDuration)
+
+			// Takeovers are not reconciled.
+			takeover := lease("candidate-2", 3*time.Second)
+			takeover.LeaderTransitions = 1
+			require.NoError(t, store.UpdateLease(ctx, takeover))
+			stored, err = store.GetLease(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 3*time.Second, stored.LeaseDuration)
+		})
+	}
+}
+
+func TestDurationReconciliationAdoptStored(t *testing.T) {
+	t.Parallel()
+
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithDurationReconciliation(DurationAdoptStored))
+
+	now := time.Now().Truncate(time.Millisecond)
+	collection.doc = &leaseDocument{
+		ID:             "test-lease-key",
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+
+	renew := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now.Add(time.Millisecond),
+		LeaseDuration:  time.Minute,
+	}
+	require.NoError(t, store.UpdateLease(context.Background(), renew))
+	assert.Equal(t, time.Second, collection.doc.LeaseDuration, "Stored duration should be kept")
+	assert.Equal(t, time.Minute, renew.LeaseDuration, "Caller's lease should not be modified")
+}
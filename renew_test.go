@@ -0,0 +1,96 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnRenew(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var renewed []*le.Lease
+	store := newTestStore(t, newFakeCollection(), WithOnRenew(func(lease *le.Lease) {
+		renewed = append(renewed, lease)
+	}))
+
+	lease := func(holder string, transitions uint32) *le.Lease {
+		// Expired, so that candidate-2 may take it over.
+		now := time.Now().Add(-time.Minute)
+		return &le.Lease{
+			HolderIdentity:    holder,
+			AcquireTime:       now,
+			RenewTime:         now,
+			LeaseDuration:     time.Second,
+			LeaderTransitions: transitions,
+		}
+	}
+
+	err := store.UpdateLease(ctx, lease("candidate-1", 0))
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+	assert.Empty(t, renewed, "Failed updates should not trigger the callback")
+
+	require.NoError(t, store.CreateLease(ctx, lease("candidate-1", 0)))
+	assert.Empty(t, renewed, "Acquisitions should not trigger the callback")
+
+	first := lease("candidate-1", 0)
+	require.NoError(t, store.UpdateLease(ctx, first))
+	second := lease("candidate-1", 0)
+	require.NoError(t, store.UpdateLease(ctx, second))
+	require.Len(t, renewed, 2, "Every renew should trigger the callback")
+	assert.Equal(t, first, renewed[0])
+	assert.Equal(t, second, renewed[1])
+
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)))
+	assert.Len(t, renewed, 2, "Takeovers should not trigger the callback")
+}
+
+func TestOnWrite(t *testing.T) {
+	t.Parallel()
+
+	type write struct {
+		before, after *le.Lease
+		reason        string
+	}
+	ctx := context.Background()
+	var writes []write
+	store := newTestStore(t, newFakeCollection(), WithOnWrite(func(before, after *le.Lease, reason string) {
+		writes = append(writes, write{before: before, after: after, reason: reason})
+	}))
+
+	// Stored with millisecond precision, expired so that candidate-2 may take
+	// it over.
+	now := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	held := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	handedOver := &le.Lease{
+		HolderIdentity:    "candidate-2",
+		AcquireTime:       now.Add(time.Second),
+		RenewTime:         now.Add(time.Second),
+		LeaseDuration:     time.Second,
+		LeaderTransitions: 1,
+	}
+
+	require.NoError(t, store.CreateLease(ctx, held))
+	require.NoError(t, store.UpdateLease(ctx, handedOver))
+
+	require.Len(t, writes, 2)
+	assert.Nil(t, writes[0].before, "Created lease should have no pre-image")
+	assert.Equal(t, held, writes[0].after)
+	assert.Equal(t, ReasonAcquire, writes[0].reason)
+
+	require.NotNil(t, writes[1].before)
+	assert.Equal(t, "candidate-1", writes[1].before.HolderIdentity, "Handover should receive the previous holder's lease")
+	assert.True(t, writes[1].before.RenewTime.Equal(now))
+	assert.Equal(t, handedOver, writes[1].after)
+	assert.Equal(t, ReasonTakeover, writes[1].reason)
+}
@@ -0,0 +1,289 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RetryPolicy configures how the store retries calls to the lease collection
+// that failed with a transient error, see WithRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts bounds the attempts of a call, the first one included.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Each later retry
+	// waits twice as long as the previous one, up to MaxBackoff if positive.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter, in [0, 1], is the fraction of each delay that is randomized, so
+	// that candidates failing together do not retry together.
+	Jitter float64
+	// Budget, if positive, bounds the total delay of the retries of a call. A
+	// call whose next delay would exceed it fails with its last error, so that
+	// retrying never outlasts the lease it is trying to keep.
+	Budget time.Duration
+}
+
+// DefaultRetryPolicy returns a policy riding out a typical replica set
+// election, which takes a few seconds.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Jitter:         0.2,
+		Budget:         3 * time.Second,
+	}
+}
+
+// WithRetryPolicy retries calls to the lease collection that fail with a
+// transient error, such as a network error or a primary stepping down, so
+// that a brief failover does not cost the leader its lease. Errors of the
+// caller's context and of the operation timeout are not retried, and the
+// operation timeout bounds a call and its retries together. By default only
+// the driver's own single retry of reads and writes applies.
+//
+// Writes are only retried when the server refused to run them, as a node that
+// is no longer primary does. A write whose acknowledgement was lost may have
+// been applied: retrying a conditional write, such as UpdateLease,
+// AcquireLease or DeleteLeaseIf, against the state it expected would then
+// miss its own effect and report ErrLeaseConflict or ErrLeaseNotFound, so that
+// the elector would drop a lease it holds. Those are left to the driver's
+// retryable writes, which the server deduplicates.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *Store) {
+		if policy.MaxAttempts < 2 {
+			s.retryPolicy = nil
+			return
+		}
+		s.retryPolicy = &policy
+	}
+}
+
+// backoff returns the delay before the retry following attempt, counted
+// from 1.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(min(p.Jitter, 1) * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// Server error codes of a replica set changing primary or of a node going
+// away, after which the call may succeed on another node.
+var transientErrorCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// Server error codes of a node refusing a write because it is not primary,
+// before running it.
+var notPrimaryErrorCodes = []int{
+	10107, // NotWritablePrimary
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// isUnappliedWrite reports whether err proves that the write it failed was
+// not applied, so that retrying it cannot miss its own effect.
+func isUnappliedWrite(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	for _, code := range notPrimaryErrorCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransient reports whether err is worth retrying: a network error, a
+// retryable write error, or a server error of a failover. Context errors are
+// not transient.
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	if serverErr.HasErrorLabel("RetryableWriteError") {
+		return true
+	}
+	for _, code := range transientErrorCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRetryPolicy wraps the lease collections with the retry policy, if any.
+// It must run after applyMaxConcurrency, so that a call does not hold a slot
+// while backing off, and before applyOperationTimeout.
+func (s *Store) applyRetryPolicy() {
+	if s.retryPolicy == nil {
+		return
+	}
+
+	s.collection = &retryCollection{leaseCollection: s.collection, policy: s.retryPolicy, leaseKey: s.leaseKey, logger: s.logger}
+	if s.staleReads != nil {
+		s.staleReads = &retryCollection{leaseCollection: s.staleReads, policy: s.retryPolicy, leaseKey: s.leaseKey, logger: s.logger}
+	}
+	if s.confirmReads != nil {
+		s.confirmReads = &retryCollection{leaseCollection: s.confirmReads, policy: s.retryPolicy, leaseKey: s.leaseKey, logger: s.logger}
+	}
+}
+
+// retryCollection retries calls to the wrapped collection that fail with a
+// transient error, following policy. Retries are logged to logger, if set.
+type retryCollection struct {
+	leaseCollection
+	policy   *RetryPolicy
+	leaseKey string
+	logger   *slog.Logger
+}
+
+// do runs call until it succeeds, fails with an error that is not retryable,
+// or runs out of attempts, budget or context. It returns the last error.
+func (c *retryCollection) do(ctx context.Context, retryable func(error) bool, call func() error) error {
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if attempt >= c.policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		delay := c.policy.backoff(attempt)
+		if c.policy.Budget > 0 && waited+delay > c.policy.Budget {
+			return err
+		}
+		waited += delay
+		if c.logger != nil {
+			c.logger.DebugContext(ctx, "retrying lease operation",
+				"lease_key", c.leaseKey,
+				"attempt", attempt,
+				"delay", delay,
+				"error", err,
+			)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+func (c *retryCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	var result *mongo.SingleResult
+	_ = c.do(ctx, isTransient, func() error {
+		result = settled(c.leaseCollection.FindOne(ctx, filter, opts...))
+		return result.Err()
+	})
+	return result
+}
+
+func (c *retryCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	var cursor *mongo.Cursor
+	err := c.do(ctx, isTransient, func() (err error) {
+		cursor, err = c.leaseCollection.Find(ctx, filter, opts...)
+		return err
+	})
+	return cursor, err
+}
+
+func (c *retryCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	var count int64
+	err := c.do(ctx, isTransient, func() (err error) {
+		count, err = c.leaseCollection.CountDocuments(ctx, filter, opts...)
+		return err
+	})
+	return count, err
+}
+
+func (c *retryCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	var result *mongo.InsertOneResult
+	err := c.do(ctx, isUnappliedWrite, func() (err error) {
+		result, err = c.leaseCollection.InsertOne(ctx, document, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	var result *mongo.UpdateResult
+	err := c.do(ctx, isUnappliedWrite, func() (err error) {
+		result, err = c.leaseCollection.UpdateOne(ctx, filter, update, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	var result *mongo.SingleResult
+	_ = c.do(ctx, isUnappliedWrite, func() error {
+		result = settled(c.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts...))
+		return result.Err()
+	})
+	return result
+}
+
+func (c *retryCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	var result *mongo.UpdateResult
+	err := c.do(ctx, isUnappliedWrite, func() (err error) {
+		result, err = c.leaseCollection.ReplaceOne(ctx, filter, replacement, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	var result *mongo.DeleteResult
+	err := c.do(ctx, isUnappliedWrite, func() (err error) {
+		result, err = c.leaseCollection.DeleteOne(ctx, filter, opts...)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	var result *mongo.SingleResult
+	_ = c.do(ctx, isUnappliedWrite, func() error {
+		result = settled(c.leaseCollection.FindOneAndDelete(ctx, filter, opts...))
+		return result.Err()
+	})
+	return result
+}
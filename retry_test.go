@@ -0,0 +1,164 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// flakyCollection fails the next failures calls to UpdateOne with err, and
+// counts the calls.
+type flakyCollection struct {
+	*fakeCollection
+
+	mu       sync.Mutex
+	failures int
+	err      error
+	attempts int
+}
+
+func (c *flakyCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	c.mu.Lock()
+	c.attempts++
+	if c.failures > 0 {
+		c.failures--
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.mu.Unlock()
+	return c.fakeCollection.UpdateOne(ctx, filter, update, opts...)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	notWritablePrimary := mongo.CommandError{Code: 10107, Name: "NotWritablePrimary"}
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, Jitter: 0.5}
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		failures int
+		err      mongo.CommandError
+		calls    int
+		wantErr  bool
+	}{
+		{
+			name:     "recovers",
+			policy:   policy,
+			failures: 2,
+			err:      notWritablePrimary,
+			calls:    3,
+		},
+		{
+			name:     "out of attempts",
+			policy:   policy,
+			failures: 3,
+			err:      notWritablePrimary,
+			calls:    3,
+			wantErr:  true,
+		},
+		{
+			name:     "out of budget",
+			policy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, Budget: time.Minute},
+			failures: 1,
+			err:      notWritablePrimary,
+			calls:    1,
+			wantErr:  true,
+		},
+		{
+			name:     "not transient",
+			policy:   policy,
+			failures: 1,
+			err:      mongo.CommandError{Code: 2, Name: "BadValue"},
+			calls:    1,
+			wantErr:  true,
+		},
+		{
+			name:     "write of unknown outcome",
+			policy:   policy,
+			failures: 1,
+			err:      mongo.CommandError{Code: 91, Name: "ShutdownInProgress"},
+			calls:    1,
+			wantErr:  true,
+		},
+		{
+			name:     "disabled",
+			policy:   RetryPolicy{MaxAttempts: 1},
+			failures: 1,
+			err:      notWritablePrimary,
+			calls:    1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			collection := &flakyCollection{fakeCollection: newFakeCollection()}
+			store := newTestStore(t, collection, WithRetryPolicy(tt.policy))
+
+			now := time.Now()
+			lease := &le.Lease{
+				HolderIdentity: "candidate-1",
+				AcquireTime:    now,
+				RenewTime:      now,
+				LeaseDuration:  time.Second,
+			}
+			require.NoError(t, store.CreateLease(ctx, lease))
+
+			collection.mu.Lock()
+			collection.failures, collection.err = tt.failures, tt.err
+			collection.mu.Unlock()
+			lease.RenewTime = now.Add(time.Millisecond)
+			err := store.UpdateLease(ctx, lease)
+			if tt.wantErr {
+				assert.True(t, hasErrorCode(err, int(tt.err.Code)), "UpdateLease should fail with the last error, got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+			collection.mu.Lock()
+			defer collection.mu.Unlock()
+			assert.Equal(t, tt.calls, collection.attempts)
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 300*time.Millisecond, policy.backoff(3), "Backoff should be capped")
+	assert.Equal(t, 300*time.Millisecond, policy.backoff(50), "Backoff should not overflow")
+
+	policy.Jitter = 0.5
+	for attempt := 1; attempt < 5; attempt++ {
+		delay := policy.backoff(attempt)
+		assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+		assert.LessOrEqual(t, delay, 300*time.Millisecond)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isTransient(mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}))
+	assert.True(t, isTransient(mongo.CommandError{Code: 1, Labels: []string{"RetryableWriteError"}}))
+	assert.True(t, isTransient(mongo.CommandError{Labels: []string{"NetworkError"}}))
+	assert.False(t, isTransient(mongo.CommandError{Code: 11000, Name: "DuplicateKey"}))
+	assert.False(t, isTransient(context.DeadlineExceeded))
+	assert.False(t, isTransient(errors.New("boom")))
+	assert.False(t, isTransient(nil))
+}
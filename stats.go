@@ -0,0 +1,98 @@
+package mongoleasestore
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultLatencyWindow is the number of latencies kept by WithLatencyStats
+// when no valid window is given.
+const defaultLatencyWindow = 32
+
+// renewRoundTrips is the minimum number of round trips a suggested renew
+// interval leaves room for.
+const renewRoundTrips = 3
+
+// latencyStats keeps the latencies of the last lease operations and the last
+// lease duration written. A nil *latencyStats is disabled tracking.
+type latencyStats struct {
+	window int
+
+	mu            sync.Mutex
+	samples       []time.Duration // Ring buffer of at most window samples.
+	next          int
+	leaseDuration time.Duration
+}
+
+func newLatencyStats(window int) *latencyStats {
+	return &latencyStats{window: window}
+}
+
+// observe records the latency of an operation started at start.
+func (st *latencyStats) observe(start time.Time) {
+	if st == nil {
+		return
+	}
+
+	latency := time.Since(start)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if len(st.samples) < st.window {
+		st.samples = append(st.samples, latency)
+		return
+	}
+	st.samples[st.next] = latency
+	st.next = (st.next + 1) % st.window
+}
+
+func (st *latencyStats) observeLeaseDuration(duration time.Duration) {
+	if st == nil {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.leaseDuration = duration
+}
+
+// maxLatency returns the highest latency in the window.
+func (st *latencyStats) maxLatency() time.Duration {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var highest time.Duration
+	for _, latency := range st.samples {
+		highest = max(highest, latency)
+	}
+	return highest
+}
+
+// SuggestRenewInterval suggests how often to renew the lease given the
+// latencies observed by the store (see WithLatencyStats) and the last lease
+// duration it wrote. Returns 0 when tracking is disabled or no lease has been
+// written yet.
+func (s *Store) SuggestRenewInterval() time.Duration {
+	if s.stats == nil {
+		return 0
+	}
+
+	s.stats.mu.Lock()
+	duration := s.stats.leaseDuration
+	s.stats.mu.Unlock()
+	if duration <= 0 {
+		return 0
+	}
+
+	return suggestRenewInterval(duration, s.stats.maxLatency())
+}
+
+// suggestRenewInterval renews three times per lease, but no more often than
+// every few round trips of the slowest observed latency. It never exceeds half
+// the lease, so that a failed renew leaves time for another attempt.
+func suggestRenewInterval(duration, latency time.Duration) time.Duration {
+	interval := max(duration/3, renewRoundTrips*latency)
+	return min(interval, duration/2)
+}
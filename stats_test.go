@@ -0,0 +1,65 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestRenewInterval(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		duration time.Duration
+		latency  time.Duration
+		want     time.Duration
+	}{
+		{name: "fast operations", duration: 15 * time.Second, latency: 5 * time.Millisecond, want: 5 * time.Second},
+		{name: "slow operations", duration: 15 * time.Second, latency: 2 * time.Second, want: 6 * time.Second},
+		{name: "operations slower than the lease", duration: 15 * time.Second, latency: 20 * time.Second, want: 7500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := suggestRenewInterval(tt.duration, tt.latency)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, got, tt.duration/2, "Suggestion should leave time for a retry")
+		})
+	}
+
+	t.Run("Store", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		disabled, err := NewStore(Args{LeaseKey: "test-lease-key"})
+		require.NoError(t, err, "Failed to create store")
+		assert.Zero(t, disabled.SuggestRenewInterval(), "No suggestion without stats tracking")
+
+		store := newTestStore(t, newFakeCollection(), WithLatencyStats(4))
+		assert.Zero(t, store.SuggestRenewInterval(), "No suggestion before a lease is written")
+
+		now := time.Now()
+		require.NoError(t, store.CreateLease(ctx, &le.Lease{
+			HolderIdentity: "candidate-1",
+			AcquireTime:    now,
+			RenewTime:      now,
+			LeaseDuration:  30 * time.Second,
+		}))
+		for i := 0; i < 10; i++ {
+			_, err := store.GetLease(ctx)
+			require.NoError(t, err)
+		}
+
+		assert.Len(t, store.stats.samples, 4, "Only the last window latencies should be kept")
+		interval := store.SuggestRenewInterval()
+		assert.GreaterOrEqual(t, interval, 10*time.Second)
+		assert.LessOrEqual(t, interval, 15*time.Second)
+	})
+}
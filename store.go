@@ -3,29 +3,158 @@ package mongoleasestore
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	le "github.com/rbroggi/leaderelection"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Store implements a lease store using MongoDB.
 type Store struct {
-	collection *mongo.Collection
+	collection leaseCollection
 	leaseKey   string // Unique key for the lease.
+	clock      Clock  // Source of "now" for client-side expiry checks.
+	softDelete bool   // Tombstone leases on delete instead of removing them.
+	logger     *slog.Logger
+	readCache  *readCache // Nil unless WithReadCache is set.
+	draining   atomic.Bool
+	// Reject writes whose lease duration differs from the stored one.
+	enforceConsistentDuration bool
+	history                   *mongo.Collection // Nil unless WithHistoryCollection is set.
+	historyRetention          time.Duration     // TTL of history records ensured by NewStore, zero for none.
+	historyTransitionsOnly    bool              // Record changes of holder, not renews.
+	minimalDocument           bool              // Omit fields that can be defaulted on read.
+	onRenew                   func(lease *le.Lease)
+	onWrite                   func(before, after *le.Lease, reason string)
+	collectionOptions         *options.CollectionOptions // Concerns and read preference applied to the collection.
+	region                    string                     // Stamped on every write, empty for none.
+	strictRegion              bool                       // Reject renews from another region than the acquire.
+	expiryPredicate           ExpiryPredicate            // Decides takeability client-side when set.
+	serverTimestamps          bool                       // AcquireLease stamps leases with $$NOW.
+	renewDeadlineGuard        bool                       // Fail renews slower than the lease they renewed.
+	stats                     *latencyStats              // Nil unless WithLatencyStats is set.
+	metrics                   Metrics                    // Nil unless WithMetrics is set.
+	metricsProvider           func() (Metrics, error)    // Nil unless WithMetricsProvider is set.
+	observer                  Observer                   // Nil unless WithObserver is set.
+	tracerProvider            trace.TracerProvider       // Nil unless WithTracerProvider is set.
+	tracer                    trace.Tracer               // From tracerProvider.
+	staleReadPreference       *readpref.ReadPref         // Read preference of staleReads.
+	staleReads                leaseCollection            // Nil unless WithStaleReadPreference is set.
+	confirmReads              leaseCollection            // Primary, majority or linearizable reads of ConfirmLeadership.
+	adminOperations           bool                       // Allow operations that bypass the election.
+	minRenewInterval          time.Duration              // Minimum server time between renews, zero for none.
+	auditLog                  *auditLog                  // Nil unless WithAuditWriter is set.
+	serverClockCheck          *serverClockCheck          // Nil unless WithServerClockMonotonicityCheck is set.
+	acquireWindow             *acquireWindow             // Nil unless WithAcquireWindow is set.
+	grace                     time.Duration              // Added to lease_duration before a lease expires.
+	durationReconciliation    DurationReconciliation     // Applied to renews by UpdateLease.
+	timeout                   time.Duration              // Bounds every collection call, zero for none.
+	lateRenewThreshold        time.Duration              // Renews with less margin are signaled.
+	maxPayloadBytes           int                        // Limit of SetLeaderPayload.
+	maxConcurrency            int                        // Bounds concurrent collection calls, zero for none.
+	retryPolicy               *RetryPolicy               // Nil unless WithRetryPolicy is set.
+	breaker                   *circuitBreaker            // Nil unless WithCircuitBreaker is set.
+	updateUpserts             bool                       // UpdateLease creates a missing lease.
+	unchangedUpdateError      bool                       // UpdateLease reports matched but unmodified updates.
+	transitions               *transitionRate            // Nil unless WithMaxTransitionRate is set.
+	ensureCollection          bool                       // Recreate a dropped collection.
+	indexes                   ensuredIndexes             // Created through the store, restored by ensureCollection.
+	expiresAt                 bool                       // Maintain the expires_at field.
+	expireAfter               time.Duration              // TTL of expires_at ensured by NewStore, zero for none.
+	decisions                 chan<- Decision            // Nil unless WithDecisionRecorder is set.
+	yieldToPreferred          bool                       // Renews yield to the preferred holder.
+	acquireBackoff            *acquireBackoff            // Nil unless WithAcquireBackoffByIdentity is set.
+	encoder                   DocumentEncoder            // Nil for the default document layout.
+	decoder                   DocumentDecoder            // Nil for the default document layout.
+	ownedClient               *mongo.Client              // Disconnected by Close, nil unless created by NewStoreFromURI.
+	releaseOnClose            string                     // Holder Close releases the lease for, empty for none.
+	readOnly                  bool                       // Refuse every write, see WithReadOnly.
+	closeGate                 closeGate                  // Rejects calls to the collections once closed.
+	comment                   string                     // Attached to every operation, empty for none.
 }
 
 type Args struct {
 	LeaseCollection *mongo.Collection
 	LeaseKey        string
+	// ExpireAfter, if positive, makes the store maintain the expires_at field
+	// (see WithExpiresAt) and NewStore ensure a TTL index deleting leases
+	// ExpireAfter past their expiry, so that leases abandoned by crashed
+	// holders do not pile up. It should exceed the grace period, see
+	// WithGracePeriod. Changing it requires dropping the index first.
+	ExpireAfter time.Duration
+	// WriteConcern and ReadConcern, if set, apply to every operation on the
+	// lease collection. A nil WriteConcern defaults to majority with
+	// journaling, see WithWriteConcern; a nil ReadConcern keeps the
+	// collection's own. Options override them.
+	WriteConcern *writeconcern.WriteConcern
+	ReadConcern  *readconcern.ReadConcern
+	// OperationTimeout, if positive, bounds every operation on the lease
+	// collection, see WithOperationTimeout.
+	OperationTimeout time.Duration
 }
 
 // NewStore creates a new Store.
-func NewStore(args Args) (*Store, error) {
+func NewStore(args Args, opts ...Option) (*Store, error) {
 	store := &Store{
-		collection: args.LeaseCollection,
-		leaseKey:   args.LeaseKey,
+		collection:        args.LeaseCollection,
+		leaseKey:          args.LeaseKey,
+		clock:             systemClock{},
+		collectionOptions: options.Collection().SetWriteConcern(defaultWriteConcern()).SetReadConcern(args.ReadConcern),
+		timeout:           args.OperationTimeout,
+		comment:           defaultOperationComment,
+		maxPayloadBytes:   defaultMaxPayloadBytes,
+		expiresAt:         args.ExpireAfter > 0,
+		expireAfter:       args.ExpireAfter,
+	}
+
+	if args.WriteConcern != nil {
+		store.collectionOptions.SetWriteConcern(args.WriteConcern)
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	if err := store.checkCodec(); err != nil {
+		return nil, err
+	}
+	if err := store.provideMetrics(); err != nil {
+		return nil, err
+	}
+
+	if err := store.applyCollectionOptions(); err != nil {
+		return nil, err
+	}
+	if err := store.applyConfirmReads(); err != nil {
+		return nil, err
+	}
+	if err := store.applyStaleReadPreference(); err != nil {
+		return nil, err
+	}
+	store.applyCollectionGuard()
+	store.applyReadOnly()
+	store.applyErrorContext()
+	store.applyMaxConcurrency()
+	store.applyRetryPolicy()
+	store.applyOperationTimeout()
+	store.applyCircuitBreaker()
+	store.applyCloseGate()
+
+	if err := store.ensureTTLIndex(); err != nil {
+		return nil, err
+	}
+	if err := store.ensureHistoryTTLIndex(); err != nil {
+		return nil, err
 	}
 
 	return store, nil
@@ -33,11 +162,43 @@ func NewStore(args Args) (*Store, error) {
 
 // GetLease retrieves the current lease. Should return ErrLeaseNotFound if the
 // lease does not exist.
-func (s *Store) GetLease(ctx context.Context) (*le.Lease, error) {
-	filter := bson.M{"_id": s.leaseKey}
+func (s *Store) GetLease(ctx context.Context) (lease *le.Lease, err error) {
+	defer s.observeOperation("get", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "get", "")
+	defer endSpan(span, &err)
+	defer s.observeGet(time.Now(), &lease, &err)
+	defer setSpanHolder(span, &lease)
 
-	var doc leaseDocument
-	err := s.collection.FindOne(ctx, filter).Decode(&doc)
+	if err := s.checkContext(ctx, "get"); err != nil {
+		return nil, err
+	}
+
+	if lease, ok := s.readCache.get(); ok {
+		return lease, nil
+	}
+
+	generation := s.readCache.current()
+	lease, err = s.fetchLease(ctx, s.readCollection())
+	if err != nil {
+		return nil, err
+	}
+	s.readCache.set(lease, generation)
+
+	return lease, nil
+}
+
+// fetchLease reads the lease from collection, bypassing the read cache.
+func (s *Store) fetchLease(ctx context.Context, collection leaseCollection) (*le.Lease, error) {
+	opts := s.findOneOptions()
+	// Secondaries have clocks of their own, only the primary's is tracked.
+	checkClock := s.serverClockCheck != nil && collection == s.collection
+	if checkClock {
+		opts.SetProjection(serverNowProjection)
+	}
+
+	sent := time.Now()
+	doc, err := s.decodeDocument(collection.FindOne(ctx, s.leaseFilter(), opts))
+	s.stats.observe(sent)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, le.ErrLeaseNotFound
@@ -45,55 +206,347 @@ func (s *Store) GetLease(ctx context.Context) (*le.Lease, error) {
 		return nil, err
 	}
 
-	return doc.toLease(), nil
+	if checkClock && doc.ServerNow != nil {
+		s.checkServerClock(ctx, *doc.ServerNow)
+	}
+
+	lease := doc.toLease()
+	s.observeExpiry(lease)
+	return lease, nil
 }
 
-// UpdateLease updates the lease if the lease exists.
-func (s *Store) UpdateLease(ctx context.Context, newLease *le.Lease) error {
-	filter := bson.M{"_id": s.leaseKey}
-	update := bson.M{"$set": fromLease(s.leaseKey, newLease)}
+// GetLeaseRaw returns the lease document as stored, decoded into a generic map,
+// for tooling that does not depend on le.Lease. Fields unknown to the store are
+// included. The read cache is bypassed.
+func (s *Store) GetLeaseRaw(ctx context.Context) (_ bson.M, err error) {
+	defer s.observeOperation("get", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "get", "")
+	defer endSpan(span, &err)
+
+	if err := s.checkContext(ctx, "get"); err != nil {
+		return nil, err
+	}
 
-	result, err := s.collection.UpdateOne(ctx, filter, update)
+	var doc bson.M
+	err = s.collection.FindOne(ctx, s.leaseFilter(), s.findOneOptions()).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, le.ErrLeaseNotFound
+		}
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// UpdateLease updates the lease if the lease exists and is still in the state
+// newLease was computed from, as the elector computes it from the lease it
+// read: a renew or a release keeps leader_transitions and a takeover
+// increments it. A takeover also requires the lease to be released or expired
+// by the server's clock. Returns ErrLeaseConflict if another write got in
+// between, for example another candidate taking the lease over or the holder
+// renewing it, so that a stale view never overwrites the current holder.
+func (s *Store) UpdateLease(ctx context.Context, newLease *le.Lease) (err error) {
+	defer s.observeOperation("update", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "update", newLease.HolderIdentity)
+	defer endSpan(span, &err)
+	defer s.observeWrite("update", newLease, time.Now(), &err)
+	defer s.recordRejection("update", newLease.HolderIdentity, &err)
+	defer s.logRejection(ctx, "update", newLease.HolderIdentity, &err)
+
+	if err := s.checkContext(ctx, "update"); err != nil {
+		return err
+	}
+	if err := s.checkDrain("update", newLease); err != nil {
+		return err
+	}
+
+	defer s.readCache.invalidate()
+
+	if err := s.yieldIfNotPreferred(ctx, "update", newLease.HolderIdentity); err != nil {
+		return err
+	}
+
+	start := s.clock.Now()
+	filter := s.leaseFilter()
+	newLease, pinned, err := s.adoptStoredDuration(ctx, filter, newLease)
+	if err != nil {
+		return err
+	}
+	conditioned := s.addUpdatePreconditions(filter, newLease) || pinned
+	if s.encoder == nil {
+		// Custom layouts may not store leader_transitions where it can be
+		// queried.
+		s.addExpectedState(filter, newLease)
+		conditioned = true
+	}
+	if s.yieldToPreferred && newLease.HasHolder() {
+		if err := s.addPreferenceCondition(ctx, filter, newLease.HolderIdentity); err != nil {
+			return err
+		}
+		conditioned = true
+	}
+	update, err := s.leaseUpdate(newLease)
+	if err != nil {
+		return err
+	}
+
+	sent := time.Now()
+	outcome, err := s.updateOne(ctx, filter, update, newLease)
+	s.stats.observe(sent)
 	if err != nil {
 		return err
 	}
 
-	if result.ModifiedCount == 0 {
+	if conditioned && !outcome.matched {
+		err := s.explainUpdateMiss(ctx, newLease)
+		if errors.Is(err, le.ErrLeaseNotFound) && s.upsertsUpdate(newLease) {
+			return s.upsertLease(ctx, newLease)
+		}
+		return err
+	}
+	if !outcome.matched && s.upsertsUpdate(newLease) {
+		return s.upsertLease(ctx, newLease)
+	}
+
+	if !outcome.matched {
 		return le.ErrLeaseNotFound
 	}
+	if !outcome.modified && s.unchangedUpdateError {
+		return fmt.Errorf("update lease %q: %w", s.leaseKey, ErrLeaseUnchanged)
+	}
+
+	return s.finishWrite(ctx, "update", newLease, outcome.before, start)
+}
+
+// finishWrite records an applied write of the lease as newLease by op, given
+// its pre-image before (nil if the lease was created or the pre-image was not
+// captured) and the clock time the write started at.
+func (s *Store) finishWrite(ctx context.Context, op string, newLease *le.Lease, before *leaseDocument, start time.Time) error {
+	s.observeExpiry(newLease)
+	if newLease.HasHolder() {
+		s.stats.observeLeaseDuration(newLease.LeaseDuration)
+	}
+
+	reason := updateReason(before, newLease)
+	s.logWrite(ctx, op, newLease, before, reason)
+	if reason == ReasonAcquire || reason == ReasonTakeover {
+		s.observeTransition(ctx, newLease.HolderIdentity)
+	}
+	if s.history != nil {
+		var previous string
+		if before != nil {
+			previous = before.HolderIdentity
+		}
+		s.recordHistory(ctx, reason, previous, newLease, storedTransitions(before, newLease.LeaderTransitions))
+	}
+	s.audit(ctx, op, newLease.HolderIdentity, reason)
+	s.recordDecision(op, newLease.HolderIdentity, decisionOutcome(reason))
+	if s.onWrite != nil {
+		var previous *le.Lease
+		if before != nil {
+			previous = before.toLease()
+		}
+		written := *newLease
+		s.onWrite(previous, &written, reason)
+	}
+	if reason != ReasonRenew {
+		return nil
+	}
+	s.checkLateRenew(ctx, before)
+	if err := s.checkRenewDeadline(ctx, before, start); err != nil {
+		return err
+	}
+	if s.onRenew != nil {
+		renewed := *newLease
+		s.onRenew(&renewed)
+	}
 
 	return nil
 }
 
-// CreateLease creates a new lease if one does not exist.
-func (s *Store) CreateLease(ctx context.Context, newLease *le.Lease) error {
-	_, err := s.collection.InsertOne(ctx, fromLease(s.leaseKey, newLease))
+// updateOutcome is the result of a single lease update.
+type updateOutcome struct {
+	matched  bool
+	modified bool
+	before   *leaseDocument // Pre-image, only captured when needed.
+}
+
+// needsPreImage reports whether anything consumes the pre-image of updates.
+func (s *Store) needsPreImage() bool {
+	return s.history != nil || s.auditLog != nil || s.onRenew != nil || s.onWrite != nil || s.renewDeadlineGuard ||
+		s.lateRenewThreshold > 0 || s.transitions != nil || s.decisions != nil || s.observesTransitions()
+}
+
+// updateOne applies update, which writes newLease, to the document matching
+// filter. The pre-image is captured (at the cost of a findAndModify) only when
+// something consumes it.
+func (s *Store) updateOne(ctx context.Context, filter bson.M, update interface{}, newLease *le.Lease) (updateOutcome, error) {
+	if !s.needsPreImage() {
+		result, err := s.collection.UpdateOne(ctx, filter, update, s.updateOptions())
+		if err != nil {
+			return updateOutcome{}, err
+		}
+		return updateOutcome{
+			matched:  result.MatchedCount > 0,
+			modified: result.ModifiedCount > 0,
+		}, nil
+	}
+
+	opts := s.findOneAndUpdateOptions().SetReturnDocument(options.Before)
+	before, err := s.decodeDocument(s.collection.FindOneAndUpdate(ctx, filter, update, opts))
 	if err != nil {
-		if mongo.IsDuplicateKeyError(err) {
-			return errors.New("lease already exists")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return updateOutcome{}, nil
 		}
+		return updateOutcome{}, err
+	}
+
+	return updateOutcome{matched: true, modified: !s.leavesUnchanged(before, newLease), before: before}, nil
+}
+
+// leavesUnchanged reports whether writing lease over the stored document
+// before leaves it as it was, as the server would have counted it.
+func (s *Store) leavesUnchanged(before *leaseDocument, lease *le.Lease) bool {
+	stored := before.toLease()
+	// Stored with millisecond precision.
+	return stored.HolderIdentity == lease.HolderIdentity &&
+		stored.AcquireTime.Equal(lease.AcquireTime.Truncate(time.Millisecond)) &&
+		stored.RenewTime.Equal(lease.RenewTime.Truncate(time.Millisecond)) &&
+		stored.LeaseDuration == lease.LeaseDuration &&
+		stored.LeaderTransitions == lease.LeaderTransitions &&
+		(s.encoder != nil || before.Region == s.region)
+}
+
+// CreateLease creates a new lease if one does not exist. Returns
+// ErrLeaseAlreadyExists if it does.
+func (s *Store) CreateLease(ctx context.Context, newLease *le.Lease) (err error) {
+	defer s.observeOperation("create", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "create", newLease.HolderIdentity)
+	defer endSpan(span, &err)
+	defer s.observeWrite("create", newLease, time.Now(), &err)
+	defer s.recordRejection("create", newLease.HolderIdentity, &err)
+	defer s.logRejection(ctx, "create", newLease.HolderIdentity, &err)
+
+	if err := s.checkContext(ctx, "create"); err != nil {
+		return err
+	}
+	if newLease.HolderIdentity == "" {
+		// Only UpdateLease can release, creating an unheld lease is a bug.
+		return fmt.Errorf("create lease %q: %w", s.leaseKey, ErrEmptyHolder)
+	}
+	if err := s.checkDrain("create", newLease); err != nil {
 		return err
 	}
 
-	return nil
+	defer s.readCache.invalidate()
+
+	err = s.insertLease(ctx, "create", newLease)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLeaseAlreadyExists
+	}
+	return err
 }
 
+// upsertsUpdate reports whether UpdateLease creates newLease when there is no
+// lease, see WithUpdateUpserts. Releases of a missing lease are not upserted.
+func (s *Store) upsertsUpdate(newLease *le.Lease) bool {
+	return s.updateUpserts && newLease.HasHolder()
+}
+
+// upsertLease creates newLease on behalf of UpdateLease. A lease created
+// concurrently makes it fail with ErrLeaseConflict.
+func (s *Store) upsertLease(ctx context.Context, newLease *le.Lease) error {
+	err := s.insertLease(ctx, "update", newLease)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("update lease %q: %w", s.leaseKey, ErrLeaseConflict)
+	}
+	return err
+}
+
+// insertLease writes newLease as a new lease document for op.
+func (s *Store) insertLease(ctx context.Context, op string, newLease *le.Lease) error {
+	doc, err := s.leaseDocument(newLease)
+	if err != nil {
+		return err
+	}
+
+	start := s.clock.Now()
+	sent := time.Now()
+	if s.softDelete {
+		// Replace a tombstone if there is one, otherwise insert.
+		filter := bson.M{"_id": s.leaseKey, "deleted_at": bson.M{"$exists": true}}
+		_, err = s.collection.ReplaceOne(ctx, filter, doc, s.replaceOptions().SetUpsert(true))
+	} else {
+		_, err = s.collection.InsertOne(ctx, doc, s.insertOneOptions())
+	}
+	s.stats.observe(sent)
+	if err != nil {
+		return err
+	}
+
+	return s.finishWrite(ctx, op, newLease, nil, start)
+}
+
+// leaseFilter matches the live lease document.
+func (s *Store) leaseFilter() bson.M {
+	filter := bson.M{"_id": s.leaseKey}
+	if s.softDelete {
+		filter["deleted_at"] = bson.M{"$exists": false}
+	}
+	return filter
+}
+
+// IsExpired reports whether the lease is past renew_time + lease_duration,
+// plus the grace period set with WithGracePeriod, according to the store's
+// clock, or whether the expiry predicate set with WithExpiryPredicate reports
+// it expired.
+func (s *Store) IsExpired(lease *le.Lease) bool {
+	if s.expiryPredicate != nil {
+		return s.expiryPredicate(lease, s.clock.Now())
+	}
+	return lease.RenewTime.Add(lease.LeaseDuration + s.grace).Before(s.clock.Now())
+}
+
+// leaseDocument is the stored lease. Its fields are marshaled in declaration
+// order, which is the canonical field order of lease documents: every write
+// path produces it, so that identical leases are stored as identical bytes.
+//
+// leader_transitions is decoded as a uint64 so that it does not wrap around
+// at 2^32. Documents written when it was a uint32 need no migration: their
+// int32 or int64 values decode as they are. le.Lease only carries the low 32
+// bits: AcquireLease counts on the server, and UpdateLease compares and
+// replaces the low bits only, keeping the stored ones above. CreateLease
+// starts from the 32 bits it is given. Versions still decoding a uint32 fail
+// to read counts past 2^32, so every store sharing a collection must be
+// upgraded before any lease gets there.
 type leaseDocument struct {
 	ID                string        `bson:"_id"`
 	HolderIdentity    string        `bson:"holder_identity"`
 	AcquireTime       time.Time     `bson:"acquire_time"`
 	RenewTime         time.Time     `bson:"renew_time"`
 	LeaseDuration     time.Duration `bson:"lease_duration"`
-	LeaderTransitions uint32        `bson:"leader_transitions"`
+	LeaderTransitions uint64        `bson:"leader_transitions"`
+	DeletedAt         *time.Time    `bson:"deleted_at,omitempty"`
+	Region            string        `bson:"region,omitempty"`
+	RequestID         string        `bson:"request_id,omitempty"` // Idempotency token of the last acquire.
+	ExpiresAt         *time.Time    `bson:"expires_at,omitempty"` // renew_time + lease_duration, see WithExpiresAt.
+	ServerNow         *time.Time    `bson:"server_now,omitempty"` // Read-only, projected by serverNowProjection.
 }
 
 func (ld *leaseDocument) toLease() *le.Lease {
+	acquireTime := ld.AcquireTime
+	if acquireTime.IsZero() {
+		// Omitted by minimal documents when equal to renew_time.
+		acquireTime = ld.RenewTime
+	}
+
 	return &le.Lease{
 		HolderIdentity:    ld.HolderIdentity,
-		AcquireTime:       ld.AcquireTime,
+		AcquireTime:       acquireTime,
 		RenewTime:         ld.RenewTime,
 		LeaseDuration:     ld.LeaseDuration,
-		LeaderTransitions: ld.LeaderTransitions,
+		LeaderTransitions: uint32(ld.LeaderTransitions), // The low 32 bits, le.Lease has no room for more.
 	}
 }
 
@@ -104,6 +557,121 @@ func fromLease(id string, lease *le.Lease) leaseDocument {
 		AcquireTime:       lease.AcquireTime,
 		RenewTime:         lease.RenewTime,
 		LeaseDuration:     lease.LeaseDuration,
-		LeaderTransitions: lease.LeaderTransitions,
+		LeaderTransitions: uint64(lease.LeaderTransitions),
+	}
+}
+
+// leaseDocument returns the document to insert for lease.
+func (s *Store) leaseDocument(lease *le.Lease) (interface{}, error) {
+	if s.encoder != nil {
+		doc, err := s.encoder(s.leaseKey, lease)
+		if err != nil {
+			return nil, fmt.Errorf("encode lease %q: %w", s.leaseKey, err)
+		}
+		return doc, nil
+	}
+	if s.minimalDocument {
+		doc, _ := s.minimalLease(lease)
+		return doc, nil
+	}
+	doc := fromLease(s.leaseKey, lease)
+	doc.Region = s.region
+	if s.expiresAt {
+		expiresAt := lease.RenewTime.Add(lease.LeaseDuration)
+		doc.ExpiresAt = &expiresAt
+	}
+	return doc, nil
+}
+
+// leaseUpdate returns the update that overwrites the stored lease with lease.
+// It is a pipeline, so that leader_transitions keeps the bits le.Lease has no
+// room for, see transitionsUpdate. Custom layouts are overwritten as encoded.
+func (s *Store) leaseUpdate(lease *le.Lease) (interface{}, error) {
+	doc, err := s.leaseDocument(lease)
+	if err != nil {
+		return nil, err
+	}
+	if s.encoder != nil {
+		return bson.M{"$set": doc}, nil
+	}
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("encode lease %q: %w", s.leaseKey, err)
+	}
+	var fields bson.D
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("encode lease %q: %w", s.leaseKey, err)
+	}
+
+	set := make(bson.D, 0, len(fields)+1)
+	transitions := bson.E{Key: "leader_transitions", Value: transitionsUpdate(lease.LeaderTransitions)}
+	hasTransitions := false
+	for _, field := range fields {
+		switch field.Key {
+		case "_id":
+			// Immutable.
+		case "leader_transitions":
+			set = append(set, transitions)
+			hasTransitions = true
+		default:
+			// Values are not expressions, even if they look like one.
+			set = append(set, bson.E{Key: field.Key, Value: bson.M{"$literal": field.Value}})
+		}
+	}
+	if !hasTransitions {
+		// Omitted by minimal documents when zero.
+		set = append(set, transitions)
 	}
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: set}}}
+
+	if !s.minimalDocument {
+		return pipeline, nil
+	}
+	_, omitted := s.minimalLease(lease)
+	delete(omitted, "leader_transitions")
+	if len(omitted) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$unset", Value: slices.Sorted(maps.Keys(omitted))}})
+	}
+	return append(pipeline, bson.D{{Key: "$set", Value: bson.M{"leader_transitions": bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{"$leader_transitions", 0}}, "$$REMOVE", "$leader_transitions",
+	}}}}}), nil
+}
+
+// minimalLease builds a lease document without the fields that toLease can
+// default: acquire_time when it equals renew_time and leader_transitions when
+// zero. The omitted fields are returned as a $unset specification.
+func (s *Store) minimalLease(lease *le.Lease) (doc bson.D, omitted bson.M) {
+	// Fields are kept in the order of leaseDocument.
+	doc = bson.D{
+		{Key: "_id", Value: s.leaseKey},
+		{Key: "holder_identity", Value: lease.HolderIdentity},
+	}
+	omitted = bson.M{}
+
+	if lease.AcquireTime.Equal(lease.RenewTime) {
+		omitted["acquire_time"] = ""
+	} else {
+		doc = append(doc, bson.E{Key: "acquire_time", Value: lease.AcquireTime})
+	}
+
+	doc = append(doc,
+		bson.E{Key: "renew_time", Value: lease.RenewTime},
+		bson.E{Key: "lease_duration", Value: lease.LeaseDuration},
+	)
+
+	if lease.LeaderTransitions == 0 {
+		omitted["leader_transitions"] = ""
+	} else {
+		doc = append(doc, bson.E{Key: "leader_transitions", Value: lease.LeaderTransitions})
+	}
+
+	if s.region != "" {
+		doc = append(doc, bson.E{Key: "region", Value: s.region})
+	}
+	if s.expiresAt {
+		doc = append(doc, bson.E{Key: "expires_at", Value: lease.RenewTime.Add(lease.LeaseDuration)})
+	}
+
+	return doc, omitted
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -207,11 +208,230 @@ func TestLeaderElection(t *testing.T) {
 	})
 }
 
+func TestEmptyHolder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		write   func(store *Store, ctx context.Context, lease *le.Lease) error
+		wantErr error
+	}{
+		{
+			name:    "acquire is rejected",
+			write:   (*Store).CreateLease,
+			wantErr: ErrEmptyHolder,
+		},
+		{
+			name:  "release is allowed",
+			write: (*Store).UpdateLease,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			collection := newFakeCollection()
+			store := newTestStore(t, collection)
+
+			now := time.Now()
+			require.NoError(t, store.CreateLease(ctx, &le.Lease{
+				HolderIdentity: "candidate-1",
+				AcquireTime:    now,
+				RenewTime:      now,
+				LeaseDuration:  time.Second,
+			}))
+			inserts := collection.callCount("InsertOne")
+
+			err := tt.write(store, ctx, &le.Lease{
+				AcquireTime:   now,
+				RenewTime:     now,
+				LeaseDuration: time.Second,
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, inserts, collection.callCount("InsertOne"), "Mongo should not be contacted")
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestMinimalDocument(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithMinimalDocument())
+	require.NoError(t, err, "Failed to create store")
+
+	// Stored with millisecond precision, expired so that candidate-2 may take
+	// it over.
+	now := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+
+	var raw bson.M
+	require.NoError(t, collection.FindOne(ctx, bson.M{"_id": "test-lease-key"}).Decode(&raw))
+	assert.NotContains(t, raw, "acquire_time", "acquire_time equal to renew_time should be omitted")
+	assert.NotContains(t, raw, "leader_transitions", "Zero leader_transitions should be omitted")
+
+	lease, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.True(t, lease.AcquireTime.Equal(now), "acquire_time should default to renew_time")
+	assert.Zero(t, lease.LeaderTransitions)
+
+	// Non-default values are written, and cleared again when they become
+	// defaults. leader_transitions never does: UpdateLease only writes over
+	// the state newLease was computed from.
+	later := now.Add(time.Second)
+	require.NoError(t, store.UpdateLease(ctx, &le.Lease{
+		HolderIdentity:    "candidate-2",
+		AcquireTime:       now,
+		RenewTime:         later,
+		LeaseDuration:     time.Second,
+		LeaderTransitions: 1,
+	}))
+	lease, err = store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.True(t, lease.AcquireTime.Equal(now))
+	assert.EqualValues(t, 1, lease.LeaderTransitions)
+
+	require.NoError(t, store.UpdateLease(ctx, &le.Lease{
+		HolderIdentity:    "candidate-2",
+		AcquireTime:       later,
+		RenewTime:         later,
+		LeaseDuration:     time.Second,
+		LeaderTransitions: 1,
+	}))
+	raw = nil
+	require.NoError(t, collection.FindOne(ctx, bson.M{"_id": "test-lease-key"}).Decode(&raw))
+	assert.NotContains(t, raw, "acquire_time")
+	assert.Contains(t, raw, "leader_transitions")
+}
+
+func TestGetLeaseRaw(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	_, err = store.GetLeaseRaw(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": "test-lease-key"}, bson.M{"$set": bson.M{"owner_team": "payments"}})
+	require.NoError(t, err)
+
+	raw, err := store.GetLeaseRaw(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "test-lease-key", raw["_id"])
+	assert.Equal(t, "candidate-1", raw["holder_identity"])
+	assert.Equal(t, "payments", raw["owner_team"], "Custom fields should be returned")
+}
+
+func TestUpdateUpserts(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr error
+	}{
+		{name: "disabled", wantErr: le.ErrLeaseNotFound},
+		{name: "enabled", opts: []Option{WithUpdateUpserts()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			collection := newFakeCollection()
+			store := newTestStore(t, collection, tt.opts...)
+
+			err := store.UpdateLease(context.Background(), lease)
+			require.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.Nil(t, collection.doc, "Lease should not be created")
+				return
+			}
+			require.NotNil(t, collection.doc, "Lease should be created")
+			assert.Equal(t, "candidate-1", collection.doc.HolderIdentity)
+
+			released := *lease
+			released.HolderIdentity = ""
+			collection.doc = nil
+			err = store.UpdateLease(context.Background(), &released)
+			require.ErrorIs(t, err, le.ErrLeaseNotFound, "Releases should not be upserted")
+		})
+	}
+}
+
 // setupMongoContainer sets up a MongoDB container using testcontainers-go,
 // initializes a MongoDB client, and registers a graceful shutdown.
 func setupMongoContainer(t *testing.T) *mongo.Client {
 	t.Helper()
 
+	ctx := context.Background()
+	clientOptions := options.Client().ApplyURI(setupMongoURI(t))
+
+	mongoClient, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		t.Fatalf("failed to connect to mongo: %v", err)
+	}
+
+	// Ping the MongoDB server to ensure it's up and running.
+	if err := mongoClient.Ping(ctx, nil); err != nil {
+		t.Fatalf("failed to ping mongo: %v", err)
+	}
+
+	// Register a cleanup function to close the client.
+	t.Cleanup(func() {
+		if err := mongoClient.Disconnect(ctx); err != nil {
+			t.Logf("failed to disconnect mongo client: %v", err)
+		}
+	})
+
+	return mongoClient
+}
+
+// setupMongoURI starts a MongoDB container, stopped when the test ends, and
+// returns its URI.
+func setupMongoURI(t *testing.T) string {
+	t.Helper()
+
 	ctx := context.Background()
 
 	req := testcontainers.ContainerRequest{
@@ -238,31 +458,14 @@ func setupMongoContainer(t *testing.T) *mongo.Client {
 		t.Fatalf("failed to get container host: %v", err)
 	}
 
-	mongoURI := "mongodb://" + hostIP + ":" + mappedPort.Port()
-
-	clientOptions := options.Client().ApplyURI(mongoURI)
-
-	mongoClient, err := mongo.Connect(ctx, clientOptions)
-	if err != nil {
-		t.Fatalf("failed to connect to mongo: %v", err)
-	}
-
-	// Ping the MongoDB server to ensure it's up and running.
-	if err := mongoClient.Ping(ctx, nil); err != nil {
-		t.Fatalf("failed to ping mongo: %v", err)
-	}
-
-	// Register a cleanup function to stop the container and close the client.
+	// Register a cleanup function to stop the container.
 	t.Cleanup(func() {
-		if err := mongoClient.Disconnect(ctx); err != nil {
-			t.Logf("failed to disconnect mongo client: %v", err)
-		}
 		if err := mongoContainer.Terminate(ctx); err != nil {
 			t.Logf("failed to terminate mongo container: %v", err)
 		}
 	})
 
-	return mongoClient
+	return "mongodb://" + hostIP + ":" + mappedPort.Port()
 }
 
 type leaderAndCnl struct {
@@ -290,3 +493,213 @@ func findLeader(electors map[string]leaderAndCnl) string {
 	}
 	return ""
 }
+
+func TestExpiresAtDocument(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithExpiresAt())
+	assert.True(t, store.Config().ExpiresAt)
+
+	lease := &le.Lease{HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now, LeaseDuration: time.Second}
+	require.NoError(t, store.CreateLease(ctx, lease))
+	require.NotNil(t, collection.doc.ExpiresAt, "Created lease should have expires_at")
+	assert.True(t, now.Add(time.Second).Equal(*collection.doc.ExpiresAt))
+
+	renewed := *lease
+	renewed.RenewTime = now.Add(500 * time.Millisecond)
+	renewed.LeaseDuration = 2 * time.Second
+	require.NoError(t, store.UpdateLease(ctx, &renewed))
+	require.NotNil(t, collection.doc.ExpiresAt, "Updated lease should have expires_at")
+	assert.True(t, now.Add(2500*time.Millisecond).Equal(*collection.doc.ExpiresAt))
+}
+
+func TestLeaderTransitionsPast32Bits(t *testing.T) {
+	t.Parallel()
+
+	const transitions = 1<<32 + 5
+	raw, err := bson.Marshal(leaseDocument{ID: "test-lease-key", HolderIdentity: "candidate-1", LeaderTransitions: transitions})
+	require.NoError(t, err)
+
+	var doc leaseDocument
+	require.NoError(t, bson.Unmarshal(raw, &doc))
+	assert.Equal(t, uint64(transitions), doc.LeaderTransitions, "Counts past 2^32 should not wrap")
+	assert.Equal(t, uint32(5), doc.toLease().LeaderTransitions, "le.Lease should carry the low 32 bits")
+
+	// Written when leader_transitions was a uint32.
+	raw, err = bson.Marshal(bson.D{{Key: "_id", Value: "test-lease-key"}, {Key: "leader_transitions", Value: int32(7)}})
+	require.NoError(t, err)
+	require.NoError(t, bson.Unmarshal(raw, &doc))
+	assert.Equal(t, uint64(7), doc.LeaderTransitions, "Older documents should decode as they are")
+
+	before := &leaseDocument{HolderIdentity: "candidate-1", LeaderTransitions: 1<<32 - 1}
+	assert.Equal(t, uint64(1<<32), acquiredTransitions(before, "candidate-2", ""), "A takeover should count past 2^32")
+	assert.Equal(t, uint64(1<<32-1), acquiredTransitions(before, "candidate-1", ""), "A renew should keep the count")
+
+	assert.Equal(t, uint64(1<<32), storedTransitions(before, 0), "A takeover should be recorded past 2^32")
+	assert.Equal(t, uint64(1<<32-1), storedTransitions(before, 1<<32-1), "A renew should record the stored count")
+	assert.Equal(t, uint64(3), storedTransitions(nil, 3), "A created lease should record its count")
+}
+
+func TestUpdateLeasePast32Bits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	stale := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	lease := func(holder string, transitions uint32) *le.Lease {
+		now := time.Now()
+		return &le.Lease{HolderIdentity: holder, AcquireTime: now, RenewTime: now, LeaseDuration: time.Minute, LeaderTransitions: transitions}
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "Default"},
+		{name: "Minimal Document", opts: []Option{WithMinimalDocument()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Counted past 2^32 by AcquireLease.
+			collection := newFakeCollection()
+			collection.doc = &leaseDocument{ID: "test-lease-key", HolderIdentity: "candidate-1", AcquireTime: stale, RenewTime: stale, LeaseDuration: time.Second, LeaderTransitions: 2<<32 - 1}
+			store := newTestStore(t, collection, tt.opts...)
+
+			got, err := store.GetLease(ctx)
+			require.NoError(t, err)
+			require.Equal(t, uint32(1<<32-1), got.LeaderTransitions)
+
+			require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", got.LeaderTransitions+1)), "A takeover should wrap the low bits")
+			assert.Equal(t, uint64(2<<32), collection.doc.LeaderTransitions, "The count should carry into the high bits")
+			require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 0)), "The holder should renew")
+			require.NoError(t, store.UpdateLease(ctx, lease("", 0)), "The holder should release")
+			assert.Equal(t, uint64(2<<32), collection.doc.LeaderTransitions, "Renews and releases should keep the count")
+
+			require.NoError(t, store.UpdateLease(ctx, lease("candidate-3", 1)))
+			assert.Equal(t, uint64(2<<32+1), collection.doc.LeaderTransitions)
+			err = store.UpdateLease(ctx, lease("candidate-1", 1))
+			require.ErrorIs(t, err, ErrLeaseConflict, "A takeover from a stale view should conflict")
+			assert.Equal(t, uint64(2<<32+1), collection.doc.LeaderTransitions, "The count should never go backwards")
+		})
+	}
+}
+
+func TestUpdateLeaseStaleView(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newTestStore(t, newFakeCollection())
+
+	lease := func(holder string, transitions uint32) *le.Lease {
+		// Expired, so that only the transitions decide.
+		now := time.Now().Add(-time.Hour)
+		return &le.Lease{
+			HolderIdentity:    holder,
+			AcquireTime:       now,
+			RenewTime:         now,
+			LeaseDuration:     time.Minute,
+			LeaderTransitions: transitions,
+		}
+	}
+	require.NoError(t, store.CreateLease(ctx, lease("candidate-1", 0)))
+
+	// candidate-2 and candidate-3 both read the lease with 0 transitions and
+	// take it over; only the first write wins.
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)))
+	err := store.UpdateLease(ctx, lease("candidate-3", 1))
+	require.ErrorIs(t, err, ErrLeaseConflict, "A takeover from a stale view should conflict")
+
+	err = store.UpdateLease(ctx, lease("candidate-1", 0))
+	require.ErrorIs(t, err, ErrLeaseConflict, "A renew by the previous holder should conflict")
+	err = store.UpdateLease(ctx, lease("", 0))
+	require.ErrorIs(t, err, ErrLeaseConflict, "A release by the previous holder should conflict")
+
+	current, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "candidate-2", current.HolderIdentity, "The current holder should not be overwritten")
+
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-2", 1)), "The holder should renew")
+	require.NoError(t, store.UpdateLease(ctx, lease("", 1)), "The holder should release")
+	require.NoError(t, store.UpdateLease(ctx, lease("candidate-3", 2)), "A takeover from the current view should succeed")
+}
+
+func TestUpdateLeaseTakeoverAfterRenew(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newTestStore(t, newFakeCollection())
+
+	stale := time.Now().Add(-time.Hour)
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    stale,
+		RenewTime:      stale,
+		LeaseDuration:  time.Minute,
+	}))
+
+	// candidate-2 reads the expired lease, then candidate-1 renews it late.
+	read, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	require.True(t, store.IsExpired(read))
+	now := time.Now()
+	require.NoError(t, store.UpdateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    stale,
+		RenewTime:      now,
+		LeaseDuration:  time.Minute,
+	}))
+
+	err = store.UpdateLease(ctx, &le.Lease{
+		HolderIdentity:    "candidate-2",
+		AcquireTime:       now,
+		RenewTime:         now,
+		LeaseDuration:     time.Minute,
+		LeaderTransitions: read.LeaderTransitions + 1,
+	})
+	require.ErrorIs(t, err, ErrLeaseConflict, "A takeover of a lease renewed since it was read should conflict")
+
+	current, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "candidate-1", current.HolderIdentity, "The holder should keep the lease")
+}
+
+func TestUpdateLeaseUnchanged(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	lease := &le.Lease{HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now, LeaseDuration: time.Second}
+	newStore := func(opts ...Option) *Store {
+		store := newTestStore(t, newFakeCollection(), opts...)
+		require.NoError(t, store.CreateLease(ctx, lease))
+		return store
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		// A renew at the same millisecond writes identical values.
+		require.NoError(t, newStore().UpdateLease(ctx, lease), "An unchanged lease should still be renewed")
+	})
+
+	t.Run("UnchangedUpdateError", func(t *testing.T) {
+		store := newStore(WithUnchangedUpdateError())
+		assert.True(t, store.Config().UnchangedUpdateError)
+		err := store.UpdateLease(ctx, lease)
+		require.ErrorIs(t, err, ErrLeaseUnchanged)
+		assert.NotErrorIs(t, err, le.ErrLeaseNotFound, "An unchanged lease is not a missing one")
+	})
+
+	t.Run("With Pre-Image", func(t *testing.T) {
+		var writes int
+		store := newStore(WithUnchangedUpdateError(), WithOnWrite(func(_, _ *le.Lease, _ string) { writes++ }))
+		require.ErrorIs(t, store.UpdateLease(ctx, lease), ErrLeaseUnchanged, "The pre-image should tell the lease is unchanged")
+		assert.Equal(t, 1, writes, "Only the creation should be reported as a write")
+
+		renewed := *lease
+		renewed.RenewTime = now.Add(time.Millisecond)
+		require.NoError(t, store.UpdateLease(ctx, &renewed))
+		assert.Equal(t, 2, writes)
+	})
+}
@@ -0,0 +1,120 @@
+package mongoleasestore
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithOperationTimeout bounds every operation on the lease collection to
+// timeout, on top of the deadline of the caller's context, so that a stuck
+// primary fails the operation instead of stalling the elector. Reads and
+// find-and-modify commands also carry timeout as maxTimeMS, so that the
+// server stops working on them once the client has given up; other writes
+// are bounded by the client only.
+func WithOperationTimeout(timeout time.Duration) Option {
+	return func(s *Store) {
+		s.timeout = timeout
+	}
+}
+
+// applyOperationTimeout wraps the lease collections with the operation
+// timeout, if any. It must run after the collections are cloned, and after
+// applyMaxConcurrency and applyRetryPolicy so that the timeout covers waiting
+// for a slot and retries.
+func (s *Store) applyOperationTimeout() {
+	if s.timeout <= 0 {
+		return
+	}
+
+	s.collection = &timeoutCollection{leaseCollection: s.collection, timeout: s.timeout}
+	if s.staleReads != nil {
+		s.staleReads = &timeoutCollection{leaseCollection: s.staleReads, timeout: s.timeout}
+	}
+	if s.confirmReads != nil {
+		s.confirmReads = &timeoutCollection{leaseCollection: s.confirmReads, timeout: s.timeout}
+	}
+}
+
+// timeoutCollection bounds every call to the wrapped collection to timeout.
+// Results are read before the call returns, so that they do not outlive the
+// bounded context.
+type timeoutCollection struct {
+	leaseCollection
+	timeout time.Duration
+}
+
+func (c *timeoutCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return settled(c.leaseCollection.FindOne(ctx, filter, opts...))
+}
+
+func (c *timeoutCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	cursor, err := c.leaseCollection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var docs []interface{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return mongo.NewCursorFromDocuments(docs, nil, nil)
+}
+
+func (c *timeoutCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.leaseCollection.CountDocuments(ctx, filter, opts...)
+}
+
+func (c *timeoutCollection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.leaseCollection.InsertOne(ctx, document, opts...)
+}
+
+func (c *timeoutCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.leaseCollection.UpdateOne(ctx, filter, update, opts...)
+}
+
+func (c *timeoutCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return settled(c.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts...))
+}
+
+func (c *timeoutCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.leaseCollection.ReplaceOne(ctx, filter, replacement, opts...)
+}
+
+func (c *timeoutCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.leaseCollection.DeleteOne(ctx, filter, opts...)
+}
+
+func (c *timeoutCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...*options.FindOneAndDeleteOptions) *mongo.SingleResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return settled(c.leaseCollection.FindOneAndDelete(ctx, filter, opts...))
+}
+
+// settled reads result so that it can be decoded after its context is done.
+func settled(result *mongo.SingleResult) *mongo.SingleResult {
+	raw, err := result.Raw()
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	return mongo.NewSingleResultFromDocument(raw, nil, nil)
+}
@@ -0,0 +1,71 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deadlineCollection records the deadline of the last FindOne.
+type deadlineCollection struct {
+	*fakeCollection
+	deadline time.Time
+}
+
+func (c *deadlineCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	c.deadline, _ = ctx.Deadline()
+	return c.fakeCollection.FindOne(ctx, filter, opts...)
+}
+
+func TestOperationTimeout(t *testing.T) {
+	t.Parallel()
+
+	collection := &deadlineCollection{fakeCollection: newFakeCollection()}
+	store := newTestStore(t, collection, WithOperationTimeout(time.Minute))
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(context.Background(), &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+
+	lease, err := store.GetLease(context.Background())
+	require.NoError(t, err, "Result should be readable after the bounded call returns")
+	assert.Equal(t, "candidate-1", lease.HolderIdentity)
+	assert.WithinDuration(t, now.Add(time.Minute), collection.deadline, 5*time.Second, "FindOne should be bounded")
+}
+
+func TestOperationTimeoutMaxTime(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	collection := newFakeCollection()
+	store := newTestStore(t, collection, WithOperationTimeout(time.Minute))
+
+	now := time.Now()
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}))
+	_, err := store.GetLease(ctx)
+	require.NoError(t, err)
+
+	findOpts, ok := collection.lastOptions("FindOne").(*options.FindOneOptions)
+	require.True(t, ok, "FindOne should receive options")
+	require.NotNil(t, findOpts.MaxTime, "FindOne should carry maxTimeMS")
+	assert.Equal(t, time.Minute, *findOpts.MaxTime)
+
+	untimed, err := NewStore(Args{LeaseKey: "test-lease-key"})
+	require.NoError(t, err, "Failed to create store")
+	assert.Nil(t, untimed.findOneOptions().MaxTime, "maxTimeMS should be unset without a timeout")
+}
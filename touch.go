@@ -0,0 +1,103 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Touch renews the lease held by holder by setting its renew_time to the
+// server's current time, in a single conditional write. It is the cheapest
+// renew: the caller computes no timestamps and nothing else is written.
+// Returns ErrLeaseLost if holder does not hold the lease.
+func (s *Store) Touch(ctx context.Context, holder string) (err error) {
+	defer s.observeOperation("touch", time.Now(), &err)
+	ctx, span := s.startSpan(ctx, "touch", holder)
+	defer endSpan(span, &err)
+	defer s.recordRejection("touch", holder, &err)
+	defer s.logRejection(ctx, "touch", holder, &err)
+
+	if err := s.checkContext(ctx, "touch"); err != nil {
+		return err
+	}
+	if err := s.requireDefaultDocument("touch"); err != nil {
+		return err
+	}
+	if holder == "" {
+		return fmt.Errorf("touch lease %q: %w", s.leaseKey, ErrEmptyHolder)
+	}
+	if err := s.checkDrain("touch", &le.Lease{HolderIdentity: holder}); err != nil {
+		return err
+	}
+
+	defer s.readCache.invalidate()
+
+	if err := s.yieldIfNotPreferred(ctx, "touch", holder); err != nil {
+		return err
+	}
+
+	filter := s.leaseFilter()
+	filter["holder_identity"] = holder
+	if s.minRenewInterval > 0 {
+		for k, v := range s.minRenewIntervalElapsed() {
+			filter[k] = v
+		}
+	}
+	update := s.withExpiresAt(mongo.Pipeline{{{Key: "$set", Value: bson.M{"renew_time": "$$NOW"}}}})
+
+	if s.history == nil && s.onRenew == nil {
+		sent := time.Now()
+		result, err := s.collection.UpdateOne(ctx, filter, update, s.updateOptions())
+		s.stats.observe(sent)
+		if err != nil {
+			return fmt.Errorf("touch lease %q: %w", s.leaseKey, err)
+		}
+		if result.MatchedCount == 0 {
+			return s.touchMiss(ctx, holder)
+		}
+		s.audit(ctx, "touch", holder, ReasonRenew)
+		s.recordDecision("touch", holder, DecisionRenewed)
+		return nil
+	}
+
+	// The hooks need the renewed lease.
+	var after leaseDocument
+	opts := s.findOneAndUpdateOptions().SetReturnDocument(options.After)
+	sent := time.Now()
+	err = s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&after)
+	s.stats.observe(sent)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s.touchMiss(ctx, holder)
+	}
+	if err != nil {
+		return fmt.Errorf("touch lease %q: %w", s.leaseKey, err)
+	}
+
+	lease := after.toLease()
+	if s.history != nil {
+		s.recordHistory(ctx, ReasonRenew, holder, lease, after.LeaderTransitions)
+	}
+	s.audit(ctx, "touch", holder, ReasonRenew)
+	s.recordDecision("touch", holder, DecisionRenewed)
+	if s.onRenew != nil {
+		s.onRenew(lease)
+	}
+
+	return nil
+}
+
+// touchMiss explains a Touch by holder that matched nothing.
+func (s *Store) touchMiss(ctx context.Context, holder string) error {
+	if s.minRenewInterval > 0 {
+		if err := s.renewTooSoon(ctx, holder); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("touch lease %q for %q: %w", s.leaseKey, holder, ErrLeaseLost)
+}
@@ -0,0 +1,51 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTouch(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+	ctx := context.Background()
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	})
+	require.NoError(t, err, "Failed to create store")
+
+	stale := time.Now().Add(-time.Hour)
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    stale,
+		RenewTime:      stale,
+		LeaseDuration:  time.Minute,
+	}))
+
+	serverClock := NewClusterClock(mongoClient, time.Minute)
+	require.NoError(t, serverClock.Sync(ctx))
+	serverNow := serverClock.Now()
+
+	require.NoError(t, store.Touch(ctx, "candidate-1"))
+
+	lease, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.WithinDuration(t, serverNow, lease.RenewTime, time.Second, "renew_time should be the server time")
+	assert.WithinDuration(t, stale, lease.AcquireTime, time.Millisecond, "Touch should only write renew_time")
+
+	err = store.Touch(ctx, "candidate-2")
+	require.ErrorIs(t, err, ErrLeaseLost, "Touch by another candidate should be rejected")
+
+	unchanged, err := store.GetLease(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, lease, unchanged)
+}
@@ -0,0 +1,86 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the store's spans.
+const tracerName = "github.com/rbroggi/mongoleasestore"
+
+// Attributes of the store's spans.
+const (
+	attrLeaseKey    = attribute.Key("lease.key")
+	attrLeaseHolder = attribute.Key("lease.holder")
+	attrLeaseResult = attribute.Key("lease.result")
+)
+
+// WithTracerProvider traces every lease operation with an OpenTelemetry span
+// named "mongoleasestore.<op>", from tracers of provider. Spans carry the lease
+// key, the holder the operation is about, if any, and its result: "ok",
+// "not_found", "conflict" or "error". Only errors mark the span as failed;
+// a missing lease or a lost contention is a normal outcome of an election.
+// The span is the parent of the driver's spans when the client is
+// instrumented too. By default operations are not traced.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(s *Store) {
+		s.tracerProvider = provider
+		s.tracer = nil
+		if provider != nil {
+			s.tracer = provider.Tracer(tracerName)
+		}
+	}
+}
+
+// startSpan starts the span of op about holder, empty if unknown, if tracing
+// is enabled. The returned context carries the span, which is nil otherwise.
+func (s *Store) startSpan(ctx context.Context, op, holder string) (context.Context, trace.Span) {
+	if s.tracer == nil {
+		return ctx, nil
+	}
+
+	attrs := []attribute.KeyValue{attrLeaseKey.String(s.leaseKey)}
+	if holder != "" {
+		attrs = append(attrs, attrLeaseHolder.String(holder))
+	}
+	return s.tracer.Start(ctx, "mongoleasestore."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span with the result of its operation. It is meant to be
+// deferred with a pointer to the operation's named error.
+func endSpan(span trace.Span, err *error) {
+	if span == nil {
+		return
+	}
+
+	switch {
+	case *err == nil:
+		span.SetAttributes(attrLeaseResult.String("ok"))
+	case errors.Is(*err, le.ErrLeaseNotFound):
+		span.SetAttributes(attrLeaseResult.String("not_found"))
+	case IsConflict(*err):
+		span.SetAttributes(attrLeaseResult.String("conflict"))
+	default:
+		span.SetAttributes(attrLeaseResult.String("error"))
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// setSpanHolder records the holder of the lease an operation read on span. It
+// is meant to be deferred with a pointer to the operation's named lease, after
+// endSpan.
+func setSpanHolder(span trace.Span, lease **le.Lease) {
+	if span == nil || *lease == nil || (*lease).HolderIdentity == "" {
+		return
+	}
+	span.SetAttributes(attrLeaseHolder.String((*lease).HolderIdentity))
+}
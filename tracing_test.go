@@ -0,0 +1,133 @@
+package mongoleasestore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingTracerProvider records the spans started by its tracers.
+type recordingTracerProvider struct {
+	noop.TracerProvider
+
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+func (p *recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{provider: p}
+}
+
+func (p *recordingTracerProvider) ended() []*recordingSpan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var ended []*recordingSpan
+	for _, span := range p.spans {
+		if span.ended {
+			ended = append(ended, span)
+		}
+	}
+	return ended
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	provider *recordingTracerProvider
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordingSpan{name: name, attrs: make(map[attribute.Key]string)}
+	cfg := trace.NewSpanStartConfig(opts...)
+	for _, attr := range cfg.Attributes() {
+		span.attrs[attr.Key] = attr.Value.Emit()
+	}
+	t.provider.mu.Lock()
+	defer t.provider.mu.Unlock()
+	t.provider.spans = append(t.provider.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// recordingSpan records its attributes and status. It is only accessed by the
+// goroutine of its operation until it ends.
+type recordingSpan struct {
+	noop.Span
+	name   string
+	attrs  map[attribute.Key]string
+	status codes.Code
+	ended  bool
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...attribute.KeyValue) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value.Emit()
+	}
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) {
+	s.status = code
+}
+
+func (s *recordingSpan) End(...trace.SpanEndOption) {
+	s.ended = true
+}
+
+func TestTracerProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	provider := &recordingTracerProvider{}
+	collection := &flakyCollection{fakeCollection: newFakeCollection()}
+	store := newTestStore(t, collection, WithTracerProvider(provider))
+	assert.Equal(t, provider, store.Config().TracerProvider)
+
+	_, err := store.GetLease(ctx)
+	require.ErrorIs(t, err, le.ErrLeaseNotFound)
+
+	now := time.Now()
+	lease := &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  time.Second,
+	}
+	require.NoError(t, store.CreateLease(ctx, lease))
+	_, err = store.GetLease(ctx)
+	require.NoError(t, err)
+
+	collection.mu.Lock()
+	collection.failures, collection.err = 1, assert.AnError
+	collection.mu.Unlock()
+	lease.RenewTime = now.Add(time.Millisecond)
+	require.Error(t, store.UpdateLease(ctx, lease))
+
+	spans := provider.ended()
+	require.Len(t, spans, 4, "Every operation should end its span")
+	tests := []struct {
+		name   string
+		holder string
+		result string
+		status codes.Code
+	}{
+		{name: "mongoleasestore.get", result: "not_found"},
+		{name: "mongoleasestore.create", holder: "candidate-1", result: "ok"},
+		{name: "mongoleasestore.get", holder: "candidate-1", result: "ok"},
+		{name: "mongoleasestore.update", holder: "candidate-1", result: "error", status: codes.Error},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		assert.Equal(t, tt.name, span.name)
+		assert.Equal(t, "test-lease-key", span.attrs[attrLeaseKey])
+		assert.Equal(t, tt.holder, span.attrs[attrLeaseHolder], "Holder of %s", tt.name)
+		assert.Equal(t, tt.result, span.attrs[attrLeaseResult], "Result of %s", tt.name)
+		assert.Equal(t, tt.status, span.status, "Status of %s", tt.name)
+	}
+}
@@ -0,0 +1,60 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	le "github.com/rbroggi/leaderelection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Validate reads the lease and checks that it is internally consistent:
+// holder_identity is set (empty only once released), renew_time is not before
+// acquire_time and lease_duration is positive. Returns ErrInvalidLease
+// describing every violation, or ErrLeaseNotFound if the lease does not exist.
+func (s *Store) Validate(ctx context.Context) error {
+	if err := s.checkContext(ctx, "validate"); err != nil {
+		return err
+	}
+	if err := s.requireDefaultDocument("validate"); err != nil {
+		return err
+	}
+
+	var raw bson.Raw
+	err := s.collection.FindOne(ctx, s.leaseFilter(), s.findOneOptions()).Decode(&raw)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return le.ErrLeaseNotFound
+		}
+		return err
+	}
+
+	var doc leaseDocument
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("%w: lease %q cannot be decoded: %v", ErrInvalidLease, s.leaseKey, err)
+	}
+
+	var violations []string
+	if _, err := raw.LookupErr("holder_identity"); err != nil {
+		violations = append(violations, "holder_identity is missing")
+	}
+	if doc.RenewTime.IsZero() {
+		violations = append(violations, "renew_time is missing")
+	}
+	lease := doc.toLease()
+	if lease.RenewTime.Before(lease.AcquireTime) {
+		violations = append(violations, fmt.Sprintf("renew_time %s is before acquire_time %s", lease.RenewTime, lease.AcquireTime))
+	}
+	if lease.LeaseDuration <= 0 {
+		violations = append(violations, fmt.Sprintf("lease_duration %s is not positive", lease.LeaseDuration))
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: lease %q: %s", ErrInvalidLease, s.leaseKey, strings.Join(violations, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Millisecond)
+	tests := []struct {
+		name    string
+		doc     *leaseDocument
+		wantErr error
+		want    []string
+	}{
+		{
+			name:    "missing",
+			wantErr: le.ErrLeaseNotFound,
+		},
+		{
+			name: "consistent",
+			doc:  &leaseDocument{ID: "test-lease-key", HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now, LeaseDuration: time.Second},
+		},
+		{
+			name: "released",
+			doc:  &leaseDocument{ID: "test-lease-key", AcquireTime: now, RenewTime: now, LeaseDuration: time.Second},
+		},
+		{
+			name:    "inconsistent",
+			doc:     &leaseDocument{ID: "test-lease-key", HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now.Add(-time.Second)},
+			wantErr: ErrInvalidLease,
+			want:    []string{"is before acquire_time", "lease_duration 0s is not positive"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			collection := newFakeCollection()
+			collection.doc = tt.doc
+			store := newTestStore(t, collection)
+
+			err := store.Validate(context.Background())
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+			for _, violation := range tt.want {
+				assert.ErrorContains(t, err, violation)
+			}
+		})
+	}
+}
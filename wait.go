@@ -0,0 +1,45 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// WaitUntilFree blocks until nobody holds the lease: it does not exist, has
+// been released or has expired according to the store's clock (use a
+// ClusterClock to judge expiry by server time). The lease is polled every
+// pollInterval, which must be positive; read errors are retried. Returns
+// ctx.Err() if ctx ends first.
+func (s *Store) WaitUntilFree(ctx context.Context, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		return fmt.Errorf("wait for lease %q: poll interval %v must be positive", s.leaseKey, pollInterval)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.isFree(ctx) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Store) isFree(ctx context.Context) bool {
+	lease, err := s.fetchLease(ctx, s.collection)
+	if err != nil {
+		return errors.Is(err, le.ErrLeaseNotFound)
+	}
+
+	return s.canAcquire(lease, "")
+}
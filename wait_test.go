@@ -0,0 +1,45 @@
+package mongoleasestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitUntilFree(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newTestStore(t, newFakeCollection())
+
+	require.Error(t, store.WaitUntilFree(ctx, 0), "A non-positive poll interval should be rejected")
+
+	leaseDuration := 300 * time.Millisecond
+	now := time.Now().Truncate(time.Millisecond) // Stored with millisecond precision.
+	require.NoError(t, store.CreateLease(ctx, &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  leaseDuration,
+	}))
+
+	t.Run("Held Lease Times Out", func(t *testing.T) {
+		waitCtx, cancel := context.WithTimeout(ctx, leaseDuration/3)
+		defer cancel()
+
+		err := store.WaitUntilFree(waitCtx, 10*time.Millisecond)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Expired Lease Is Free", func(t *testing.T) {
+		waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		require.NoError(t, store.WaitUntilFree(waitCtx, 10*time.Millisecond))
+		assert.GreaterOrEqual(t, time.Since(now), leaseDuration, "Waiter should return only once the lease expired")
+	})
+}
@@ -0,0 +1,231 @@
+package mongoleasestore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+)
+
+// LeaderChange is emitted when the leader of the lease changes.
+type LeaderChange struct {
+	// Previous is the leader before the change, empty for "no leader".
+	Previous string
+	// Current is the leader after the change, empty for "no leader".
+	Current string
+	// Lease is the lease as observed after the change, nil if it does not exist.
+	Lease *le.Lease
+	// ExpiryImminent is set on the events emitted by WithExpiryWarning, while
+	// the leader does not change: Previous and Current are both the leader.
+	ExpiryImminent bool
+}
+
+// WatchOption configures a single WatchLeaderPolling call.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	expiryWarning time.Duration
+}
+
+// WithExpiryWarning makes the watch emit a LeaderChange with ExpiryImminent
+// set once the current lease has lead left before it expires without having
+// been renewed, so that followers can prepare to contend. The lease is read
+// again at that time, regardless of the poll interval.
+func WithExpiryWarning(lead time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.expiryWarning = lead
+	}
+}
+
+// WatchLeaderPolling emits a LeaderChange every time the leader of the lease
+// changes, starting with the leader at the time of the call. An expired or
+// released lease counts as "no leader". The channel is closed once ctx is
+// done or the store is closed.
+//
+// It polls GetLease every interval, so it works against standalone
+// deployments where change streams are unavailable, at the cost of noticing
+// changes up to interval late and missing leaders that came and went between
+// two polls. See WithExpiryWarning to also be told about imminent expiries.
+func (s *Store) WatchLeaderPolling(ctx context.Context, interval time.Duration, opts ...WatchOption) (<-chan LeaderChange, error) {
+	if interval <= 0 {
+		return nil, errors.New("poll interval must be greater than zero")
+	}
+
+	var cfg watchConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, cancel := s.untilClosed(ctx)
+	changes := make(chan LeaderChange, 1)
+	go func() {
+		defer close(changes)
+		defer cancel()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		first := true
+		var leader string
+		var warned time.Time // renew_time of the last lease warned about.
+		for {
+			var warning <-chan time.Time
+			if lease, err := s.GetLease(ctx); err == nil || errors.Is(err, le.ErrLeaseNotFound) {
+				current := s.currentLeader(lease)
+				if first || current != leader {
+					select {
+					case changes <- LeaderChange{Previous: leader, Current: current, Lease: lease}:
+					case <-ctx.Done():
+						return
+					}
+					first = false
+					leader = current
+				}
+
+				if cfg.expiryWarning > 0 && current != "" && !lease.RenewTime.Equal(warned) {
+					expiry := lease.RenewTime.Add(lease.LeaseDuration + s.grace)
+					wait := expiry.Add(-cfg.expiryWarning).Sub(s.clock.Now())
+					if wait <= 0 {
+						select {
+						case changes <- LeaderChange{Previous: current, Current: current, Lease: lease, ExpiryImminent: true}:
+						case <-ctx.Done():
+							return
+						}
+						warned = lease.RenewTime
+					} else {
+						warning = time.After(wait)
+					}
+				}
+			} else if ctx.Err() == nil && s.logger != nil {
+				s.logger.WarnContext(ctx, "failed to poll lease", "lease_key", s.leaseKey, "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-warning:
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// currentLeader returns the holder of lease, or "" if it is missing, released
+// or expired.
+func (s *Store) currentLeader(lease *le.Lease) string {
+	if s.canAcquire(lease, "") {
+		return ""
+	}
+	return lease.HolderIdentity
+}
+
+// WatchLeader emits the holder of the lease every time it changes, starting
+// with the holder at the time of the call once the lease could be read, ""
+// standing for "no leader". It
+// follows the lease with Watch, and notices expiries as they happen rather
+// than waiting for the next write. The channel is closed once ctx is done or
+// the store is closed, or right away if the change stream cannot be opened,
+// which is logged; see WatchLeaderPolling for deployments without change
+// streams.
+func (s *Store) WatchLeader(ctx context.Context) <-chan string {
+	leaders := make(chan string, 1)
+	events, err := s.Watch(ctx)
+	if err != nil {
+		if s.logger != nil {
+			s.logger.WarnContext(ctx, "failed to watch lease leader", "lease_key", s.leaseKey, "error", err)
+		}
+		close(leaders)
+		return leaders
+	}
+
+	go func() {
+		defer close(leaders)
+
+		// Read after opening the stream so that no change falls in between.
+		lease, ok := s.readInitialLease(ctx, events)
+		if !ok {
+			return
+		}
+
+		first := true
+		var leader string
+		var expiry *time.Timer
+		defer func() {
+			if expiry != nil {
+				expiry.Stop()
+			}
+		}()
+		for {
+			if current := s.currentLeader(lease); first || current != leader {
+				select {
+				case leaders <- current:
+				case <-ctx.Done():
+					return
+				}
+				first = false
+				leader = current
+			}
+
+			var expired <-chan time.Time
+			if expiry != nil {
+				expiry.Stop()
+			}
+			if leader != "" {
+				// Past the expiry, only a custom predicate keeps the leader.
+				if wait := lease.RenewTime.Add(lease.LeaseDuration + s.grace).Sub(s.clock.Now()); wait >= 0 {
+					expiry = time.NewTimer(wait)
+					expired = expiry.C
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case event, open := <-events:
+				if !open {
+					return
+				}
+				lease = event.Lease
+			case <-expired:
+			}
+		}
+	}()
+
+	return leaders
+}
+
+// readInitialLease reads the lease WatchLeader starts from, nil if there is
+// none. A failed read is retried with backoff rather than taken for "no
+// leader", unless events reports the lease first. It reports false once ctx
+// is done or events is closed.
+func (s *Store) readInitialLease(ctx context.Context, events <-chan LeaseEvent) (*le.Lease, bool) {
+	delay := watchReconnectMin
+	for {
+		lease, err := s.fetchLease(ctx, s.collection)
+		switch {
+		case err == nil:
+			return lease, true
+		case errors.Is(err, le.ErrLeaseNotFound):
+			return nil, true
+		case ctx.Err() != nil:
+			return nil, false
+		}
+		if s.logger != nil {
+			s.logger.WarnContext(ctx, "failed to read lease leader", "lease_key", s.leaseKey, "error", err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case event, open := <-events:
+			timer.Stop()
+			return event.Lease, open
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, false
+		}
+		delay = min(2*delay, watchReconnectMax)
+	}
+}
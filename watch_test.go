@@ -0,0 +1,184 @@
+package mongoleasestore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	le "github.com/rbroggi/leaderelection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestWatchLeaderPolling(t *testing.T) {
+	t.Parallel()
+
+	mongoClient := setupMongoContainer(t)
+	collection := mongoClient.Database(t.Name()).Collection(t.Name())
+
+	store, err := NewStore(Args{
+		LeaseCollection: collection,
+		LeaseKey:        "test-lease-key",
+	}, WithAdminOperations())
+	require.NoError(t, err, "Failed to create store")
+
+	lease := func(holder string, transitions uint32) *le.Lease {
+		now := time.Now()
+		return &le.Lease{
+			HolderIdentity:    holder,
+			AcquireTime:       now,
+			RenewTime:         now,
+			LeaseDuration:     time.Minute,
+			LeaderTransitions: transitions,
+		}
+	}
+	require.NoError(t, store.CreateLease(context.Background(), lease("candidate-1", 0)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := store.WatchLeaderPolling(ctx, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	next := func() LeaderChange {
+		select {
+		case change := <-changes:
+			return change
+		case <-time.After(2 * time.Second):
+			require.FailNow(t, "Timed out waiting for a leader change")
+			return LeaderChange{}
+		}
+	}
+
+	initial := next()
+	assert.Equal(t, "", initial.Previous)
+	assert.Equal(t, "candidate-1", initial.Current, "Initial leader should be emitted")
+
+	// Renews are not leader changes.
+	require.NoError(t, store.UpdateLease(context.Background(), lease("candidate-1", 0)))
+	require.NoError(t, store.AdminTransfer(context.Background(), "candidate-2"))
+
+	change := next()
+	assert.Equal(t, "candidate-1", change.Previous)
+	assert.Equal(t, "candidate-2", change.Current)
+	require.NotNil(t, change.Lease)
+	assert.EqualValues(t, 1, change.Lease.LeaderTransitions)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, open := <-changes
+		return !open
+	}, time.Second, 10*time.Millisecond, "Channel should be closed on cancel")
+}
+
+func TestWatchLeaderPollingExpiryWarning(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, newFakeCollection())
+
+	now := time.Now()
+	expiry := now.Add(400 * time.Millisecond)
+	require.NoError(t, store.CreateLease(context.Background(), &le.Lease{
+		HolderIdentity: "candidate-1",
+		AcquireTime:    now,
+		RenewTime:      now,
+		LeaseDuration:  400 * time.Millisecond,
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The poll interval alone would notice the expiry too late to warn.
+	changes, err := store.WatchLeaderPolling(ctx, time.Second, WithExpiryWarning(200*time.Millisecond))
+	require.NoError(t, err)
+
+	next := func() LeaderChange {
+		select {
+		case change := <-changes:
+			return change
+		case <-time.After(3 * time.Second):
+			require.FailNow(t, "Timed out waiting for a leader change")
+			return LeaderChange{}
+		}
+	}
+
+	initial := next()
+	assert.Equal(t, "candidate-1", initial.Current)
+	assert.False(t, initial.ExpiryImminent)
+
+	imminent := next()
+	assert.True(t, imminent.ExpiryImminent, "Expiry should be announced")
+	assert.Equal(t, "candidate-1", imminent.Previous)
+	assert.Equal(t, "candidate-1", imminent.Current)
+	assert.True(t, time.Now().Before(expiry), "Expiry should be announced before the lease expires")
+
+	expired := next()
+	assert.False(t, expired.ExpiryImminent)
+	assert.Equal(t, "", expired.Current, "Expired lease should have no leader")
+	assert.True(t, time.Now().After(expiry))
+}
+
+// unreadableCollection fails the next failures calls to FindOne with a
+// network error, every call if failures is negative.
+type unreadableCollection struct {
+	*fakeCollection
+
+	mu       sync.Mutex
+	failures int
+}
+
+func (c *unreadableCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures != 0 {
+		c.failures--
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.CommandError{Code: 6, Labels: []string{"NetworkError"}}, nil)
+	}
+	return c.fakeCollection.FindOne(ctx, filter, opts...)
+}
+
+func TestReadInitialLease(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	lease := &le.Lease{HolderIdentity: "candidate-1", AcquireTime: now, RenewTime: now, LeaseDuration: time.Minute}
+
+	t.Run("Retried", func(t *testing.T) {
+		collection := &unreadableCollection{fakeCollection: newFakeCollection()}
+		store := newTestStore(t, collection)
+		require.NoError(t, store.CreateLease(ctx, lease))
+		collection.failures = 2
+
+		got, ok := store.readInitialLease(ctx, nil)
+		require.True(t, ok)
+		require.NotNil(t, got, "A failed read should not be taken for no leader")
+		assert.Equal(t, "candidate-1", got.HolderIdentity)
+	})
+
+	t.Run("Event First", func(t *testing.T) {
+		collection := &unreadableCollection{fakeCollection: newFakeCollection(), failures: -1}
+		store := newTestStore(t, collection)
+
+		events := make(chan LeaseEvent, 1)
+		events <- LeaseEvent{Type: LeaseUpdated, Lease: lease}
+		got, ok := store.readInitialLease(ctx, events)
+		require.True(t, ok)
+		assert.Same(t, lease, got, "The lease of the first event should be used")
+
+		close(events)
+		_, ok = store.readInitialLease(ctx, events)
+		assert.False(t, ok, "A closed stream should stop the read")
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		collection := &unreadableCollection{fakeCollection: newFakeCollection(), failures: -1}
+		store := newTestStore(t, collection)
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, ok := store.readInitialLease(ctx, nil)
+		assert.False(t, ok)
+	})
+}
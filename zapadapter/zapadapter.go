@@ -0,0 +1,32 @@
+// Package zapadapter routes the logs of a mongoleasestore.Store to a zap
+// logger:
+//
+//	store, err := mongoleasestore.NewStore(args,
+//		mongoleasestore.WithLoggerAdapter(zapadapter.New(logger)))
+package zapadapter
+
+import (
+	"github.com/rbroggi/mongoleasestore"
+	"go.uber.org/zap"
+)
+
+// New returns a mongoleasestore.Logger writing to logger.
+func New(logger *zap.Logger) mongoleasestore.Logger {
+	return adapter{logger: logger.Sugar()}
+}
+
+type adapter struct {
+	logger *zap.SugaredLogger
+}
+
+func (a adapter) Debug(msg string, keysAndValues ...any) {
+	a.logger.Debugw(msg, keysAndValues...)
+}
+
+func (a adapter) Info(msg string, keysAndValues ...any) {
+	a.logger.Infow(msg, keysAndValues...)
+}
+
+func (a adapter) Warn(msg string, keysAndValues ...any) {
+	a.logger.Warnw(msg, keysAndValues...)
+}
@@ -0,0 +1,30 @@
+package zapadapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := New(zap.New(core))
+	logger.Debug("lease acquired", "lease_key", "k", "holder", "h")
+	logger.Info("lease heartbeat")
+	logger.Warn("lease write failed", "attempt", 2)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, "lease acquired", entries[0].Message)
+	assert.Equal(t, map[string]any{"lease_key": "k", "holder": "h"}, entries[0].ContextMap())
+	assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+	assert.Equal(t, zapcore.WarnLevel, entries[2].Level)
+	assert.Equal(t, map[string]any{"attempt": int64(2)}, entries[2].ContextMap())
+}